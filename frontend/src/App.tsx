@@ -3,7 +3,7 @@ import { Box, MantineProvider, useComputedColorScheme } from "@mantine/core";
 import * as Services from "../wailsjs/go/services/Service";
 
 // Hooks - Core layers
-import { useAudioPlayer, usePlaylist, useAudioInterval, usePlaylistActions, useSkipIntervalHandler, useDownloadManager, useAudioEvents, usePlaybackControls, usePlaylistPersistence, useAudioSourceManager, usePlaySong, usePlayModes } from "./hooks/player";
+import { useAudioPlayer, usePlaylist, useAudioInterval, usePlaylistActions, useSkipIntervalHandler, useDownloadManager, useAudioEvents, usePlaybackControls, usePlaylistPersistence, useAudioSourceManager, usePlaySong, usePlayModes, usePlayTracking } from "./hooks/player";
 import { useSongs, useFavorites, useSongCache, useSettingsPersistence } from "./hooks/data";
 
 // Hooks - Features
@@ -21,7 +21,7 @@ import { AppPanels } from "./components/layouts";
 
 // Utils
 import { formatTime, formatTimeLabel, parseTimeLabel, formatTimeWithMs } from "./utils/time";
-import { APP_VERSION, PLACEHOLDER_COVER, DEFAULT_THEMES } from "./utils/constants";
+import { APP_VERSION, PLACEHOLDER_COVER } from "./utils/constants";
 import { LyricMapping, PlayerSetting, Song } from "./types";
 
 // Wails runtime
@@ -212,7 +212,7 @@ const App: React.FC = () => {
 
     const playlistActions = usePlaylistActions({ queue, setQueue, currentIndex, setCurrentIndex, currentSong, setCurrentSong, setIsPlaying, currentFav, favorites, setFavorites, setStatus, setConfirmRemoveSongId, openModal, closeModal, playSong, addCurrentToFavorite, addSongToFavorite, setPendingFavoriteSong, pendingFavoriteSong });
 
-    const themeEditor = useThemeEditor({ themes, setThemes, defaultThemes: DEFAULT_THEMES, currentThemeId, computedColorScheme, saveCachedCustomThemes, applyThemeToUi: store.actions.applyTheme, getCustomThemesFromState: getCustomThemes, editingThemeId, setEditingThemeId, newThemeName, setNewThemeName, themeColorDraft, setThemeColorDraft, backgroundColorDraft, setBackgroundColorDraft, backgroundOpacityDraft, setBackgroundOpacityDraft, backgroundImageUrlDraft, setBackgroundImageUrlDraftSafe, backgroundBlurDraft, setBackgroundBlurDraft, panelColorDraft, setPanelColorDraft, panelOpacityDraft, setPanelOpacityDraft, panelBlurDraft, setPanelBlurDraft, panelRadiusDraft, setPanelRadiusDraft, controlColorDraft, setControlColorDraft, controlOpacityDraft, setControlOpacityDraft, controlBlurDraft, setControlBlurDraft, textColorPrimaryDraft, setTextColorPrimaryDraft, textColorSecondaryDraft, setTextColorSecondaryDraft, favoriteCardColorDraft, setFavoriteCardColorDraft, cardOpacityDraft, setCardOpacityDraft, modalRadiusDraft, setModalRadiusDraft, notificationRadiusDraft, setNotificationRadiusDraft, componentRadiusDraft, setComponentRadiusDraft, coverRadiusDraft, setCoverRadiusDraft, modalColorDraft, setModalColorDraft, modalOpacityDraft, setModalOpacityDraft, modalBlurDraft, setModalBlurDraft, windowControlsPosDraft, setWindowControlsPosDraft, colorSchemeDraft, setColorSchemeDraft, setSavingTheme, openModal, closeModal });
+    const themeEditor = useThemeEditor({ themes, setThemes, currentThemeId, computedColorScheme, saveCachedCustomThemes, applyThemeToUi: store.actions.applyTheme, getCustomThemesFromState: getCustomThemes, editingThemeId, setEditingThemeId, newThemeName, setNewThemeName, themeColorDraft, setThemeColorDraft, backgroundColorDraft, setBackgroundColorDraft, backgroundOpacityDraft, setBackgroundOpacityDraft, backgroundImageUrlDraft, setBackgroundImageUrlDraftSafe, backgroundBlurDraft, setBackgroundBlurDraft, panelColorDraft, setPanelColorDraft, panelOpacityDraft, setPanelOpacityDraft, panelBlurDraft, setPanelBlurDraft, panelRadiusDraft, setPanelRadiusDraft, controlColorDraft, setControlColorDraft, controlOpacityDraft, setControlOpacityDraft, controlBlurDraft, setControlBlurDraft, textColorPrimaryDraft, setTextColorPrimaryDraft, textColorSecondaryDraft, setTextColorSecondaryDraft, favoriteCardColorDraft, setFavoriteCardColorDraft, cardOpacityDraft, setCardOpacityDraft, modalRadiusDraft, setModalRadiusDraft, notificationRadiusDraft, setNotificationRadiusDraft, componentRadiusDraft, setComponentRadiusDraft, coverRadiusDraft, setCoverRadiusDraft, modalColorDraft, setModalColorDraft, modalOpacityDraft, setModalOpacityDraft, modalBlurDraft, setModalBlurDraft, windowControlsPosDraft, setWindowControlsPosDraft, colorSchemeDraft, setColorSchemeDraft, setSavingTheme, openModal, closeModal });
 
     const bvModal = useBVModal({ bvPreview, sliceStart, sliceEnd, bvSongName, bvSinger, bvTargetFavId, selectedFavId, favorites, songs, currentSong, themeColor, setBvModalOpen, setBvPreview, setBvSongName, setBvSinger, setSliceStart, setSliceEnd, setSongs, setFavorites, setSelectedFavId });
 
@@ -285,6 +285,7 @@ const App: React.FC = () => {
     useAppEffects({ intervalStart, intervalEnd, intervalLength, intervalRef, currentSong, songs, setIsDownloaded, downloadedSongIds, setDownloadedSongIds, audioRef, prevSongIdRef });
 
     useAudioEvents({ audioRef, currentSong, queue, currentIndex, playMode, isPlaying, intervalRef: intervalRef as React.MutableRefObject<{ start: number; end: number; length: number }>, setIsPlaying, setProgress, setDuration, setCurrentIndex, setCurrentSong, setStatus, playbackRetryRef, isHandlingErrorRef, upsertSongs: async (arg1: any[]) => Services.UpsertSongs(arg1), playSong, playNext });
+    usePlayTracking({ audioRef, currentSong, currentIndex, favoriteId: selectedFavId });
 
     // ========== Handlers ==========
     const myFavoriteImport = favoriteActions.myFavoriteImport;
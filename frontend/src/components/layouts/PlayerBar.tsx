@@ -135,13 +135,8 @@ const PlayerBar: React.FC<PlayerBarProps> = ({
                 return;
             }
 
-            // 添加歌曲到收藏夹
-            const updatedFavorite = {
-                ...favorite,
-                songIds: [...favorite.songIds, { id: 0, songId: currentSong.id, favoriteId: favorite.id }],
-            };
-
-            await Services.SaveFavorite(updatedFavorite as any);
+            // 添加歌曲到收藏夹末尾
+            await Services.FavoriteAddSongs(favorite.id, [currentSong.id], -1);
             console.log(`成功添加到收藏夹: ${favorite.title}`);
             setShowFavoriteModal(false);
         } catch (error) {
@@ -1,7 +1,7 @@
 import React, { createContext, useContext, useState, useCallback, useEffect, ReactNode, useRef } from 'react';
 import { MantineColorScheme, useComputedColorScheme } from '@mantine/core';
-import { Theme, convertTheme, convertThemes } from '../types';
-import { DEFAULT_THEMES } from '../utils/constants';
+import { Theme, convertTheme } from '../types';
+import { loadCachedThemes } from '../utils/storage';
 
 // ========== 类型定义 ==========
 export interface ThemeState {
@@ -84,20 +84,8 @@ export const ThemeProvider: React.FC<{ children: ReactNode }> = ({ children }) =
 
     // ========== 初始化数据 ==========
     // 从 localStorage 读取缓存的自定义主题
-    const getCachedThemes = (): Theme[] => {
-        try {
-            const saved = localStorage.getItem('half-beat.customThemes');
-            if (saved) {
-                const cached = JSON.parse(saved) as Theme[];
-                return convertThemes(cached);
-            }
-        } catch (e) {
-            console.warn('[ThemeContext] 读取缓存主题失败:', e);
-        }
-        return [];
-    };
-
-    const initialThemes = [...DEFAULT_THEMES, ...getCachedThemes()];
+    // 内置主题由后端提供，这里只用缓存渲染首屏
+    const initialThemes = loadCachedThemes();
 
     useEffect(() => {
         // 清理旧的 localStorage 键，避免与带前缀的新键冲突
@@ -111,7 +99,7 @@ export const ThemeProvider: React.FC<{ children: ReactNode }> = ({ children }) =
     }, []);
 
     // 优先从 localStorage 读取，否则使用系统偏好
-    const getInitialThemeId = (allThemes: (Theme | typeof DEFAULT_THEMES[number])[]): string => {
+    const getInitialThemeId = (allThemes: Theme[]): string => {
         try {
             const saved = localStorage.getItem('half-beat.currentThemeId');
             if (saved && allThemes.find(t => t.id === saved)) {
@@ -125,10 +113,11 @@ export const ThemeProvider: React.FC<{ children: ReactNode }> = ({ children }) =
     };
 
     const initialThemeId = getInitialThemeId(initialThemes);
-    const defaultThemeObj = initialThemes.find(t => t.id === initialThemeId) || DEFAULT_THEMES.find(t => t.id === "light");
-    const defaultTheme = defaultThemeObj ? convertTheme(defaultThemeObj) : convertTheme(DEFAULT_THEMES[0]);
+    // 首次启动尚无缓存时使用占位配置，后端主题加载后再应用
+    const defaultTheme = initialThemes.find(t => t.id === initialThemeId)
+        || convertTheme({ id: initialThemeId, data: JSON.stringify({ colorScheme: computedColorScheme }) });
 
-    const [themes, setThemes] = useState<Theme[]>(convertThemes(initialThemes));
+    const [themes, setThemes] = useState<Theme[]>(initialThemes);
     const [currentThemeId, setCurrentThemeId] = useState<string | null>(defaultTheme.id);
     const [themeColor, setThemeColor] = useState(defaultTheme.themeColor || '#ffffff');
     const [backgroundColor, setBackgroundColor] = useState(defaultTheme.backgroundColor || '#ffffff');
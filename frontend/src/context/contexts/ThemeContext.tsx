@@ -13,8 +13,8 @@ import {
     LayoutConfig,
     ThemeActions,
 } from '../types/contexts';
-import { Theme, convertTheme, convertThemes } from '../../types';
-import { DEFAULT_THEMES } from '../../utils/constants';
+import { Theme, convertTheme } from '../../types';
+import { loadCachedThemes } from '../../utils/storage';
 
 const ThemeContext = createContext<ThemeContextValue | undefined>(undefined);
 
@@ -23,20 +23,8 @@ export const ThemeProvider: React.FC<{ children: ReactNode }> = ({ children }) =
     const computedColorScheme = useComputedColorScheme('light');
 
     // ========== 初始化主题数据 ==========
-    const getCachedThemes = (): Theme[] => {
-        try {
-            const saved = localStorage.getItem('half-beat.customThemes');
-            if (saved) {
-                const cached = JSON.parse(saved) as Theme[];
-                return convertThemes(cached);
-            }
-        } catch (e) {
-            console.warn('[ThemeContext] 读取缓存主题失败:', e);
-        }
-        return [];
-    };
-
-    const initialThemes = [...DEFAULT_THEMES, ...getCachedThemes()];
+    // 内置主题由后端提供，这里只用缓存渲染首屏
+    const initialThemes = loadCachedThemes();
 
     const getInitialThemeId = (): string => {
         try {
@@ -51,11 +39,12 @@ export const ThemeProvider: React.FC<{ children: ReactNode }> = ({ children }) =
     };
 
     const initialThemeId = getInitialThemeId();
-    const defaultThemeObj = initialThemes.find(t => t.id === initialThemeId) || DEFAULT_THEMES.find(t => t.id === "light");
-    const defaultTheme = defaultThemeObj ? convertTheme(defaultThemeObj) : convertTheme(DEFAULT_THEMES[0]);
+    // 首次启动尚无缓存时使用占位配置，后端主题加载后再应用
+    const defaultTheme = initialThemes.find(t => t.id === initialThemeId)
+        || convertTheme({ id: initialThemeId, data: JSON.stringify({ colorScheme: computedColorScheme }) });
 
     // ========== 主题信息状态 ==========
-    const [themes, setThemes] = useState<Theme[]>(convertThemes(initialThemes));
+    const [themes, setThemes] = useState<Theme[]>(initialThemes);
     const [currentThemeId, setCurrentThemeId] = useState<string | null>(defaultTheme.id);
     const [colorScheme, setColorScheme] = useState<'light' | 'dark'>(
         (defaultTheme.colorScheme as 'light' | 'dark') || 'dark'
@@ -161,12 +161,8 @@ export const useBVModal = ({
             if (addedSongs.length > 0 && targetFavId) {
                 const fav = favorites.find((f) => f.id === targetFavId);
                 if (fav) {
-                    const updatedFav = {
-                        ...fav,
-                        songIds: [...fav.songIds, ...addedSongs.map((s) => ({ id: 0, songId: s.id, favoriteId: fav.id }))],
-                    };
                     try {
-                        await Services.SaveFavorite(updatedFav as any);
+                        await Services.FavoriteAddSongs(fav.id, addedSongs.map((s) => s.id), -1);
                     } catch (err) {
                         throw new Error(`保存歌单失败: ${err instanceof Error ? err.message : String(err)}`);
                     }
@@ -209,10 +209,12 @@ export const useFavoriteActions = ({
                 });
                 console.log('[createFavorite] 组装歌单 songIds，共', allSongIds.length, '首');
 
-                // 创建新歌单
+                // 创建新歌单，记录来源收藏夹；后端据此把歌单设为当前账号私有
+                const mediaId = Number(fidToImport);
                 const newFav = {
                     id: "",
                     title: finalName,
+                    biliMediaId: Number.isFinite(mediaId) ? mediaId : 0,
                     songIds: allSongIds.map((songId: string) => ({ id: 0, songId, favoriteId: "" })),
                     createdAt: new Date().toISOString(),
                     updatedAt: new Date().toISOString(),
@@ -266,13 +268,8 @@ export const useFavoriteActions = ({
             return;
         }
 
-        const updated = {
-            ...target,
-            songIds: [...target.songIds, { id: 0, songId: song.id, favoriteId: favId }],
-        };
-
         try {
-            await Services.SaveFavorite(updated as any);
+            await Services.FavoriteAddSongs(favId, [song.id], -1);
             const rawRefreshed = await Services.ListFavorites();
             setFavorites(convertFavorites(rawRefreshed || []));
             notifications.show({
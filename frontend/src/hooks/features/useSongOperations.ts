@@ -115,14 +115,8 @@ export const useSongOperations = ({
                 return;
             }
 
-            // 创建更新后的收藏夹
-            const updatedFavorite = {
-                ...target,
-                songIds: [...target.songIds, { id: 0, songId: song.id, favoriteId: favId }],
-            };
-
-            // 保存到数据库
-            await Services.SaveFavorite(updatedFavorite as any);
+            // 添加到收藏夹末尾
+            await Services.FavoriteAddSongs(favId, [song.id], -1);
 
             // 异步更新UI状态，不阻塞当前操作
             setTimeout(async () => {
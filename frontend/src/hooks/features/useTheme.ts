@@ -6,8 +6,7 @@
 import { useState, useCallback, useRef, useEffect, useMemo } from 'react';
 import { useComputedColorScheme, useMantineColorScheme } from '@mantine/core';
 import type { Theme } from '../../types';
-import { storage, STORAGE_KEYS } from '../../utils/storage';
-import { DEFAULT_THEMES } from '../../utils/constants';
+import { storage, STORAGE_KEYS, loadCachedThemes } from '../../utils/storage';
 
 export interface UseThemeReturn {
     // 主题列表
@@ -43,7 +42,7 @@ export const useTheme = () => {
     const computedColorScheme = useComputedColorScheme('light');
     const skipPersistRef = useRef(false);
 
-    const [themes, setThemes] = useState<Theme[]>(DEFAULT_THEMES);
+    const [themes, setThemes] = useState<Theme[]>(loadCachedThemes);
     const [currentThemeId, setCurrentThemeId] = useState<string>('light');
     const [themeColor, setThemeColor] = useState<string>('#228be6');
     const [backgroundColor, setBackgroundColor] = useState<string>(
@@ -92,7 +91,7 @@ export const useTheme = () => {
     return {
         themes,
         currentThemeId,
-        defaultThemes: DEFAULT_THEMES,
+        defaultThemes: themes.filter((t) => t.isDefault),
         themeColor,
         backgroundColor,
         backgroundOpacity,
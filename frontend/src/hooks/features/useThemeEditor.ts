@@ -4,10 +4,12 @@ import * as Services from '../../../wailsjs/go/services/Service';
 import { Theme } from '../../types';
 import type { ModalStates } from '../ui/useModalManager';
 
+// 内置主题来自后端 ListThemes，编辑自定义主题时原样保留
+const builtinThemesOf = (themes: Theme[]) => themes.filter((t) => t.isDefault);
+
 interface UseThemeEditorProps {
     themes: Theme[];
     setThemes: (themes: Theme[]) => void;
-    defaultThemes: Theme[];
     currentThemeId: string | null;
     computedColorScheme: string;
     saveCachedCustomThemes: (themes: Theme[]) => void;
@@ -75,7 +77,6 @@ interface UseThemeEditorProps {
 export const useThemeEditor = ({
     themes,
     setThemes,
-    defaultThemes,
     currentThemeId,
     computedColorScheme,
     saveCachedCustomThemes,
@@ -181,8 +182,8 @@ export const useThemeEditor = ({
         const currentCustomThemes = getCustomThemesFromState(themes);
         const nextCustom = currentCustomThemes.filter((t: Theme) => t.id !== id);
         saveCachedCustomThemes(nextCustom);
-        setThemes([...defaultThemes, ...nextCustom]);
-    }, [themes, setThemes, saveCachedCustomThemes, getCustomThemesFromState, defaultThemes]);
+        setThemes([...builtinThemesOf(themes), ...nextCustom]);
+    }, [themes, setThemes, saveCachedCustomThemes, getCustomThemesFromState]);
 
     const createThemeClick = useCallback(() => {
         setEditingThemeId(null);
@@ -272,7 +273,7 @@ export const useThemeEditor = ({
                 const currentCustomThemes = getCustomThemesFromState(themes);
                 const nextCustom = currentCustomThemes.map((t: Theme) => (t.id === editingThemeId ? displayTheme : t));
                 saveCachedCustomThemes(nextCustom);
-                setThemes([...defaultThemes, ...nextCustom]);
+                setThemes([...builtinThemesOf(themes), ...nextCustom]);
                 if (currentThemeId === editingThemeId) {
                     applyThemeToUi(displayTheme);
                 }
@@ -301,7 +302,7 @@ export const useThemeEditor = ({
                 const currentCustomThemes = getCustomThemesFromState(themes);
                 const nextCustom = [...currentCustomThemes, displayTheme];
                 saveCachedCustomThemes(nextCustom);
-                setThemes([...defaultThemes, ...nextCustom]);
+                setThemes([...builtinThemesOf(themes), ...nextCustom]);
                 notifications.update({
                     id: toastId,
                     title: "主题已创建",
@@ -333,7 +334,7 @@ export const useThemeEditor = ({
         backgroundColorDraft, backgroundOpacityDraft, backgroundImageUrlDraft, backgroundBlurDraft,
         panelColorDraft, panelOpacityDraft, panelBlurDraft, panelRadiusDraft,
         controlColorDraft, controlOpacityDraft, controlBlurDraft, textColorPrimaryDraft, textColorSecondaryDraft, favoriteCardColorDraft, cardOpacityDraft,
-        componentRadiusDraft, coverRadiusDraft, windowControlsPosDraft, defaultThemes,
+        componentRadiusDraft, coverRadiusDraft, windowControlsPosDraft,
         modalRadiusDraft, notificationRadiusDraft
     ]);
 
@@ -11,6 +11,7 @@ export * from './usePlaylistPersistence';
 export * from './useAudioSourceManager';
 export * from './usePlaySong';
 export * from './usePlayModes';
+export * from './usePlayTracking';
 
 // ========== 新的合并 Hook（推荐使用）==========
 export { usePlayer as usePlayerV2 } from './usePlayerV2';
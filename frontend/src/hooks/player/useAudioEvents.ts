@@ -1,6 +1,7 @@
 import { useEffect, useRef } from 'react';
 import { notifications } from '@mantine/notifications';
 import type { Song } from '../../types';
+import { isProxyUrl } from '../../utils/proxy';
 
 interface UseAudioEventsProps {
     audioRef: React.MutableRefObject<HTMLAudioElement | null>;
@@ -84,7 +85,7 @@ export const useAudioEvents = ({
             }
 
             // 如果是本地文件 404，说明文件已被删除，应该清除本地 URL 并重新获取
-            const isLocalUrl = currentSong?.streamUrl?.includes('127.0.0.1:9999/local');
+            const isLocalUrl = isProxyUrl(currentSong?.streamUrl, '/local');
             if (isLocalUrl && currentSong?.bvid) {
                 const count = (playbackRetryRef.current.get(currentSong.id) ?? 0) + 1;
                 playbackRetryRef.current.set(currentSong.id, count);
@@ -3,6 +3,7 @@ import { notifications } from '@mantine/notifications';
 import type { Song } from '../../types';
 import { convertSongs } from '../../types';
 import * as Services from '../../../wailsjs/go/services/Service';
+import { isProxyUrl } from '../../utils/proxy';
 
 interface UsePlaySongProps {
     queue: Song[];
@@ -102,9 +103,9 @@ export const usePlaySong = ({
 
         const exp: any = (song as any).streamUrlExpiresAt;
         // 检查是否需要刷新URL：无URL、已过期、或不是代理URL（本地文件除外）
-        const isLocalUrl = song.streamUrl?.includes('127.0.0.1:9999/local');
-        const isProxyUrl = song.streamUrl?.includes('127.0.0.1:9999/audio');
-        const expired = !isLocalUrl && (!song.streamUrl || !isProxyUrl || (exp && new Date(exp).getTime() <= Date.now() + 60_000));
+        const isLocalUrl = isProxyUrl(song.streamUrl, '/local');
+        const isAudioProxyUrl = isProxyUrl(song.streamUrl, '/audio');
+        const expired = !isLocalUrl && (!song.streamUrl || !isAudioProxyUrl || (exp && new Date(exp).getTime() <= Date.now() + 60_000));
 
         if (expired && song.bvid) {
             try {
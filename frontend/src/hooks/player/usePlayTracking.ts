@@ -0,0 +1,213 @@
+/**
+ * 播放记录 Hook
+ * 跟踪每首歌的实际收听时长，在播放结束、切歌或退出时上报播放事件；
+ * 每首歌开始播放时通知 scrobble 服务“正在播放”；
+ * 定期保存播放进度（歌曲与队列条目各一份），再次播放同一首歌时从上次的位置继续
+ */
+
+import { useCallback, useEffect, useRef } from 'react';
+import type { Song } from '../../types';
+import * as Services from '../../../wailsjs/go/services/Service';
+import { isProxyUrl } from '../../utils/proxy';
+
+interface UsePlayTrackingProps {
+    audioRef: React.MutableRefObject<HTMLAudioElement | null>;
+    currentSong: Song | null;
+    currentIndex: number;
+    favoriteId: string | null;
+}
+
+interface PlaySession {
+    songId: string;
+    favoriteId: string;
+    queueIndex: number;
+    startedAt: Date;
+    listened: number;
+    lastTime: number;
+    duration: number;
+    source: 'cache' | 'stream';
+}
+
+// 收听不足该秒数（如快速切歌）不记录
+const MIN_RECORD_SECONDS = 1;
+// timeupdate 间隔通常小于 1 秒，更大的跳变视为拖动进度，不计入收听时长
+const MAX_TIME_STEP = 2;
+// 播放中保存进度的间隔（毫秒）
+const RESUME_SAVE_INTERVAL = 10000;
+
+export const usePlayTracking = ({ audioRef, currentSong, currentIndex, favoriteId }: UsePlayTrackingProps) => {
+    const sessionRef = useRef<PlaySession | null>(null);
+    // 待恢复的播放位置，元数据加载后再跳转
+    const pendingResumeRef = useRef<{ songId: string; position: number } | null>(null);
+
+    // 事件处理中读取最新值，避免频繁重新注册监听
+    const currentSongRef = useRef(currentSong);
+    const favoriteIdRef = useRef(favoriteId);
+    const currentIndexRef = useRef(currentIndex);
+    useEffect(() => {
+        currentSongRef.current = currentSong;
+    }, [currentSong]);
+    useEffect(() => {
+        currentIndexRef.current = currentIndex;
+    }, [currentIndex]);
+    useEffect(() => {
+        favoriteIdRef.current = favoriteId;
+    }, [favoriteId]);
+
+    const saveProgress = useCallback((session: PlaySession, position: number) => {
+        if (session.duration > 0) {
+            Services.SaveResumePosition(session.songId, position, session.duration).catch((err) => {
+                console.warn('保存播放进度失败:', err);
+            });
+        }
+        if (session.queueIndex >= 0) {
+            Services.QueueSetProgress(session.queueIndex, position).catch((err) => {
+                console.warn('保存队列进度失败:', err);
+            });
+        }
+    }, []);
+
+    // 结束当前会话并上报；未播完时保存进度（播完由后端清除）
+    const finish = useCallback((completed: boolean, skipped: boolean) => {
+        const session = sessionRef.current;
+        sessionRef.current = null;
+        if (!session) return;
+        if (!completed) {
+            saveProgress(session, session.lastTime);
+        }
+        if (session.listened < MIN_RECORD_SECONDS) return;
+        Services.RecordPlayEvent({
+            songId: session.songId,
+            favoriteId: session.favoriteId,
+            startedAt: session.startedAt.toISOString(),
+            listenedSeconds: session.listened,
+            durationSeconds: session.duration,
+            completed,
+            skipped,
+            source: session.source,
+        } as any).catch((err) => {
+            console.warn('记录播放事件失败:', err);
+        });
+    }, [saveProgress]);
+
+    // 切歌：上一首未播完即视为跳过；读取新歌曲上次的播放位置
+    useEffect(() => {
+        const session = sessionRef.current;
+        if (session && session.songId !== currentSong?.id) {
+            finish(false, true);
+        }
+        pendingResumeRef.current = null;
+        const songId = currentSong?.id;
+        if (!songId) return;
+        let cancelled = false;
+        Services.GetResumePosition(songId)
+            .then((position) => {
+                if (cancelled || position <= 0) return;
+                const audio = audioRef.current;
+                const song = currentSongRef.current;
+                // 新歌曲的音频已加载完元数据则直接跳转，否则等 loadedmetadata
+                const loaded = audio && audio.readyState >= 1 && song?.id === songId && !!song.streamUrl && audio.src === song.streamUrl;
+                if (audio && loaded && position < audio.duration) {
+                    audio.currentTime = position;
+                } else {
+                    pendingResumeRef.current = { songId, position };
+                }
+            })
+            .catch((err) => {
+                console.warn('读取播放进度失败:', err);
+            });
+        return () => {
+            cancelled = true;
+        };
+    }, [currentSong?.id, audioRef, finish]);
+
+    useEffect(() => {
+        const audio = (audioRef.current ||= new Audio());
+
+        const readDuration = () => (Number.isFinite(audio.duration) && audio.duration > 0 ? audio.duration : 0);
+
+        const onPlay = () => {
+            const song = currentSongRef.current;
+            if (!song?.id) return;
+            const session = sessionRef.current;
+            if (session && session.songId === song.id) {
+                // 暂停后继续，从当前位置接着计时
+                session.lastTime = audio.currentTime;
+                return;
+            }
+            sessionRef.current = {
+                songId: song.id,
+                favoriteId: favoriteIdRef.current || '',
+                queueIndex: currentIndexRef.current,
+                startedAt: new Date(),
+                listened: 0,
+                lastTime: audio.currentTime,
+                duration: readDuration(),
+                source: isProxyUrl(song.streamUrl, '/local') ? 'cache' : 'stream',
+            };
+            Services.NotifyNowPlaying(song.id).catch((err) => {
+                console.warn('发送正在播放失败:', err);
+            });
+        };
+
+        const onTime = () => {
+            const session = sessionRef.current;
+            if (!session || audio.paused) return;
+            const t = audio.currentTime;
+            const step = t - session.lastTime;
+            if (step > 0 && step <= MAX_TIME_STEP) {
+                session.listened += step;
+            }
+            session.lastTime = t;
+            session.duration = readDuration() || session.duration;
+        };
+
+        const onLoaded = () => {
+            const pending = pendingResumeRef.current;
+            if (!pending || pending.songId !== currentSongRef.current?.id) return;
+            pendingResumeRef.current = null;
+            if (pending.position < readDuration()) {
+                audio.currentTime = pending.position;
+            }
+        };
+
+        const onPause = () => {
+            const session = sessionRef.current;
+            if (session && !audio.ended) {
+                saveProgress(session, audio.currentTime);
+            }
+        };
+
+        // 播放完成（含播放到区间末尾）；单曲循环重新播放时会开始新的会话
+        const onEnded = () => finish(true, false);
+
+        // 播放中定期保存进度，防止异常退出丢失
+        const timer = window.setInterval(() => {
+            const session = sessionRef.current;
+            if (session && !audio.paused) {
+                saveProgress(session, audio.currentTime);
+            }
+        }, RESUME_SAVE_INTERVAL);
+
+        // 退出应用时上报当前播放，不算跳过
+        const onUnload = () => finish(false, false);
+
+        audio.addEventListener('play', onPlay);
+        audio.addEventListener('timeupdate', onTime);
+        audio.addEventListener('ended', onEnded);
+        audio.addEventListener('loadedmetadata', onLoaded);
+        audio.addEventListener('pause', onPause);
+        window.addEventListener('beforeunload', onUnload);
+
+        return () => {
+            audio.removeEventListener('play', onPlay);
+            audio.removeEventListener('timeupdate', onTime);
+            audio.removeEventListener('ended', onEnded);
+            audio.removeEventListener('loadedmetadata', onLoaded);
+            audio.removeEventListener('pause', onPause);
+            window.removeEventListener('beforeunload', onUnload);
+            window.clearInterval(timer);
+            onUnload();
+        };
+    }, [audioRef, finish, saveProgress]);
+};
@@ -61,11 +61,7 @@ export const usePlaylistActions = ({
     const removeSongFromPlaylist = useCallback(async (song: Song) => {
         if (!currentFav) return;
         try {
-            const updatedFav = {
-                ...currentFav,
-                songIds: currentFav.songIds.filter((ref: any) => ref.songId !== song.id),
-            };
-            await Services.SaveFavorite(updatedFav as any);
+            await Services.FavoriteRemoveSongs(currentFav.id, [song.id]);
             const rawRefreshedFavs = await Services.ListFavorites();
             setFavorites(convertFavorites(rawRefreshedFavs || []));
             setConfirmRemoveSongId(null);
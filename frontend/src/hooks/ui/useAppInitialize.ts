@@ -82,7 +82,7 @@ export function useAppInitialize(options: UseAppInitializeOptions = {}) {
                 }
             }
 
-            const themes = await Services.GetThemes();
+            const themes = await Services.ListThemes();
             if (themes && themes.length > 0) {
                 const defaultTheme = themes.find((t: any) => t.isDefault);
                 if (defaultTheme) {
@@ -1,11 +1,12 @@
 import { useEffect } from "react";
 import type { MutableRefObject } from "react";
 import * as Services from "../../../wailsjs/go/services/Service";
-import { DEFAULT_THEMES } from "../../utils/constants";
 import type { Theme, Favorite, Song } from "../../types";
 import { convertSongs, convertFavorites, convertThemes } from "../../types";
 import type { ModalStates } from './useModalManager';
 import { waitForWailsRuntime } from "../../utils/wails";
+import { initProxy } from "../../utils/proxy";
+import { storage, STORAGE_KEYS } from "../../utils/storage";
 
 interface UseAppLifecycleParams {
     userInfo: any;
@@ -75,17 +76,9 @@ export const useAppLifecycle = ({
         // 跳过初始化期间的持久化，等待设置加载完成
         skipPersistRef.current = true;
 
-        const loadCachedCustomThemes = (): Theme[] => {
-            try {
-                const saved = localStorage.getItem('half-beat.customThemes');
-                if (!saved) return [];
-                const parsed = JSON.parse(saved);
-                if (!Array.isArray(parsed)) return [];
-                return convertThemes(parsed);
-            } catch (e) {
-                console.warn('读取自定义主题缓存失败:', e);
-                return [];
-            }
+        const loadCachedThemeList = (key: string): Theme[] => {
+            const parsed = storage.get<Theme[]>(key);
+            return Array.isArray(parsed) ? convertThemes(parsed) : [];
         };
 
         // 等待 Wails 运行时初始化完成
@@ -99,10 +92,14 @@ export const useAppLifecycle = ({
                 return;
             }
 
+            // 代理端口可能不是默认值，尽早获取实际地址
+            await initProxy();
+
             // 先加载本地主题缓存，避免后端主题加载慢/失败导致自定义主题丢失
-            const cachedCustomThemes = loadCachedCustomThemes();
-            if (cachedCustomThemes.length > 0) {
-                const cachedAllThemes = [...DEFAULT_THEMES, ...cachedCustomThemes];
+            const cachedBuiltinThemes = loadCachedThemeList(STORAGE_KEYS.BUILTIN_THEMES);
+            const cachedCustomThemes = loadCachedThemeList(STORAGE_KEYS.CUSTOM_THEMES);
+            if (cachedBuiltinThemes.length + cachedCustomThemes.length > 0) {
+                const cachedAllThemes = [...cachedBuiltinThemes, ...cachedCustomThemes];
                 setThemes(cachedAllThemes);
 
                 // 尽早应用当前主题（如果本地有记录且能命中）
@@ -139,11 +136,15 @@ export const useAppLifecycle = ({
                     console.warn("检查登录状态失败:", err);
                 });
 
-            const themesPromise = Services.GetThemes();
+            // 内置主题与自定义主题都由后端提供
+            const themesPromise = Services.ListThemes();
 
             Promise.all([Services.GetPlayerSetting(), themesPromise])
-                .then(([s, customThemesList]) => {
-                    const backendCustomThemes = convertThemes(customThemesList || []);
+                .then(([s, themeList]) => {
+                    const backendThemes = convertThemes(themeList || []);
+                    const builtinThemes = backendThemes.filter((t) => t.isDefault);
+                    const backendCustomThemes = backendThemes.filter((t) => !t.isDefault);
+                    storage.set(STORAGE_KEYS.BUILTIN_THEMES, builtinThemes);
 
                     // 仅在后端返回非空列表时更新缓存，避免覆盖掉本地已有的自定义主题
                     if (backendCustomThemes.length > 0) {
@@ -160,20 +161,22 @@ export const useAppLifecycle = ({
                     const mode = validModes.includes(savedMode) ? savedMode : 'loop';
                     setPlayMode(mode as any);
 
-                    const allThemes = [...DEFAULT_THEMES, ...effectiveCustomThemes];
+                    const allThemes = [...builtinThemes, ...effectiveCustomThemes];
                     setThemes(allThemes);
 
                     // 优先从后端配置获取当前主题 ID，如果没有则尝试从 localStorage 获取
                     const preferredThemeId = s.config?.currentThemeId || localStorage.getItem('half-beat.currentThemeId') || "light";
-                    const targetTheme = allThemes.find((t: Theme) => t.id === preferredThemeId) || allThemes[0] || DEFAULT_THEMES[0];
+                    const targetTheme = allThemes.find((t: Theme) => t.id === preferredThemeId) || allThemes[0];
 
-                    // 如果后端记录的主题不存在（例如旧的自定义主题被删除），回退到默认主题并更新后端设置
-                    if (targetTheme.id !== preferredThemeId) {
-                        Services.SetCurrentTheme(targetTheme.id).catch((err) => console.warn("SetCurrentTheme fallback failed", err));
-                    }
+                    if (targetTheme) {
+                        // 如果后端记录的主题不存在（例如旧的自定义主题被删除），回退到默认主题并更新后端设置
+                        if (targetTheme.id !== preferredThemeId) {
+                            Services.SetCurrentTheme(targetTheme.id).catch((err) => console.warn("SetCurrentTheme fallback failed", err));
+                        }
 
-                    // 应用主题到 UI（同步所有字段）
-                    applyThemeToUi(targetTheme);
+                        // 应用主题到 UI（同步所有字段）
+                        applyThemeToUi(targetTheme);
+                    }
 
                     // 设置加载完成，允许后续持久化
                     skipPersistRef.current = false;
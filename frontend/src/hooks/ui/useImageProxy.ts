@@ -1,5 +1,6 @@
 import { useCallback, useEffect, useState } from 'react';
 import { GetImageProxyURL } from '../../api';
+import { buildImageProxyUrl } from '../../utils/proxy';
 
 /**
  * Hook for handling image proxy URLs to bypass CORS restrictions
@@ -44,8 +45,7 @@ export const useImageProxy = () => {
 
         // For synchronous usage, construct the proxy URL directly
         try {
-            const encodedUrl = encodeURIComponent(originalUrl);
-            return `http://127.0.0.1:9999/image?u=${encodedUrl}`;
+            return buildImageProxyUrl(originalUrl);
         } catch (error) {
             console.warn('Failed to construct proxied image URL:', error);
             return originalUrl;
@@ -6,7 +6,6 @@
 import { useCallback, useRef } from 'react';
 import { useMantineColorScheme } from '@mantine/core';
 import { Theme } from '../../types';
-import { DEFAULT_THEMES } from '../../utils/constants';
 
 interface UseThemeManagementProps {
     themes: Theme[];
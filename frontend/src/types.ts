@@ -39,6 +39,9 @@ export interface Favorite {
     id: string;
     title: string;
     songIds: SongRef[];
+    ownerUid: number;     // 所属账号 UID，0 为共享
+    biliMediaId: number;  // 导入来源的 B 站收藏夹 ID
+    sortBy: string;       // 排序方式，空为手动
     createdAt: string;
     updatedAt: string;
 }
@@ -144,6 +147,9 @@ export function convertFavorite(f: any): Favorite {
         id: f.id || '',
         title: f.title || '',
         songIds: (f.songIds || []).map(convertSongRef),
+        ownerUid: f.ownerUid || 0,
+        biliMediaId: f.biliMediaId || 0,
+        sortBy: f.sortBy || '',
         createdAt: f.createdAt?.toString ? f.createdAt.toString() : f.createdAt || '',
         updatedAt: f.updatedAt?.toString ? f.updatedAt.toString() : f.updatedAt || '',
     };
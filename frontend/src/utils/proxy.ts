@@ -0,0 +1,69 @@
+/**
+ * 本地音频代理地址
+ *
+ * 代理端口由后端设置决定（被占用时自动改用空闲端口），
+ * 前端所有代理 URL 都通过这里拼接和识别。
+ */
+import { notifications } from '@mantine/notifications';
+import * as Services from '../../wailsjs/go/services/Service';
+import * as runtime from '../../wailsjs/runtime';
+
+interface ProxyStatus {
+    running: boolean;
+    baseUrl: string;
+    port: number;
+    token: string;
+    error: string;
+}
+
+let proxyBaseUrl = 'http://127.0.0.1:9999';
+let proxyToken = '';
+let initialized = false;
+
+const applyStatus = (status: ProxyStatus | null | undefined) => {
+    if (!status) return;
+    if (status.baseUrl) {
+        proxyBaseUrl = status.baseUrl;
+    }
+    if (status.token) {
+        proxyToken = status.token;
+    }
+    if (status.error) {
+        notifications.show({
+            title: '音频代理启动失败',
+            message: `${status.error}，请在设置中更换代理端口`,
+            color: 'red',
+            autoClose: false,
+        });
+    }
+};
+
+/** 读取代理状态并监听端口变化，应用启动时调用一次 */
+export const initProxy = async () => {
+    if (initialized) return;
+    initialized = true;
+    runtime.EventsOn('proxy:status', (status: ProxyStatus) => applyStatus(status));
+    try {
+        applyStatus(await Services.GetProxyStatus());
+    } catch (e) {
+        console.warn('获取音频代理状态失败:', e);
+    }
+};
+
+export const getProxyBaseUrl = () => proxyBaseUrl;
+
+/**
+ * 是否为当前代理的指定端点（/audio、/local、/image）。
+ * 旧会话保存的 URL 端口或令牌不同，视为失效。
+ */
+export const isProxyUrl = (url: string | undefined | null, endpoint: '/audio' | '/local' | '/image') => {
+    if (!url || !url.startsWith(`${proxyBaseUrl}${endpoint}?`)) return false;
+    try {
+        return new URL(url).searchParams.get('t') === proxyToken;
+    } catch {
+        return false;
+    }
+};
+
+export const buildImageProxyUrl = (originalUrl: string) =>
+    `${proxyBaseUrl}/image?u=${encodeURIComponent(originalUrl)}&t=${proxyToken}`;
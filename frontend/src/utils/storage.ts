@@ -2,9 +2,13 @@
  * localStorage 操作工具函数
  */
 
+import type { Theme } from '../types';
+import { convertThemes } from '../types';
+
 export const STORAGE_KEYS = {
     USER_INFO: 'half-beat.userInfo',
     CUSTOM_THEMES: 'half-beat.customThemes',
+    BUILTIN_THEMES: 'half-beat.builtinThemes',
     SONG_CACHE_PREFIX: 'half-beat.song.',
 } as const;
 
@@ -47,3 +51,16 @@ export const storage = {
         }
     },
 };
+
+/**
+ * 读取缓存的主题列表（内置在前，自定义在后）。
+ * 内置主题由后端 ListThemes 提供，缓存只用于后端返回前的首屏。
+ */
+export const loadCachedThemes = (): Theme[] => {
+    const builtin = storage.get<Theme[]>(STORAGE_KEYS.BUILTIN_THEMES);
+    const custom = storage.get<Theme[]>(STORAGE_KEYS.CUSTOM_THEMES);
+    return convertThemes([
+        ...(Array.isArray(builtin) ? builtin : []),
+        ...(Array.isArray(custom) ? custom : []),
+    ]);
+};
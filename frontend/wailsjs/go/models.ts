@@ -103,6 +103,8 @@ export namespace models {
 	    id: number;
 	    favoriteId: string;
 	    songId: string;
+	    position: number;
+	    addedAt: time.Time;
 	
 	    static createFrom(source: any = {}) {
 	        return new SongRef(source);
@@ -113,12 +115,37 @@ export namespace models {
 	        this.id = source["id"];
 	        this.favoriteId = source["favoriteId"];
 	        this.songId = source["songId"];
+	        this.position = source["position"];
+	        this.addedAt = this.convertValues(source["addedAt"], time.Time);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class Favorite {
 	    id: string;
 	    title: string;
 	    songIds: SongRef[];
+	    ownerUid: number;
+	    biliMediaId: number;
+	    smartRules: string;
+	    readOnly: boolean;
+	    sortBy: string;
 	    createdAt: time.Time;
 	    updatedAt: time.Time;
 	
@@ -131,6 +158,11 @@ export namespace models {
 	        this.id = source["id"];
 	        this.title = source["title"];
 	        this.songIds = this.convertValues(source["songIds"], SongRef);
+	        this.ownerUid = source["ownerUid"];
+	        this.biliMediaId = source["biliMediaId"];
+	        this.smartRules = source["smartRules"];
+	        this.readOnly = source["readOnly"];
+	        this.sortBy = source["sortBy"];
 	        this.createdAt = this.convertValues(source["createdAt"], time.Time);
 	        this.updatedAt = this.convertValues(source["updatedAt"], time.Time);
 	    }
@@ -189,6 +221,54 @@ export namespace models {
 		    return a;
 		}
 	}
+	export class PlayEvent {
+	    id: number;
+	    songId: string;
+	    favoriteId: string;
+	    startedAt: time.Time;
+	    listenedSeconds: number;
+	    durationSeconds: number;
+	    completed: boolean;
+	    skipped: boolean;
+	    source: string;
+	    createdAt: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlayEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.songId = source["songId"];
+	        this.favoriteId = source["favoriteId"];
+	        this.startedAt = this.convertValues(source["startedAt"], time.Time);
+	        this.listenedSeconds = source["listenedSeconds"];
+	        this.durationSeconds = source["durationSeconds"];
+	        this.completed = source["completed"];
+	        this.skipped = source["skipped"];
+	        this.source = source["source"];
+	        this.createdAt = this.convertValues(source["createdAt"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class PlayerSetting {
 	    id: number;
 	    config: Record<string, any>;
@@ -225,8 +305,12 @@ export namespace models {
 	}
 	export class Playlist {
 	    id: number;
+	    name: string;
 	    queue: string;
 	    currentIndex: number;
+	    repeatMode: string;
+	    shuffled: boolean;
+	    shuffleSeed: number;
 	    updatedAt: time.Time;
 	
 	    static createFrom(source: any = {}) {
@@ -236,8 +320,12 @@ export namespace models {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
+	        this.name = source["name"];
 	        this.queue = source["queue"];
 	        this.currentIndex = source["currentIndex"];
+	        this.repeatMode = source["repeatMode"];
+	        this.shuffled = source["shuffled"];
+	        this.shuffleSeed = source["shuffleSeed"];
 	        this.updatedAt = this.convertValues(source["updatedAt"], time.Time);
 	    }
 	
@@ -259,6 +347,90 @@ export namespace models {
 		    return a;
 		}
 	}
+	export class QueueEntry {
+	    id: number;
+	    playlistId: number;
+	    position: number;
+	    originalPosition: number;
+	    songId: string;
+	    favoriteId: string;
+	    addedBy: string;
+	    addedAt: time.Time;
+	    progressSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.playlistId = source["playlistId"];
+	        this.position = source["position"];
+	        this.originalPosition = source["originalPosition"];
+	        this.songId = source["songId"];
+	        this.favoriteId = source["favoriteId"];
+	        this.addedBy = source["addedBy"];
+	        this.addedAt = this.convertValues(source["addedAt"], time.Time);
+	        this.progressSeconds = source["progressSeconds"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueueHistoryEntry {
+	    id: number;
+	    playlistId: number;
+	    songId: string;
+	    favoriteId: string;
+	    playedAt: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueHistoryEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.playlistId = source["playlistId"];
+	        this.songId = source["songId"];
+	        this.favoriteId = source["favoriteId"];
+	        this.playedAt = this.convertValues(source["playedAt"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class Song {
 	    id: string;
 	    bvid: string;
@@ -334,6 +506,8 @@ export namespace models {
 	    data: string;
 	    isDefault: boolean;
 	    isReadOnly: boolean;
+	    createdAt: time.Time;
+	    updatedAt: time.Time;
 	
 	    static createFrom(source: any = {}) {
 	        return new Theme(source);
@@ -346,29 +520,103 @@ export namespace models {
 	        this.data = source["data"];
 	        this.isDefault = source["isDefault"];
 	        this.isReadOnly = source["isReadOnly"];
+	        this.createdAt = this.convertValues(source["createdAt"], time.Time);
+	        this.updatedAt = this.convertValues(source["updatedAt"], time.Time);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 
 }
 
 export namespace services {
 	
-	export class ExportData {
-	    songs: models.Song[];
+	export class Account {
+	    id: number;
+	    uid: number;
+	    username: string;
+	    face: string;
+	    active: boolean;
+	    savedAt: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new Account(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.uid = source["uid"];
+	        this.username = source["username"];
+	        this.face = source["face"];
+	        this.active = source["active"];
+	        this.savedAt = this.convertValues(source["savedAt"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ArtistStat {
+	    singer: string;
+	    plays: number;
+	    listenedSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArtistStat(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.singer = source["singer"];
+	        this.plays = source["plays"];
+	        this.listenedSeconds = source["listenedSeconds"];
+	    }
+	}
+	export class AzusaImportResult {
 	    favorites: models.Favorite[];
-	    settings: models.PlayerSetting;
-	    lyrics: models.LyricMapping[];
+	    songsCreated: number;
+	    songsUpdated: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new ExportData(source);
+	        return new AzusaImportResult(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.songs = this.convertValues(source["songs"], models.Song);
 	        this.favorites = this.convertValues(source["favorites"], models.Favorite);
-	        this.settings = this.convertValues(source["settings"], models.PlayerSetting);
-	        this.lyrics = this.convertValues(source["lyrics"], models.LyricMapping);
+	        this.songsCreated = source["songsCreated"];
+	        this.songsUpdated = source["songsUpdated"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -389,54 +637,60 @@ export namespace services {
 		    return a;
 		}
 	}
-	export class LoginPollResponse {
-	    loggedIn: boolean;
-	    message: string;
+	export class BackupImportResult {
+	    schemaVersion: number;
+	    migrated: boolean;
+	    tables: Record<string, number>;
+	    files: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new LoginPollResponse(source);
+	        return new BackupImportResult(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.loggedIn = source["loggedIn"];
-	        this.message = source["message"];
+	        this.schemaVersion = source["schemaVersion"];
+	        this.migrated = source["migrated"];
+	        this.tables = source["tables"];
+	        this.files = source["files"];
 	    }
 	}
-	export class PlayHistory {
-	    favoriteId: string;
-	    songId: string;
-	    timestamp: number;
+	export class BackupManifestFile {
+	    name: string;
+	    size: number;
+	    sha256: string;
+	    entries?: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new PlayHistory(source);
+	        return new BackupManifestFile(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.favoriteId = source["favoriteId"];
-	        this.songId = source["songId"];
-	        this.timestamp = source["timestamp"];
+	        this.name = source["name"];
+	        this.size = source["size"];
+	        this.sha256 = source["sha256"];
+	        this.entries = source["entries"];
 	    }
 	}
-	export class PlayInfo {
-	    RawURL: string;
-	    ProxyURL: string;
-	    ExpiresAt: time.Time;
-	    Title: string;
-	    Duration: number;
+	export class BackupManifest {
+	    format: string;
+	    schemaVersion: number;
+	    appVersion: string;
+	    createdAt: time.Time;
+	    files: BackupManifestFile[];
 	
 	    static createFrom(source: any = {}) {
-	        return new PlayInfo(source);
+	        return new BackupManifest(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.RawURL = source["RawURL"];
-	        this.ProxyURL = source["ProxyURL"];
-	        this.ExpiresAt = this.convertValues(source["ExpiresAt"], time.Time);
-	        this.Title = source["Title"];
-	        this.Duration = source["Duration"];
+	        this.format = source["format"];
+	        this.schemaVersion = source["schemaVersion"];
+	        this.appVersion = source["appVersion"];
+	        this.createdAt = this.convertValues(source["createdAt"], time.Time);
+	        this.files = this.convertValues(source["files"], BackupManifestFile);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -457,20 +711,880 @@ export namespace services {
 		    return a;
 		}
 	}
-	export class QRCodeResponse {
-	    url: string;
-	    qrcode_key: string;
-	    expire_at: time.Time;
+	
+	export class BackupOptions {
+	    includeCovers: boolean;
+	    includeDownloads: boolean;
 	
 	    static createFrom(source: any = {}) {
-	        return new QRCodeResponse(source);
+	        return new BackupOptions(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.url = source["url"];
-	        this.qrcode_key = source["qrcode_key"];
-	        this.expire_at = this.convertValues(source["expire_at"], time.Time);
+	        this.includeCovers = source["includeCovers"];
+	        this.includeDownloads = source["includeDownloads"];
+	    }
+	}
+	export class CaptchaResult {
+	    token: string;
+	    challenge: string;
+	    validate: string;
+	    seccode: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CaptchaResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.token = source["token"];
+	        this.challenge = source["challenge"];
+	        this.validate = source["validate"];
+	        this.seccode = source["seccode"];
+	    }
+	}
+	export class DBBackup {
+	    name: string;
+	    path: string;
+	    size: number;
+	    createdAt: time.Time;
+	    kind: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DBBackup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.createdAt = this.convertValues(source["createdAt"], time.Time);
+	        this.kind = source["kind"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DBBackupConfig {
+	    enabled: boolean;
+	    intervalHours: number;
+	    keepDaily: number;
+	    keepWeekly: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DBBackupConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.intervalHours = source["intervalHours"];
+	        this.keepDaily = source["keepDaily"];
+	        this.keepWeekly = source["keepWeekly"];
+	    }
+	}
+	export class DuplicateGroup {
+	    reasons: string[];
+	    songs: models.Song[];
+	    survivor: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DuplicateGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.reasons = source["reasons"];
+	        this.songs = this.convertValues(source["songs"], models.Song);
+	        this.survivor = source["survivor"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ExportData {
+	    songs: models.Song[];
+	    favorites: models.Favorite[];
+	    settings: models.PlayerSetting;
+	    lyrics: models.LyricMapping[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportData(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.songs = this.convertValues(source["songs"], models.Song);
+	        this.favorites = this.convertValues(source["favorites"], models.Favorite);
+	        this.settings = this.convertValues(source["settings"], models.PlayerSetting);
+	        this.lyrics = this.convertValues(source["lyrics"], models.LyricMapping);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FavoriteSortDef {
+	    key: string;
+	    description: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FavoriteSortDef(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.description = source["description"];
+	    }
+	}
+	export class HeatmapDay {
+	    date: string;
+	    plays: number;
+	    listenedSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HeatmapDay(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = source["date"];
+	        this.plays = source["plays"];
+	        this.listenedSeconds = source["listenedSeconds"];
+	    }
+	}
+	export class ImportReportItem {
+	    kind: string;
+	    id: string;
+	    name: string;
+	    action: string;
+	    reason?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportReportItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.action = source["action"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class ImportReportCounts {
+	    added: number;
+	    updated: number;
+	    skipped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportReportCounts(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.added = source["added"];
+	        this.updated = source["updated"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+	export class ImportReport {
+	    dryRun: boolean;
+	    songs: ImportReportCounts;
+	    favorites: ImportReportCounts;
+	    lyrics: ImportReportCounts;
+	    items: ImportReportItem[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dryRun = source["dryRun"];
+	        this.songs = this.convertValues(source["songs"], ImportReportCounts);
+	        this.favorites = this.convertValues(source["favorites"], ImportReportCounts);
+	        this.lyrics = this.convertValues(source["lyrics"], ImportReportCounts);
+	        this.items = this.convertValues(source["items"], ImportReportItem);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class LibraryIssue {
+	    check: string;
+	    severity: string;
+	    message: string;
+	    count: number;
+	    samples: string[];
+	    repairable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LibraryIssue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.check = source["check"];
+	        this.severity = source["severity"];
+	        this.message = source["message"];
+	        this.count = source["count"];
+	        this.samples = source["samples"];
+	        this.repairable = source["repairable"];
+	    }
+	}
+	export class LibraryHealthReport {
+	    integrityOk: boolean;
+	    integrity: string[];
+	    issues: LibraryIssue[];
+	    checkedAt: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new LibraryHealthReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.integrityOk = source["integrityOk"];
+	        this.integrity = source["integrity"];
+	        this.issues = this.convertValues(source["issues"], LibraryIssue);
+	        this.checkedAt = this.convertValues(source["checkedAt"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class LibraryRepairResult {
+	    dryRun: boolean;
+	    fixed: Record<string, number>;
+	    before: LibraryHealthReport;
+	    after: LibraryHealthReport;
+	
+	    static createFrom(source: any = {}) {
+	        return new LibraryRepairResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dryRun = source["dryRun"];
+	        this.fixed = source["fixed"];
+	        this.before = this.convertValues(source["before"], LibraryHealthReport);
+	        this.after = this.convertValues(source["after"], LibraryHealthReport);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ListeningSummary {
+	    plays: number;
+	    completedPlays: number;
+	    skippedPlays: number;
+	    skipRate: number;
+	    listenedSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ListeningSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.plays = source["plays"];
+	        this.completedPlays = source["completedPlays"];
+	        this.skippedPlays = source["skippedPlays"];
+	        this.skipRate = source["skipRate"];
+	        this.listenedSeconds = source["listenedSeconds"];
+	    }
+	}
+	export class LoginCaptcha {
+	    token: string;
+	    gt: string;
+	    challenge: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LoginCaptcha(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.token = source["token"];
+	        this.gt = source["gt"];
+	        this.challenge = source["challenge"];
+	    }
+	}
+	export class LoginPollResponse {
+	    loggedIn: boolean;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LoginPollResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.loggedIn = source["loggedIn"];
+	        this.message = source["message"];
+	    }
+	}
+	export class MergeSongsResult {
+	    survivor: string;
+	    deleted: string[];
+	    refsMoved: number;
+	    refsRemoved: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeSongsResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.survivor = source["survivor"];
+	        this.deleted = source["deleted"];
+	        this.refsMoved = source["refsMoved"];
+	        this.refsRemoved = source["refsRemoved"];
+	    }
+	}
+	export class PaletteSwatch {
+	    color: string;
+	    population: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PaletteSwatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.color = source["color"];
+	        this.population = source["population"];
+	    }
+	}
+	export class PlayHistory {
+	    favoriteId: string;
+	    songId: string;
+	    timestamp: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlayHistory(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.favoriteId = source["favoriteId"];
+	        this.songId = source["songId"];
+	        this.timestamp = source["timestamp"];
+	    }
+	}
+	export class PlayInfo {
+	    RawURL: string;
+	    ProxyURL: string;
+	    ExpiresAt: time.Time;
+	    Title: string;
+	    Duration: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlayInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.RawURL = source["RawURL"];
+	        this.ProxyURL = source["ProxyURL"];
+	        this.ExpiresAt = this.convertValues(source["ExpiresAt"], time.Time);
+	        this.Title = source["Title"];
+	        this.Duration = source["Duration"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PlaylistImportResult {
+	    favorite: models.Favorite;
+	    matched: number;
+	    created: number;
+	    skipped: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PlaylistImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.favorite = this.convertValues(source["favorite"], models.Favorite);
+	        this.matched = source["matched"];
+	        this.created = source["created"];
+	        this.skipped = source["skipped"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProxyStatus {
+	    running: boolean;
+	    baseUrl: string;
+	    port: number;
+	    token: string;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProxyStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.running = source["running"];
+	        this.baseUrl = source["baseUrl"];
+	        this.port = source["port"];
+	        this.token = source["token"];
+	        this.error = source["error"];
+	    }
+	}
+	export class QRCodeResponse {
+	    url: string;
+	    qrcode_key: string;
+	    expire_at: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new QRCodeResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.qrcode_key = source["qrcode_key"];
+	        this.expire_at = this.convertValues(source["expire_at"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueueSnapshot {
+	    id: number;
+	    name: string;
+	    songCount: number;
+	    currentIndex: number;
+	    updatedAt: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueSnapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.songCount = source["songCount"];
+	        this.currentIndex = source["currentIndex"];
+	        this.updatedAt = this.convertValues(source["updatedAt"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueueState {
+	    entries: models.QueueEntry[];
+	    currentIndex: number;
+	    repeatMode: string;
+	    shuffled: boolean;
+	    shuffleSeed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = this.convertValues(source["entries"], models.QueueEntry);
+	        this.currentIndex = source["currentIndex"];
+	        this.repeatMode = source["repeatMode"];
+	        this.shuffled = source["shuffled"];
+	        this.shuffleSeed = source["shuffleSeed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecentPlay {
+	    song: models.Song;
+	    favoriteId: string;
+	    playedAt: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecentPlay(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.song = this.convertValues(source["song"], models.Song);
+	        this.favoriteId = source["favoriteId"];
+	        this.playedAt = this.convertValues(source["playedAt"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ScrobbleConfig {
+	    enabled: boolean;
+	    protocol: string;
+	    serverUrl: string;
+	    token: string;
+	    apiKey: string;
+	    apiSecret: string;
+	    sessionKey: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScrobbleConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.protocol = source["protocol"];
+	        this.serverUrl = source["serverUrl"];
+	        this.token = source["token"];
+	        this.apiKey = source["apiKey"];
+	        this.apiSecret = source["apiSecret"];
+	        this.sessionKey = source["sessionKey"];
+	    }
+	}
+	export class SettingDef {
+	    key: string;
+	    type: string;
+	    default: any;
+	    min?: number;
+	    max?: number;
+	    options?: string[];
+	    category: string;
+	    description: string;
+	    internal: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingDef(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.type = source["type"];
+	        this.default = source["default"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	        this.options = source["options"];
+	        this.category = source["category"];
+	        this.description = source["description"];
+	        this.internal = source["internal"];
+	    }
+	}
+	export class SmartRule {
+	    field: string;
+	    value?: string;
+	    number?: number;
+	    max?: number;
+	    negate?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SmartRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.value = source["value"];
+	        this.number = source["number"];
+	        this.max = source["max"];
+	        this.negate = source["negate"];
+	    }
+	}
+	export class SmartRuleDef {
+	    field: string;
+	    input: string;
+	    description: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SmartRuleDef(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.input = source["input"];
+	        this.description = source["description"];
+	    }
+	}
+	export class SmartRuleSet {
+	    match: string;
+	    rules: SmartRule[];
+	    limit?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SmartRuleSet(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.match = source["match"];
+	        this.rules = this.convertValues(source["rules"], SmartRule);
+	        this.limit = source["limit"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SongStat {
+	    song: models.Song;
+	    plays: number;
+	    listenedSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SongStat(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.song = this.convertValues(source["song"], models.Song);
+	        this.plays = source["plays"];
+	        this.listenedSeconds = source["listenedSeconds"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TextImportCandidate {
+	    line: string;
+	    title: string;
+	    artist: string;
+	    match?: models.Song;
+	    alternatives: models.Song[];
+	    confidence: number;
+	    needsReview: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TextImportCandidate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.title = source["title"];
+	        this.artist = source["artist"];
+	        this.match = this.convertValues(source["match"], models.Song);
+	        this.alternatives = this.convertValues(source["alternatives"], models.Song);
+	        this.confidence = source["confidence"];
+	        this.needsReview = source["needsReview"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -1,17 +1,23 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {services} from '../models';
 import {time} from '../models';
 import {models} from '../models';
-import {services} from '../models';
 import {http} from '../models';
 import {context} from '../models';
 
+export function BackupDatabaseNow():Promise<services.DBBackup>;
+
 export function ClearAudioCache():Promise<void>;
 
 export function ClearLibrary():Promise<void>;
 
+export function ClearResumePosition(arg1:string):Promise<void>;
+
 export function CloseWindow():Promise<void>;
 
+export function ConnectLastFM(arg1:string,arg2:string):Promise<void>;
+
 export function CreateStreamSource(arg1:string,arg2:string,arg3:time.Time):Promise<string>;
 
 export function CreateTheme(arg1:models.Theme):Promise<models.Theme>;
@@ -20,32 +26,70 @@ export function DeleteDownloadedSong(arg1:string):Promise<void>;
 
 export function DeleteFavorite(arg1:string):Promise<void>;
 
+export function DeleteQueueSnapshot(arg1:number):Promise<void>;
+
 export function DeleteSong(arg1:string):Promise<void>;
 
 export function DeleteTheme(arg1:string):Promise<void>;
 
 export function DeleteUnreferencedSongs():Promise<number>;
 
+export function DiagnoseLibrary():Promise<services.LibraryHealthReport>;
+
 export function DownloadSong(arg1:string):Promise<string>;
 
 export function DragWindow():Promise<void>;
 
+export function ExportBackup(arg1:string,arg2:services.BackupOptions):Promise<string>;
+
 export function ExportData():Promise<services.ExportData>;
 
+export function ExportFavoritePlaylist(arg1:string,arg2:string,arg3:boolean):Promise<string>;
+
+export function ExportTheme(arg1:string):Promise<string>;
+
+export function ExtractCoverPalette(arg1:string):Promise<Array<services.PaletteSwatch>>;
+
+export function FavoriteAddSongs(arg1:string,arg2:Array<string>,arg3:number):Promise<Array<models.SongRef>>;
+
+export function FavoriteMoveSong(arg1:string,arg2:number,arg3:number):Promise<Array<models.SongRef>>;
+
+export function FavoriteRemoveSongs(arg1:string,arg2:Array<string>):Promise<Array<models.SongRef>>;
+
+export function FavoriteReorder(arg1:string,arg2:Array<string>):Promise<Array<models.SongRef>>;
+
+export function FindDuplicateSongs():Promise<Array<services.DuplicateGroup>>;
+
+export function FlushScrobbleQueue():Promise<void>;
+
+export function GenerateCoverTheme(arg1:string):Promise<models.Theme>;
+
 export function GenerateLoginQR():Promise<services.QRCodeResponse>;
 
 export function GetAudioCacheSize():Promise<number>;
 
+export function GetBuiltinThemes():Promise<Array<models.Theme>>;
+
+export function GetDBBackupConfig():Promise<services.DBBackupConfig>;
+
 export function GetFavoriteCollectionBVIDs(arg1:number):Promise<Array<models.BiliFavoriteInfo>>;
 
 export function GetFavoriteCollectionInfo(arg1:number):Promise<models.BiliFavoriteCollection>;
 
+export function GetFavoriteSortDefs():Promise<Array<services.FavoriteSortDef>>;
+
 export function GetHTTPClient():Promise<http.Client>;
 
 export function GetImageProxyURL(arg1:string):Promise<string>;
 
+export function GetListeningHeatmap(arg1:number):Promise<Array<services.HeatmapDay>>;
+
+export function GetListeningSummary(arg1:string):Promise<services.ListeningSummary>;
+
 export function GetLocalAudioURL(arg1:string):Promise<string>;
 
+export function GetLoginCaptcha():Promise<services.LoginCaptcha>;
+
 export function GetLyricMapping(arg1:string):Promise<models.LyricMapping>;
 
 export function GetMyFavoriteCollections():Promise<Array<models.BiliFavoriteCollection>>;
@@ -58,30 +102,86 @@ export function GetPlayerSetting():Promise<models.PlayerSetting>;
 
 export function GetPlaylist():Promise<models.Playlist>;
 
+export function GetProxyStatus():Promise<services.ProxyStatus>;
+
+export function GetQueue():Promise<services.QueueState>;
+
+export function GetQueueHistory(arg1:number):Promise<Array<models.QueueHistoryEntry>>;
+
+export function GetRecentlyPlayed(arg1:number):Promise<Array<services.RecentPlay>>;
+
+export function GetResumeMinDuration():Promise<number>;
+
+export function GetResumePosition(arg1:string):Promise<number>;
+
+export function GetScrobbleConfig():Promise<services.ScrobbleConfig>;
+
+export function GetScrobbleQueueSize():Promise<number>;
+
+export function GetSettingsSchema():Promise<Array<services.SettingDef>>;
+
+export function GetSmartRuleDefs():Promise<Array<services.SmartRuleDef>>;
+
 export function GetThemes():Promise<Array<models.Theme>>;
 
+export function GetTopArtists(arg1:string,arg2:number):Promise<Array<services.ArtistStat>>;
+
+export function GetTopSongs(arg1:string,arg2:number):Promise<Array<services.SongStat>>;
+
 export function GetUserInfo():Promise<services.UserInfo>;
 
+export function ImportAzusaPlayer(arg1:string):Promise<services.AzusaImportResult>;
+
+export function ImportBackup(arg1:string):Promise<services.BackupImportResult>;
+
 export function ImportData(arg1:services.ExportData):Promise<void>;
 
+export function ImportDataMerge(arg1:services.ExportData,arg2:boolean):Promise<services.ImportReport>;
+
+export function ImportPlaylist(arg1:string,arg2:string,arg3:string):Promise<services.PlaylistImportResult>;
+
+export function ImportTextPlaylist(arg1:string,arg2:Array<models.Song>):Promise<models.Favorite>;
+
+export function ImportTheme(arg1:string):Promise<models.Theme>;
+
+export function InspectBackup(arg1:string):Promise<services.BackupManifest>;
+
 export function IsLoggedIn():Promise<boolean>;
 
 export function IsSongDownloaded(arg1:string):Promise<boolean>;
 
 export function IsWindowMaximized():Promise<boolean>;
 
+export function ListAccounts():Promise<Array<services.Account>>;
+
+export function ListBackups():Promise<Array<services.DBBackup>>;
+
 export function ListFavorites():Promise<Array<models.Favorite>>;
 
+export function ListQueueSnapshots():Promise<Array<services.QueueSnapshot>>;
+
 export function ListSongs():Promise<Array<models.Song>>;
 
+export function ListThemes():Promise<Array<models.Theme>>;
+
+export function LoginWithPassword(arg1:string,arg2:string,arg3:services.CaptchaResult):Promise<services.LoginPollResponse>;
+
+export function LoginWithSMS(arg1:number,arg2:string,arg3:string,arg4:string):Promise<services.LoginPollResponse>;
+
 export function Logout():Promise<void>;
 
+export function MatchTextPlaylist(arg1:string):Promise<Array<services.TextImportCandidate>>;
+
 export function MaximizeWindow():Promise<void>;
 
+export function MergeSongs(arg1:string,arg2:Array<string>):Promise<services.MergeSongsResult>;
+
 export function MinimiseWindow():Promise<void>;
 
 export function MinimizeToTray():Promise<void>;
 
+export function NotifyNowPlaying(arg1:string):Promise<void>;
+
 export function OpenAudioCacheFolder():Promise<void>;
 
 export function OpenDatabaseFile():Promise<void>;
@@ -92,10 +192,52 @@ export function OpenDownloadsFolder():Promise<void>;
 
 export function PollLogin(arg1:string):Promise<services.LoginPollResponse>;
 
+export function PreviewSmartPlaylist(arg1:services.SmartRuleSet):Promise<Array<string>>;
+
+export function QueueAppend(arg1:Array<string>,arg2:string,arg3:string):Promise<services.QueueState>;
+
+export function QueueClear():Promise<services.QueueState>;
+
+export function QueueInsertNext(arg1:Array<string>,arg2:string,arg3:string):Promise<services.QueueState>;
+
+export function QueueMove(arg1:number,arg2:number):Promise<services.QueueState>;
+
+export function QueueNext():Promise<services.QueueState>;
+
+export function QueuePrevious():Promise<services.QueueState>;
+
+export function QueueRemove(arg1:number):Promise<services.QueueState>;
+
+export function QueueSetCurrent(arg1:number):Promise<services.QueueState>;
+
+export function QueueSetProgress(arg1:number,arg2:number):Promise<void>;
+
+export function QueueSetRepeatMode(arg1:string):Promise<services.QueueState>;
+
+export function QueueShuffle(arg1:number):Promise<services.QueueState>;
+
+export function QueueUnshuffle():Promise<services.QueueState>;
+
 export function QuitApp():Promise<void>;
 
+export function RecordPlayEvent(arg1:models.PlayEvent):Promise<models.PlayEvent>;
+
+export function RefreshAllLoginSessions():Promise<number>;
+
+export function RefreshLoginSession():Promise<boolean>;
+
+export function RemoveAccount(arg1:number):Promise<void>;
+
+export function RepairLibrary(arg1:boolean):Promise<services.LibraryRepairResult>;
+
 export function ResolveBiliAudio(arg1:string):Promise<models.BiliAudio>;
 
+export function RestoreBackup(arg1:string):Promise<void>;
+
+export function RestoreQueueSnapshot(arg1:number):Promise<services.QueueState>;
+
+export function SaveDBBackupConfig(arg1:services.DBBackupConfig):Promise<void>;
+
 export function SaveFavorite(arg1:models.Favorite):Promise<void>;
 
 export function SaveLyricMapping(arg1:models.LyricMapping):Promise<void>;
@@ -106,6 +248,14 @@ export function SavePlayerSetting(arg1:models.PlayerSetting):Promise<void>;
 
 export function SavePlaylist(arg1:string,arg2:number):Promise<void>;
 
+export function SaveQueueSnapshot(arg1:string):Promise<services.QueueSnapshot>;
+
+export function SaveResumePosition(arg1:string,arg2:number,arg3:number):Promise<void>;
+
+export function SaveScrobbleConfig(arg1:services.ScrobbleConfig):Promise<void>;
+
+export function SaveSmartPlaylist(arg1:string,arg2:string,arg3:services.SmartRuleSet):Promise<models.Favorite>;
+
 export function SearchBVID(arg1:string):Promise<Array<models.Song>>;
 
 export function SearchBiliVideos(arg1:string,arg2:number,arg3:number,arg4:string):Promise<Array<models.Song>>;
@@ -114,10 +264,24 @@ export function SearchLocalSongs(arg1:string):Promise<Array<models.Song>>;
 
 export function Seed():Promise<void>;
 
+export function SendSMSCode(arg1:number,arg2:string,arg3:services.CaptchaResult):Promise<string>;
+
 export function SetAppContext(arg1:context.Context):Promise<void>;
 
 export function SetCurrentTheme(arg1:string):Promise<void>;
 
+export function SetFavoriteShared(arg1:string,arg2:boolean):Promise<void>;
+
+export function SetFavoriteSort(arg1:string,arg2:string):Promise<Array<models.SongRef>>;
+
+export function SetResumeMinDuration(arg1:number):Promise<void>;
+
+export function StartAudioProxy():Promise<void>;
+
+export function StopAudioProxy():Promise<void>;
+
+export function SwitchAccount(arg1:number):Promise<void>;
+
 export function UnmaximizeWindow():Promise<void>;
 
 export function UpdateTheme(arg1:models.Theme):Promise<void>;
@@ -2,6 +2,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function BackupDatabaseNow() {
+  return window['go']['services']['Service']['BackupDatabaseNow']();
+}
+
 export function ClearAudioCache() {
   return window['go']['services']['Service']['ClearAudioCache']();
 }
@@ -10,10 +14,18 @@ export function ClearLibrary() {
   return window['go']['services']['Service']['ClearLibrary']();
 }
 
+export function ClearResumePosition(arg1) {
+  return window['go']['services']['Service']['ClearResumePosition'](arg1);
+}
+
 export function CloseWindow() {
   return window['go']['services']['Service']['CloseWindow']();
 }
 
+export function ConnectLastFM(arg1, arg2) {
+  return window['go']['services']['Service']['ConnectLastFM'](arg1, arg2);
+}
+
 export function CreateStreamSource(arg1, arg2, arg3) {
   return window['go']['services']['Service']['CreateStreamSource'](arg1, arg2, arg3);
 }
@@ -30,6 +42,10 @@ export function DeleteFavorite(arg1) {
   return window['go']['services']['Service']['DeleteFavorite'](arg1);
 }
 
+export function DeleteQueueSnapshot(arg1) {
+  return window['go']['services']['Service']['DeleteQueueSnapshot'](arg1);
+}
+
 export function DeleteSong(arg1) {
   return window['go']['services']['Service']['DeleteSong'](arg1);
 }
@@ -42,6 +58,10 @@ export function DeleteUnreferencedSongs() {
   return window['go']['services']['Service']['DeleteUnreferencedSongs']();
 }
 
+export function DiagnoseLibrary() {
+  return window['go']['services']['Service']['DiagnoseLibrary']();
+}
+
 export function DownloadSong(arg1) {
   return window['go']['services']['Service']['DownloadSong'](arg1);
 }
@@ -50,10 +70,54 @@ export function DragWindow() {
   return window['go']['services']['Service']['DragWindow']();
 }
 
+export function ExportBackup(arg1, arg2) {
+  return window['go']['services']['Service']['ExportBackup'](arg1, arg2);
+}
+
 export function ExportData() {
   return window['go']['services']['Service']['ExportData']();
 }
 
+export function ExportFavoritePlaylist(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['ExportFavoritePlaylist'](arg1, arg2, arg3);
+}
+
+export function ExportTheme(arg1) {
+  return window['go']['services']['Service']['ExportTheme'](arg1);
+}
+
+export function ExtractCoverPalette(arg1) {
+  return window['go']['services']['Service']['ExtractCoverPalette'](arg1);
+}
+
+export function FavoriteAddSongs(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['FavoriteAddSongs'](arg1, arg2, arg3);
+}
+
+export function FavoriteMoveSong(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['FavoriteMoveSong'](arg1, arg2, arg3);
+}
+
+export function FavoriteRemoveSongs(arg1, arg2) {
+  return window['go']['services']['Service']['FavoriteRemoveSongs'](arg1, arg2);
+}
+
+export function FavoriteReorder(arg1, arg2) {
+  return window['go']['services']['Service']['FavoriteReorder'](arg1, arg2);
+}
+
+export function FindDuplicateSongs() {
+  return window['go']['services']['Service']['FindDuplicateSongs']();
+}
+
+export function FlushScrobbleQueue() {
+  return window['go']['services']['Service']['FlushScrobbleQueue']();
+}
+
+export function GenerateCoverTheme(arg1) {
+  return window['go']['services']['Service']['GenerateCoverTheme'](arg1);
+}
+
 export function GenerateLoginQR() {
   return window['go']['services']['Service']['GenerateLoginQR']();
 }
@@ -62,6 +126,14 @@ export function GetAudioCacheSize() {
   return window['go']['services']['Service']['GetAudioCacheSize']();
 }
 
+export function GetBuiltinThemes() {
+  return window['go']['services']['Service']['GetBuiltinThemes']();
+}
+
+export function GetDBBackupConfig() {
+  return window['go']['services']['Service']['GetDBBackupConfig']();
+}
+
 export function GetFavoriteCollectionBVIDs(arg1) {
   return window['go']['services']['Service']['GetFavoriteCollectionBVIDs'](arg1);
 }
@@ -70,6 +142,10 @@ export function GetFavoriteCollectionInfo(arg1) {
   return window['go']['services']['Service']['GetFavoriteCollectionInfo'](arg1);
 }
 
+export function GetFavoriteSortDefs() {
+  return window['go']['services']['Service']['GetFavoriteSortDefs']();
+}
+
 export function GetHTTPClient() {
   return window['go']['services']['Service']['GetHTTPClient']();
 }
@@ -78,10 +154,22 @@ export function GetImageProxyURL(arg1) {
   return window['go']['services']['Service']['GetImageProxyURL'](arg1);
 }
 
+export function GetListeningHeatmap(arg1) {
+  return window['go']['services']['Service']['GetListeningHeatmap'](arg1);
+}
+
+export function GetListeningSummary(arg1) {
+  return window['go']['services']['Service']['GetListeningSummary'](arg1);
+}
+
 export function GetLocalAudioURL(arg1) {
   return window['go']['services']['Service']['GetLocalAudioURL'](arg1);
 }
 
+export function GetLoginCaptcha() {
+  return window['go']['services']['Service']['GetLoginCaptcha']();
+}
+
 export function GetLyricMapping(arg1) {
   return window['go']['services']['Service']['GetLyricMapping'](arg1);
 }
@@ -106,18 +194,94 @@ export function GetPlaylist() {
   return window['go']['services']['Service']['GetPlaylist']();
 }
 
+export function GetProxyStatus() {
+  return window['go']['services']['Service']['GetProxyStatus']();
+}
+
+export function GetQueue() {
+  return window['go']['services']['Service']['GetQueue']();
+}
+
+export function GetQueueHistory(arg1) {
+  return window['go']['services']['Service']['GetQueueHistory'](arg1);
+}
+
+export function GetRecentlyPlayed(arg1) {
+  return window['go']['services']['Service']['GetRecentlyPlayed'](arg1);
+}
+
+export function GetResumeMinDuration() {
+  return window['go']['services']['Service']['GetResumeMinDuration']();
+}
+
+export function GetResumePosition(arg1) {
+  return window['go']['services']['Service']['GetResumePosition'](arg1);
+}
+
+export function GetScrobbleConfig() {
+  return window['go']['services']['Service']['GetScrobbleConfig']();
+}
+
+export function GetScrobbleQueueSize() {
+  return window['go']['services']['Service']['GetScrobbleQueueSize']();
+}
+
+export function GetSettingsSchema() {
+  return window['go']['services']['Service']['GetSettingsSchema']();
+}
+
+export function GetSmartRuleDefs() {
+  return window['go']['services']['Service']['GetSmartRuleDefs']();
+}
+
 export function GetThemes() {
   return window['go']['services']['Service']['GetThemes']();
 }
 
+export function GetTopArtists(arg1, arg2) {
+  return window['go']['services']['Service']['GetTopArtists'](arg1, arg2);
+}
+
+export function GetTopSongs(arg1, arg2) {
+  return window['go']['services']['Service']['GetTopSongs'](arg1, arg2);
+}
+
 export function GetUserInfo() {
   return window['go']['services']['Service']['GetUserInfo']();
 }
 
+export function ImportAzusaPlayer(arg1) {
+  return window['go']['services']['Service']['ImportAzusaPlayer'](arg1);
+}
+
+export function ImportBackup(arg1) {
+  return window['go']['services']['Service']['ImportBackup'](arg1);
+}
+
 export function ImportData(arg1) {
   return window['go']['services']['Service']['ImportData'](arg1);
 }
 
+export function ImportDataMerge(arg1, arg2) {
+  return window['go']['services']['Service']['ImportDataMerge'](arg1, arg2);
+}
+
+export function ImportPlaylist(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['ImportPlaylist'](arg1, arg2, arg3);
+}
+
+export function ImportTextPlaylist(arg1, arg2) {
+  return window['go']['services']['Service']['ImportTextPlaylist'](arg1, arg2);
+}
+
+export function ImportTheme(arg1) {
+  return window['go']['services']['Service']['ImportTheme'](arg1);
+}
+
+export function InspectBackup(arg1) {
+  return window['go']['services']['Service']['InspectBackup'](arg1);
+}
+
 export function IsLoggedIn() {
   return window['go']['services']['Service']['IsLoggedIn']();
 }
@@ -130,22 +294,54 @@ export function IsWindowMaximized() {
   return window['go']['services']['Service']['IsWindowMaximized']();
 }
 
+export function ListAccounts() {
+  return window['go']['services']['Service']['ListAccounts']();
+}
+
+export function ListBackups() {
+  return window['go']['services']['Service']['ListBackups']();
+}
+
 export function ListFavorites() {
   return window['go']['services']['Service']['ListFavorites']();
 }
 
+export function ListQueueSnapshots() {
+  return window['go']['services']['Service']['ListQueueSnapshots']();
+}
+
 export function ListSongs() {
   return window['go']['services']['Service']['ListSongs']();
 }
 
+export function ListThemes() {
+  return window['go']['services']['Service']['ListThemes']();
+}
+
+export function LoginWithPassword(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['LoginWithPassword'](arg1, arg2, arg3);
+}
+
+export function LoginWithSMS(arg1, arg2, arg3, arg4) {
+  return window['go']['services']['Service']['LoginWithSMS'](arg1, arg2, arg3, arg4);
+}
+
 export function Logout() {
   return window['go']['services']['Service']['Logout']();
 }
 
+export function MatchTextPlaylist(arg1) {
+  return window['go']['services']['Service']['MatchTextPlaylist'](arg1);
+}
+
 export function MaximizeWindow() {
   return window['go']['services']['Service']['MaximizeWindow']();
 }
 
+export function MergeSongs(arg1, arg2) {
+  return window['go']['services']['Service']['MergeSongs'](arg1, arg2);
+}
+
 export function MinimiseWindow() {
   return window['go']['services']['Service']['MinimiseWindow']();
 }
@@ -154,6 +350,10 @@ export function MinimizeToTray() {
   return window['go']['services']['Service']['MinimizeToTray']();
 }
 
+export function NotifyNowPlaying(arg1) {
+  return window['go']['services']['Service']['NotifyNowPlaying'](arg1);
+}
+
 export function OpenAudioCacheFolder() {
   return window['go']['services']['Service']['OpenAudioCacheFolder']();
 }
@@ -174,14 +374,98 @@ export function PollLogin(arg1) {
   return window['go']['services']['Service']['PollLogin'](arg1);
 }
 
+export function PreviewSmartPlaylist(arg1) {
+  return window['go']['services']['Service']['PreviewSmartPlaylist'](arg1);
+}
+
+export function QueueAppend(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['QueueAppend'](arg1, arg2, arg3);
+}
+
+export function QueueClear() {
+  return window['go']['services']['Service']['QueueClear']();
+}
+
+export function QueueInsertNext(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['QueueInsertNext'](arg1, arg2, arg3);
+}
+
+export function QueueMove(arg1, arg2) {
+  return window['go']['services']['Service']['QueueMove'](arg1, arg2);
+}
+
+export function QueueNext() {
+  return window['go']['services']['Service']['QueueNext']();
+}
+
+export function QueuePrevious() {
+  return window['go']['services']['Service']['QueuePrevious']();
+}
+
+export function QueueRemove(arg1) {
+  return window['go']['services']['Service']['QueueRemove'](arg1);
+}
+
+export function QueueSetCurrent(arg1) {
+  return window['go']['services']['Service']['QueueSetCurrent'](arg1);
+}
+
+export function QueueSetProgress(arg1, arg2) {
+  return window['go']['services']['Service']['QueueSetProgress'](arg1, arg2);
+}
+
+export function QueueSetRepeatMode(arg1) {
+  return window['go']['services']['Service']['QueueSetRepeatMode'](arg1);
+}
+
+export function QueueShuffle(arg1) {
+  return window['go']['services']['Service']['QueueShuffle'](arg1);
+}
+
+export function QueueUnshuffle() {
+  return window['go']['services']['Service']['QueueUnshuffle']();
+}
+
 export function QuitApp() {
   return window['go']['services']['Service']['QuitApp']();
 }
 
+export function RecordPlayEvent(arg1) {
+  return window['go']['services']['Service']['RecordPlayEvent'](arg1);
+}
+
+export function RefreshAllLoginSessions() {
+  return window['go']['services']['Service']['RefreshAllLoginSessions']();
+}
+
+export function RefreshLoginSession() {
+  return window['go']['services']['Service']['RefreshLoginSession']();
+}
+
+export function RemoveAccount(arg1) {
+  return window['go']['services']['Service']['RemoveAccount'](arg1);
+}
+
+export function RepairLibrary(arg1) {
+  return window['go']['services']['Service']['RepairLibrary'](arg1);
+}
+
 export function ResolveBiliAudio(arg1) {
   return window['go']['services']['Service']['ResolveBiliAudio'](arg1);
 }
 
+export function RestoreBackup(arg1) {
+  return window['go']['services']['Service']['RestoreBackup'](arg1);
+}
+
+export function RestoreQueueSnapshot(arg1) {
+  return window['go']['services']['Service']['RestoreQueueSnapshot'](arg1);
+}
+
+export function SaveDBBackupConfig(arg1) {
+  return window['go']['services']['Service']['SaveDBBackupConfig'](arg1);
+}
+
 export function SaveFavorite(arg1) {
   return window['go']['services']['Service']['SaveFavorite'](arg1);
 }
@@ -202,6 +486,22 @@ export function SavePlaylist(arg1, arg2) {
   return window['go']['services']['Service']['SavePlaylist'](arg1, arg2);
 }
 
+export function SaveQueueSnapshot(arg1) {
+  return window['go']['services']['Service']['SaveQueueSnapshot'](arg1);
+}
+
+export function SaveResumePosition(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['SaveResumePosition'](arg1, arg2, arg3);
+}
+
+export function SaveScrobbleConfig(arg1) {
+  return window['go']['services']['Service']['SaveScrobbleConfig'](arg1);
+}
+
+export function SaveSmartPlaylist(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['SaveSmartPlaylist'](arg1, arg2, arg3);
+}
+
 export function SearchBVID(arg1) {
   return window['go']['services']['Service']['SearchBVID'](arg1);
 }
@@ -218,6 +518,10 @@ export function Seed() {
   return window['go']['services']['Service']['Seed']();
 }
 
+export function SendSMSCode(arg1, arg2, arg3) {
+  return window['go']['services']['Service']['SendSMSCode'](arg1, arg2, arg3);
+}
+
 export function SetAppContext(arg1) {
   return window['go']['services']['Service']['SetAppContext'](arg1);
 }
@@ -226,6 +530,30 @@ export function SetCurrentTheme(arg1) {
   return window['go']['services']['Service']['SetCurrentTheme'](arg1);
 }
 
+export function SetFavoriteShared(arg1, arg2) {
+  return window['go']['services']['Service']['SetFavoriteShared'](arg1, arg2);
+}
+
+export function SetFavoriteSort(arg1, arg2) {
+  return window['go']['services']['Service']['SetFavoriteSort'](arg1, arg2);
+}
+
+export function SetResumeMinDuration(arg1) {
+  return window['go']['services']['Service']['SetResumeMinDuration'](arg1);
+}
+
+export function StartAudioProxy() {
+  return window['go']['services']['Service']['StartAudioProxy']();
+}
+
+export function StopAudioProxy() {
+  return window['go']['services']['Service']['StopAudioProxy']();
+}
+
+export function SwitchAccount(arg1) {
+  return window['go']['services']['Service']['SwitchAccount'](arg1);
+}
+
 export function UnmaximizeWindow() {
   return window['go']['services']['Service']['UnmaximizeWindow']();
 }
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// BackupTo writes a consistent copy of the open database to dst using VACUUM INTO.
+func BackupTo(gdb *gorm.DB, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("backup target exists: %s", dst)
+	}
+	if err := gdb.Exec("VACUUM INTO ?", dst).Error; err != nil {
+		return fmt.Errorf("vacuum into: %w", err)
+	}
+	return nil
+}
+
+// CheckIntegrity runs PRAGMA integrity_check on the database file at path.
+func CheckIntegrity(path string) error {
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var result string
+	if err := conn.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("integrity check: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
+
+// RestoreFrom replaces the content of the open database with the database file at
+// src, using SQLite's online backup API so the connection pool stays usable.
+func RestoreFrom(gdb *gorm.DB, src string) error {
+	ctx := context.Background()
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return err
+	}
+	dstConn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	srcDB, err := sql.Open("sqlite3", "file:"+src+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer srcDB.Close()
+	srcConn, err := srcDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dc any) error {
+		dst, ok := dc.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", dc)
+		}
+		return srcConn.Raw(func(sc any) error {
+			srcRaw, ok := sc.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", sc)
+			}
+			b, err := dst.Backup("main", srcRaw, "main")
+			if err != nil {
+				return fmt.Errorf("start restore: %w", err)
+			}
+			if _, err := b.Step(-1); err != nil {
+				b.Finish()
+				return fmt.Errorf("restore: %w", err)
+			}
+			return b.Finish()
+		})
+	})
+}
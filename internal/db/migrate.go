@@ -0,0 +1,141 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one numbered, forward-only schema or data change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// SchemaVersion records an applied migration.
+type SchemaVersion struct {
+	Version   int `gorm:"primaryKey"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaVersion) TableName() string { return "schema_version" }
+
+// CurrentVersion returns the highest applied migration version, 0 for a database
+// that predates versioning.
+func CurrentVersion(gdb *gorm.DB) (int, error) {
+	if !gdb.Migrator().HasTable(&SchemaVersion{}) {
+		return 0, nil
+	}
+	var version int
+	if err := gdb.Model(&SchemaVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// LatestVersion returns the version the registered migrations bring a database to.
+func LatestVersion() int {
+	latest := 0
+	for _, m := range migrations {
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest
+}
+
+// Migrate brings the database to LatestVersion. When an existing database has
+// pending migrations it is first copied to backupPath (skipped when backupPath is
+// empty). Then, in order:
+//
+//  0. AutoMigrate creates missing tables, columns and indexes for Models (add-only,
+//     in one transaction). It runs on every start and is not versioned.
+//  1. Each pending registered migration runs in its own transaction and records
+//     its version. These handle data changes and anything AutoMigrate cannot do,
+//     so they may rely on the step 0 schema.
+func Migrate(gdb *gorm.DB, backupPath string) error {
+	return runMigrations(gdb, migrations, backupPath)
+}
+
+func runMigrations(gdb *gorm.DB, list []Migration, backupPath string) error {
+	current, err := CurrentVersion(gdb)
+	if err != nil {
+		return err
+	}
+	pending := make([]Migration, 0, len(list))
+	latest := 0
+	for _, m := range list {
+		if m.Version > latest {
+			latest = m.Version
+		}
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than this build (%d)", current, latest)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Version < pending[j].Version })
+
+	// 已有数据的库在升级前先备份，新库无需备份
+	if len(pending) > 0 && backupPath != "" {
+		existing, err := hasUserTables(gdb)
+		if err != nil {
+			return err
+		}
+		if existing {
+			if err := backupBeforeMigrate(gdb, backupPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 第 0 步：模型表结构同步（只增不删），版本化迁移在此基础上处理数据
+	err = gdb.Transaction(func(tx *gorm.DB) error {
+		return tx.AutoMigrate(append([]any{&SchemaVersion{}}, Models...)...)
+	})
+	if err != nil {
+		return fmt.Errorf("auto migrate: %w", err)
+	}
+
+	for _, m := range pending {
+		err := gdb.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaVersion{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		log.Printf("applied migration %d (%s)", m.Version, m.Name)
+	}
+	return nil
+}
+
+func backupBeforeMigrate(gdb *gorm.DB, backupPath string) error {
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0o755); err != nil {
+		return fmt.Errorf("create backup dir: %w", err)
+	}
+	if err := BackupTo(gdb, backupPath); err != nil {
+		return fmt.Errorf("pre-migration backup: %w", err)
+	}
+	log.Printf("pre-migration backup written to %s", backupPath)
+	return nil
+}
+
+// hasUserTables reports whether the database already holds application tables.
+func hasUserTables(gdb *gorm.DB) (bool, error) {
+	var count int64
+	if err := gdb.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").Scan(&count).Error; err != nil {
+		return false, fmt.Errorf("inspect tables: %w", err)
+	}
+	return count > 0, nil
+}
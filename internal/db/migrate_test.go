@@ -0,0 +1,184 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// v0Schema is the database layout written by releases before versioned migrations.
+const v0Schema = `
+CREATE TABLE stream_sources (id text PRIMARY KEY, bvid text, stream_url text, expires_at datetime, created_at datetime, updated_at datetime);
+CREATE TABLE songs (id text PRIMARY KEY, name text, singer text, singer_id text, cover text, cover_local text,
+	source_id text, stream_url text, stream_url_expires_at datetime, lyric text, lyric_offset integer,
+	skip_start_time real, skip_end_time real, page_number integer, page_title text, video_title text,
+	total_pages integer, created_at datetime, updated_at datetime);
+CREATE TABLE favorites (id text PRIMARY KEY, title text, created_at datetime, updated_at datetime);
+CREATE TABLE song_refs (id integer PRIMARY KEY AUTOINCREMENT, favorite_id text, song_id text);
+CREATE TABLE player_settings (id integer PRIMARY KEY AUTOINCREMENT, config text, updated_at datetime);
+CREATE TABLE playlists (id integer PRIMARY KEY AUTOINCREMENT, queue text, current_index integer, updated_at datetime);
+
+INSERT INTO songs (id, name, stream_url, created_at, updated_at) VALUES
+	('s1', 'one', 'https://example.com/1.m4s', '2024-01-01 00:00:00', '2024-01-01 00:00:00'),
+	('s2', 'two', '', '2024-01-01 00:00:00', '2024-01-01 00:00:00');
+INSERT INTO favorites (id, title, created_at, updated_at) VALUES ('f1', 'fav', '2024-02-01 00:00:00', '2024-02-01 00:00:00');
+INSERT INTO song_refs (favorite_id, song_id) VALUES ('f1', 's2'), ('f1', 's1');
+INSERT INTO player_settings (id, config, updated_at) VALUES (1,
+	'{"volume":0.5,"themes":"[{\"id\":\"light\",\"name\":\"亮色\",\"data\":\"{}\",\"isDefault\":true},{\"id\":\"custom-1\",\"name\":\"mine\",\"data\":\"{}\",\"isReadOnly\":true}]"}',
+	'2024-01-01 00:00:00');
+`
+
+func openV0(t *testing.T) *gorm.DB {
+	t.Helper()
+	gdb, err := Open(filepath.Join(t.TempDir(), "v0.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Exec(v0Schema).Error; err != nil {
+		t.Fatalf("load v0 fixture: %v", err)
+	}
+	return gdb
+}
+
+func assertVersion(t *testing.T, gdb *gorm.DB, want int) {
+	t.Helper()
+	got, err := CurrentVersion(gdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("schema version = %d, want %d", got, want)
+	}
+}
+
+func TestMigrateStepByStepFromV0(t *testing.T) {
+	gdb := openV0(t)
+	assertVersion(t, gdb, 0)
+
+	// 1: songs gain the bvid column
+	if err := runMigrations(gdb, migrations[:1], ""); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, gdb, 1)
+	if !gdb.Migrator().HasColumn(&models.Song{}, "bvid") {
+		t.Fatal("songs.bvid missing after migration 1")
+	}
+
+	// 2: a song with only a cached stream URL gets a StreamSource
+	if err := runMigrations(gdb, migrations[:2], ""); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, gdb, 2)
+	var s1, s2 models.Song
+	gdb.First(&s1, "id = ?", "s1")
+	gdb.First(&s2, "id = ?", "s2")
+	if s1.SourceID == "" {
+		t.Fatal("s1 has no stream source after migration 2")
+	}
+	if s2.SourceID != "" {
+		t.Fatalf("s2 got a stream source without a stream URL: %q", s2.SourceID)
+	}
+	var source models.StreamSource
+	if err := gdb.First(&source, "id = ?", s1.SourceID).Error; err != nil {
+		t.Fatalf("stream source of s1: %v", err)
+	}
+	if source.StreamURL != s1.StreamURL {
+		t.Fatalf("stream source URL = %q, want %q", source.StreamURL, s1.StreamURL)
+	}
+
+	// 3: refs are numbered in insertion order and dated from the favorite
+	if err := runMigrations(gdb, migrations[:3], ""); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, gdb, 3)
+	var refs []models.SongRef
+	gdb.Order("position").Find(&refs, "favorite_id = ?", "f1")
+	if len(refs) != 2 || refs[0].SongID != "s2" || refs[1].SongID != "s1" || refs[1].Position != 1 {
+		t.Fatalf("refs after migration 3 = %+v", refs)
+	}
+	wantAdded := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	for _, r := range refs {
+		if !r.AddedAt.Equal(wantAdded) {
+			t.Fatalf("ref %d added_at = %v, want %v", r.ID, r.AddedAt, wantAdded)
+		}
+	}
+
+	// 4: custom themes move out of the settings; built-in ones are dropped
+	if err := runMigrations(gdb, migrations[:4], ""); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, gdb, 4)
+	var themes []models.Theme
+	gdb.Find(&themes)
+	if len(themes) != 1 || themes[0].ID != "custom-1" || themes[0].IsReadOnly {
+		t.Fatalf("themes after migration 4 = %+v", themes)
+	}
+	var setting models.PlayerSetting
+	gdb.First(&setting, 1)
+	if _, ok := setting.Config[legacyThemesKey]; ok {
+		t.Fatal("legacy themes key left in settings")
+	}
+	if setting.Config["volume"] != 0.5 {
+		t.Fatalf("other settings changed: %v", setting.Config)
+	}
+
+	if got := LatestVersion(); got != 4 {
+		t.Fatalf("test covers up to version 4, registry is at %d: extend the test", got)
+	}
+}
+
+func TestMigrateBacksUpExistingDatabase(t *testing.T) {
+	gdb := openV0(t)
+	backup := filepath.Join(t.TempDir(), "backups", "pre.db")
+	if err := Migrate(gdb, backup); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, gdb, LatestVersion())
+	if _, err := os.Stat(backup); err != nil {
+		t.Fatalf("pre-migration backup: %v", err)
+	}
+
+	// 已是最新版本时不再备份
+	if err := os.Remove(backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(gdb, backup); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Fatalf("backup written without pending migrations: %v", err)
+	}
+}
+
+func TestMigrateFreshDatabaseSkipsBackup(t *testing.T) {
+	gdb, err := Open(filepath.Join(t.TempDir(), "new.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(t.TempDir(), "pre.db")
+	if err := Migrate(gdb, backup); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, gdb, LatestVersion())
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Fatalf("backup written for a fresh database: %v", err)
+	}
+}
+
+func TestMigrateRejectsNewerSchema(t *testing.T) {
+	gdb := openV0(t)
+	if err := Migrate(gdb, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Create(&SchemaVersion{Version: LatestVersion() + 1, Name: "future", AppliedAt: time.Now()}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(gdb, ""); err == nil {
+		t.Fatal("expected an error for a schema newer than the build")
+	}
+}
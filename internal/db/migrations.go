@@ -0,0 +1,139 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Models lists every table the application owns; Migrate keeps them in sync.
+var Models = []any{
+	&models.StreamSource{},
+	&models.Song{},
+	&models.Favorite{},
+	&models.SongRef{},
+	&models.PlayerSetting{},
+	&models.LyricMapping{},
+	&models.Playlist{},
+	&models.QueueEntry{},
+	&models.QueueHistoryEntry{},
+	&models.LoginSession{},
+	&models.PlayHistory{},
+	&models.ResumePosition{},
+	&models.PlayEvent{},
+	&models.ScrobbleQueueItem{},
+	&models.Theme{},
+}
+
+// migrations is the ordered registry; append new entries, never renumber or edit
+// applied ones.
+var migrations = []Migration{
+	{Version: 1, Name: "songs_bvid_column", Up: migrateSongsBVIDColumn},
+	{Version: 2, Name: "backfill_stream_sources", Up: migrateBackfillStreamSources},
+	{Version: 3, Name: "song_ref_positions", Up: migrateSongRefPositions},
+	{Version: 4, Name: "themes_table", Up: migrateThemesTable},
+}
+
+// migrateSongsBVIDColumn 确保 songs 表有 bvid 列（兼容旧数据库）。
+// 第 0 步的 AutoMigrate 通常已经加上该列，此迁移保留以固定版本号。
+func migrateSongsBVIDColumn(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&models.Song{}, "bvid") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&models.Song{}, "bvid")
+}
+
+// migrateBackfillStreamSources gives songs that only cached a stream URL a
+// StreamSource row, as UpsertSongs does for new songs.
+func migrateBackfillStreamSources(tx *gorm.DB) error {
+	var songs []models.Song
+	if err := tx.Where("stream_url <> '' AND (source_id IS NULL OR source_id = '')").Find(&songs).Error; err != nil {
+		return err
+	}
+	for _, song := range songs {
+		source := models.StreamSource{
+			ID:        uuid.NewString(),
+			BVID:      song.BVID,
+			StreamURL: song.StreamURL,
+			ExpiresAt: song.StreamURLExpiresAt,
+		}
+		if err := tx.Create(&source).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Song{}).Where("id = ?", song.ID).UpdateColumn("source_id", source.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateSongRefPositions numbers refs of favorites created before explicit ordering
+// (all positions 0) by insertion order, and backfills AddedAt from the favorite.
+func migrateSongRefPositions(tx *gorm.DB) error {
+	err := tx.Exec(`UPDATE song_refs SET position = (
+			SELECT COUNT(*) FROM song_refs r WHERE r.favorite_id = song_refs.favorite_id AND r.id < song_refs.id)
+		WHERE favorite_id IN (
+			SELECT favorite_id FROM song_refs GROUP BY favorite_id HAVING COUNT(*) > 1 AND MAX(position) = 0)`).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.SongRef{}).
+		Where("added_at IS NULL OR added_at = ?", time.Time{}).
+		UpdateColumn("added_at", gorm.Expr("COALESCE((SELECT created_at FROM favorites WHERE favorites.id = song_refs.favorite_id), added_at)")).Error
+}
+
+// legacyThemesKey is the settings key that held custom themes as a JSON string.
+const legacyThemesKey = "themes"
+
+// SplitLegacyThemes removes the legacy themes entry from a settings config and
+// returns the custom themes it held.
+func SplitLegacyThemes(cfg map[string]any) []models.Theme {
+	raw, ok := cfg[legacyThemesKey]
+	if !ok {
+		return nil
+	}
+	delete(cfg, legacyThemesKey)
+	str, _ := raw.(string)
+	var themes []models.Theme
+	if str == "" || json.Unmarshal([]byte(str), &themes) != nil {
+		return nil
+	}
+	out := themes[:0]
+	for _, t := range themes {
+		// 内置主题由程序提供，不再保存
+		if t.ID == "" || t.IsDefault {
+			continue
+		}
+		t.IsReadOnly = false
+		out = append(out, t)
+	}
+	return out
+}
+
+// migrateThemesTable moves custom themes from the settings JSON into the themes table.
+func migrateThemesTable(tx *gorm.DB) error {
+	var setting models.PlayerSetting
+	err := tx.First(&setting, 1).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := setting.Config[legacyThemesKey]; !ok {
+		return nil
+	}
+	themes := SplitLegacyThemes(setting.Config)
+	if len(themes) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&themes).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Save(&setting).Error
+}
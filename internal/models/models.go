@@ -39,29 +39,41 @@ type Song struct {
 }
 
 // Favorite stores a playlist of songs by id to keep schema simple.
+// OwnerUID scopes the favorite to one logged-in account; 0 means visible to every account.
+// Smart favorites have SmartRules set and their SongIDs are computed on read.
 type Favorite struct {
-	ID        string    `gorm:"primaryKey" json:"id"`
-	Title     string    `json:"title"`
-	SongIDs   []SongRef `gorm:"foreignKey:FavoriteID" json:"songIds"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID          string    `gorm:"primaryKey" json:"id"`
+	Title       string    `json:"title"`
+	SongIDs     []SongRef `gorm:"foreignKey:FavoriteID" json:"songIds"`
+	OwnerUID    int64     `gorm:"column:owner_uid;default:0;index" json:"ownerUid"` // 所属账号 UID，0 为共享
+	BiliMediaID int64     `gorm:"default:0" json:"biliMediaId"`                     // 导入来源的 B 站收藏夹 ID
+	SmartRules  string    `gorm:"type:text" json:"smartRules"`                      // 智能歌单规则 JSON，为空表示普通歌单
+	ReadOnly    bool      `gorm:"-" json:"readOnly"`                                // 智能歌单由规则生成，不可手动编辑
+	SortBy      string    `json:"sortBy"`                                           // 排序方式：空为手动，name/singer/added/duration，前缀 - 表示倒序
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
+// SongRef places a song in a favorite; refs are ordered by Position.
 type SongRef struct {
-	ID         uint   `gorm:"primaryKey" json:"id"`
-	FavoriteID string `json:"favoriteId"`
-	SongID     string `json:"songId"`
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FavoriteID string    `gorm:"index:idx_song_refs_favorite_position,priority:1" json:"favoriteId"`
+	SongID     string    `json:"songId"`
+	Position   int       `gorm:"default:0;index:idx_song_refs_favorite_position,priority:2" json:"position"`
+	AddedAt    time.Time `json:"addedAt"`
 }
 
-// Theme represents a theme configuration
-// Data field stores the complete theme configuration as JSON
-// Backend doesn't enforce schema, allowing flexible field changes on frontend
+// Theme represents a custom theme configuration.
+// Data field stores the complete theme configuration as JSON.
+// Built-in themes are shipped by the backend and are not stored.
 type Theme struct {
-	ID       string    `json:"id"`
-	Name     string    `json:"name"`
-	Data     string    `gorm:"type:longtext" json:"data"` // JSON string containing theme configuration
-	IsDefault bool    `json:"isDefault"`
-	IsReadOnly bool   `json:"isReadOnly"`
+	ID         string    `gorm:"primaryKey" json:"id"`
+	Name       string    `json:"name"`
+	Data       string    `gorm:"type:longtext" json:"data"` // JSON string containing theme configuration
+	IsDefault  bool      `json:"isDefault"`
+	IsReadOnly bool      `json:"isReadOnly"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
 }
 
 // PlayerSetting captures basic playback preferences.
@@ -71,21 +83,57 @@ type PlayerSetting struct {
 	UpdatedAt time.Time      `json:"updatedAt"`
 }
 
-// Playlist stores the current playback queue state.
+// Playlist stores a playback queue state. ID=1 is the current queue; other rows are
+// named snapshots saved by the user.
+// Entries live in queue_entries; Queue is only filled on read for older frontends.
 type Playlist struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
-	Queue        string    `gorm:"type:longtext" json:"queue"` // JSON array of song IDs
+	Name         string    `gorm:"index" json:"name"`          // 快照名称，当前队列为空
+	Queue        string    `gorm:"type:longtext" json:"queue"` // JSON array of song IDs (legacy)
 	CurrentIndex int       `json:"currentIndex"`
+	RepeatMode   string    `json:"repeatMode"` // off | all | one，为空按 all 处理
+	Shuffled     bool      `gorm:"default:false" json:"shuffled"`
+	ShuffleSeed  int64     `json:"shuffleSeed"`
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
-// LoginSession stores persisted login cookie (SESSDATA) for restoring session.
-// Single-row table with ID=1.
+// QueueEntry is one song in a playback queue.
+// Position is the play order; OriginalPosition is the order before shuffling.
+type QueueEntry struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	PlaylistID       uint      `gorm:"index" json:"playlistId"`
+	Position         int       `json:"position"`
+	OriginalPosition int       `json:"originalPosition"`
+	SongID           string    `json:"songId"`
+	FavoriteID       string    `json:"favoriteId"` // 来源歌单
+	AddedBy          string    `json:"addedBy"`    // user | favorite | search | restore
+	AddedAt          time.Time `json:"addedAt"`
+	ProgressSeconds  float64   `json:"progressSeconds"` // 该歌曲上次的播放进度
+}
+
+// QueueHistoryEntry records a queue item that started playing.
+type QueueHistoryEntry struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PlaylistID uint      `gorm:"index" json:"playlistId"`
+	SongID     string    `json:"songId"`
+	FavoriteID string    `json:"favoriteId"`
+	PlayedAt   time.Time `gorm:"index" json:"playedAt"`
+}
+
+// LoginSession stores a persisted Bilibili account login (cookies + refresh token) for restoring session.
+// One row per account; the row marked Active is restored on startup.
 type LoginSession struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Sessdata  string    `json:"sessdata"`
-	SavedAt   time.Time `json:"savedAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UID      int64  `gorm:"column:uid;default:0;index" json:"uid"`
+	Username string `json:"username"`
+	Face     string `json:"face"`
+	Sessdata string `json:"sessdata"`
+	Cookies  string `gorm:"type:text" json:"cookies"` // JSON 编码的完整 cookie 集合（SESSDATA、bili_jct、DedeUserID、buvid3…）
+	// RefreshToken is issued at login and consumed by the cookie refresh flow.
+	RefreshToken string    `json:"refreshToken"`
+	Active       bool      `gorm:"default:false" json:"active"`
+	SavedAt      time.Time `json:"savedAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
 // PlayHistory stores last played favorite + song.
@@ -98,6 +146,44 @@ type PlayHistory struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// ResumePosition remembers where playback of a long song stopped.
+type ResumePosition struct {
+	SongID          string    `gorm:"primaryKey" json:"songId"`
+	PositionSeconds float64   `json:"positionSeconds"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// PlayEvent is an append-only record of one playback of a song.
+type PlayEvent struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	SongID          string    `gorm:"index" json:"songId"`
+	FavoriteID      string    `json:"favoriteId"`
+	StartedAt       time.Time `gorm:"index" json:"startedAt"`
+	ListenedSeconds float64   `json:"listenedSeconds"`
+	DurationSeconds float64   `json:"durationSeconds"` // 歌曲总时长，未知时为 0
+	Completed       bool      `json:"completed"`
+	Skipped         bool      `json:"skipped"`
+	Source          string    `json:"source"` // "cache" 本地缓存/下载 或 "stream" 在线播放
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// ScrobbleQueueItem is a listen waiting to be submitted to the scrobble server.
+// Items stay queued while the network is down and are retried with backoff.
+type ScrobbleQueueItem struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Artist        string    `json:"artist"`
+	Title         string    `json:"title"`
+	Album         string    `json:"album"`
+	DurationSec   int       `json:"durationSec"`
+	OriginURL     string    `json:"originUrl"`
+	ListenedAt    time.Time `gorm:"index" json:"listenedAt"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"lastError"`
+	NextAttemptAt time.Time `gorm:"index" json:"nextAttemptAt"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
 // LyricMapping caches text and offset.
 type LyricMapping struct {
 	ID        string    `gorm:"primaryKey" json:"id"`
@@ -0,0 +1,163 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// tokenParam carries the per-session secret on every proxy URL. Media elements
+// cannot send custom headers, so it has to be a query parameter.
+const tokenParam = "t"
+
+// maxRedirects matches the default policy of net/http.
+const maxRedirects = 10
+
+// defaultAllowedHosts are the upstream hosts the proxy may fetch from. An entry
+// matches the host itself and its subdomains; entries with * are path.Match patterns.
+var defaultAllowedHosts = []string{
+	"bilivideo.com",
+	"bilivideo.cn",
+	"szbdyd.com", // PCDN
+	"upos-*.akamaized.net",
+	"hdslb.com",
+	"biliimg.com",
+	"bilibili.com",
+}
+
+// allowedOrigins are the WebView origins of the app (Windows, macOS/Linux) and of
+// the dev servers used by `wails dev`. Other pages must not read proxy responses.
+var allowedOrigins = []string{
+	"http://wails.localhost",
+	"https://wails.localhost",
+	"wails://wails",
+	"wails://wails.localhost",
+	"http://localhost:5173",
+	"http://localhost:34115",
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("proxy token: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// Token returns the secret required on every request of this session.
+func (ap *AudioProxy) Token() string {
+	return ap.token
+}
+
+// authorized checks the session token; it writes 403 and returns false otherwise.
+func (ap *AudioProxy) authorized(w http.ResponseWriter, r *http.Request) bool {
+	got := r.URL.Query().Get(tokenParam)
+	if subtle.ConstantTimeCompare([]byte(got), []byte(ap.token)) == 1 {
+		return true
+	}
+	fmt.Printf("[Proxy] Rejected %s without valid token\n", r.URL.Path)
+	http.Error(w, "forbidden", http.StatusForbidden)
+	return false
+}
+
+// setCORS lets the app's origin read the response. Media elements without the
+// crossorigin attribute send no Origin and need no CORS headers.
+func setCORS(w http.ResponseWriter, r *http.Request, methods string) {
+	w.Header().Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	if !originAllowed(origin) {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Methods", methods)
+	w.Header().Set("Access-Control-Allow-Headers", "Range")
+}
+
+func originAllowed(origin string) bool {
+	for _, o := range allowedOrigins {
+		if strings.EqualFold(origin, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostAllowed reports whether host (without port) is an allowed upstream.
+func hostAllowed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, entry := range defaultAllowedHosts {
+		if strings.Contains(entry, "*") {
+			if ok, _ := path.Match(entry, host); ok {
+				return true
+			}
+			continue
+		}
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUpstream accepts only http(s) URLs on an allowed host.
+func checkUpstream(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme %q not allowed", u.Scheme)
+	}
+	if u.User != nil {
+		return errors.New("credentials in URL not allowed")
+	}
+	if !hostAllowed(u.Hostname()) {
+		return fmt.Errorf("host %q not allowed", u.Hostname())
+	}
+	return nil
+}
+
+// upstreamURL reads and validates the u parameter; it writes the error response
+// and returns "" when the URL may not be fetched.
+func upstreamURL(w http.ResponseWriter, r *http.Request) string {
+	rawURL := r.URL.Query().Get("u")
+	if rawURL == "" {
+		http.Error(w, "missing u parameter", http.StatusBadRequest)
+		return ""
+	}
+
+	decodedURL, err := url.QueryUnescape(rawURL)
+	if err != nil {
+		http.Error(w, "invalid URL encoding", http.StatusBadRequest)
+		return ""
+	}
+	u, err := url.Parse(decodedURL)
+	if err != nil {
+		http.Error(w, "invalid URL", http.StatusBadRequest)
+		return ""
+	}
+	if err := checkUpstream(u); err != nil {
+		fmt.Printf("[Proxy] Rejected upstream %s: %v\n", decodedURL, err)
+		http.Error(w, "upstream not allowed", http.StatusForbidden)
+		return ""
+	}
+	return decodedURL
+}
+
+// checkRedirect keeps redirects on allowed hosts.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if err := checkUpstream(req.URL); err != nil {
+		return fmt.Errorf("redirect rejected: %w", err)
+	}
+	return nil
+}
+
+// withToken appends the session token to a proxy URL.
+func (ap *AudioProxy) withToken(u string) string {
+	return u + "&" + tokenParam + "=" + ap.token
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestProxy(t *testing.T) *AudioProxy {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "audio_cache"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "audio_cache", "a.m4s"), []byte("audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return NewAudioProxy(0, &http.Client{}, dir)
+}
+
+func serve(handler http.HandlerFunc, method, target, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestRejectsMissingOrWrongToken(t *testing.T) {
+	ap := newTestProxy(t)
+	handlers := map[string]http.HandlerFunc{
+		"/audio": ap.handleAudio,
+		"/image": ap.handleImage,
+		"/local": ap.handleLocal,
+	}
+	for path, h := range handlers {
+		for _, method := range []string{http.MethodGet, http.MethodOptions} {
+			for _, query := range []string{"?f=a.m4s", "?f=a.m4s&t=wrong", "?f=a.m4s&t="} {
+				rec := serve(h, method, path+query, "wails://wails")
+				if rec.Code != http.StatusForbidden {
+					t.Errorf("%s %s%s = %d, want 403", method, path, query, rec.Code)
+				}
+			}
+		}
+	}
+
+	rec := serve(ap.handleLocal, http.MethodGet, ap.GetLocalURL("a.m4s"), "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "audio" {
+		t.Fatalf("valid token: %d %q", rec.Code, rec.Body.String())
+	}
+	rec = serve(ap.handleLocal, http.MethodOptions, ap.GetLocalURL("a.m4s"), "wails://wails")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preflight with token = %d", rec.Code)
+	}
+}
+
+func TestCORSOnlyForAppOrigin(t *testing.T) {
+	ap := newTestProxy(t)
+	target := ap.GetLocalURL("a.m4s")
+	for _, origin := range []string{"wails://wails", "http://wails.localhost"} {
+		rec := serve(ap.handleLocal, http.MethodGet, target, origin)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != origin {
+			t.Errorf("origin %s: Allow-Origin = %q", origin, got)
+		}
+	}
+	for _, origin := range []string{"", "https://evil.example", "null"} {
+		rec := serve(ap.handleLocal, http.MethodGet, target, origin)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("origin %q: Allow-Origin = %q, want none", origin, got)
+		}
+	}
+}
+
+func TestHostAllowList(t *testing.T) {
+	cases := map[string]bool{
+		"upos-sz-mirrorcos.bilivideo.com":  true,
+		"cn-gdfs-ct-01-01.bilivideo.com":   true,
+		"BILIVIDEO.COM.":                   true,
+		"i0.hdslb.com":                     true,
+		"upos-hz-mirrorakam.akamaized.net": true,
+		"xy1x2x3x4xy.mcdn.bilivideo.cn":    true,
+		"example.com":                      false,
+		"bilivideo.com.evil.example":       false,
+		"evilbilivideo.com":                false,
+		"other.akamaized.net":              false,
+		"127.0.0.1":                        false,
+		"localhost":                        false,
+	}
+	for host, want := range cases {
+		if got := hostAllowed(host); got != want {
+			t.Errorf("hostAllowed(%q) = %v, want %v", host, got, want)
+		}
+	}
+
+	for raw, ok := range map[string]bool{
+		"https://upos-sz-mirrorcos.bilivideo.com/a.m4s": true,
+		"file:///etc/passwd":                            false,
+		"https://user:pw@i0.hdslb.com/a.jpg":            false,
+		"http://127.0.0.1:8080/admin":                   false,
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := checkUpstream(u); (err == nil) != ok {
+			t.Errorf("checkUpstream(%q) = %v, want allowed=%v", raw, err, ok)
+		}
+	}
+}
+
+func TestRejectsDisallowedUpstream(t *testing.T) {
+	ap := newTestProxy(t)
+	for _, path := range []string{"/audio", "/image"} {
+		target := path + "?u=" + url.QueryEscape("http://127.0.0.1:1/secret") + "&t=" + ap.Token()
+		h := ap.handleAudio
+		if path == "/image" {
+			h = ap.handleImage
+		}
+		if rec := serve(h, http.MethodGet, target, ""); rec.Code != http.StatusForbidden {
+			t.Errorf("%s to loopback = %d, want 403", path, rec.Code)
+		}
+	}
+}
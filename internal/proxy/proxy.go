@@ -21,6 +21,7 @@ type AudioProxy struct {
 	server     *http.Server
 	httpClient *http.Client
 	baseDir    string
+	token      string // 每次启动生成，所有请求都需携带
 	mu         sync.RWMutex
 	isRunning  bool
 
@@ -29,10 +30,14 @@ type AudioProxy struct {
 }
 
 func NewAudioProxy(port int, httpClient *http.Client, baseDir string) *AudioProxy {
+	// 共用传输层与 cookie，但重定向只允许跳转到白名单主机
+	client := *httpClient
+	client.CheckRedirect = checkRedirect
 	return &AudioProxy{
 		port:       port,
-		httpClient: httpClient,
+		httpClient: &client,
 		baseDir:    baseDir,
+		token:      newToken(),
 		cacheInFlight: map[string]struct{}{},
 	}
 }
@@ -122,6 +127,8 @@ func (ap *AudioProxy) ensureCachedAsync(decodedURL, sid string) {
 	}()
 }
 
+// Start listens on the configured port. If that port is taken it falls back to a
+// free port chosen by the system; Addr reports the port actually used.
 func (ap *AudioProxy) Start() error {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
@@ -135,14 +142,18 @@ func (ap *AudioProxy) Start() error {
 	mux.HandleFunc("/local", ap.handleLocal)
 	mux.HandleFunc("/image", ap.handleImage)
 
-	server := &http.Server{
-		Addr:    fmt.Sprintf("127.0.0.1:%d", ap.port),
-		Handler: mux,
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", ap.port))
+	if err != nil && ap.port != 0 {
+		fmt.Printf("[Proxy] Port %d unavailable (%v), using a free port\n", ap.port, err)
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
 	}
-
-	listener, err := net.Listen("tcp", server.Addr)
 	if err != nil {
-		return err
+		return fmt.Errorf("audio proxy listen: %w", err)
+	}
+
+	server := &http.Server{
+		Addr:    listener.Addr().String(),
+		Handler: mux,
 	}
 
 	ap.listener = listener
@@ -153,6 +164,7 @@ func (ap *AudioProxy) Start() error {
 		_ = server.Serve(listener)
 	}()
 
+	fmt.Printf("[Proxy] Listening on %s\n", server.Addr)
 	return nil
 }
 
@@ -175,27 +187,56 @@ func (ap *AudioProxy) Stop() error {
 	return nil
 }
 
+// SetPort changes the port used by the next Start; 0 picks a free port.
+func (ap *AudioProxy) SetPort(port int) {
+	ap.mu.Lock()
+	ap.port = port
+	ap.mu.Unlock()
+}
+
+// Port returns the configured port.
+func (ap *AudioProxy) Port() int {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+	return ap.port
+}
+
+// IsRunning reports whether the proxy is serving.
+func (ap *AudioProxy) IsRunning() bool {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+	return ap.isRunning
+}
+
+// Addr returns the address the proxy listens on, or the configured one when stopped.
+func (ap *AudioProxy) Addr() string {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+	if ap.isRunning && ap.listener != nil {
+		return ap.listener.Addr().String()
+	}
+	return fmt.Sprintf("127.0.0.1:%d", ap.port)
+}
+
+// BaseURL returns the http:// origin of the proxy.
+func (ap *AudioProxy) BaseURL() string {
+	return "http://" + ap.Addr()
+}
+
 func (ap *AudioProxy) handleAudio(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers first for all responses
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Range")
+	setCORS(w, r, "GET, HEAD, OPTIONS")
 
-	// Handle preflight
+	// Preflight requests carry the token in the URL as well
+	if !ap.authorized(w, r) {
+		return
+	}
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
-
-	rawURL := r.URL.Query().Get("u")
-	if rawURL == "" {
-		http.Error(w, "missing u parameter", http.StatusBadRequest)
-		return
-	}
-
-	decodedURL, err := url.QueryUnescape(rawURL)
-	if err != nil {
-		http.Error(w, "invalid URL encoding", http.StatusBadRequest)
+	decodedURL := upstreamURL(w, r)
+	if decodedURL == "" {
 		return
 	}
 
@@ -344,7 +385,6 @@ func (ap *AudioProxy) serveLocalFile(w http.ResponseWriter, r *http.Request, fil
 	w.Header().Set("Content-Type", "audio/mp4")
 	w.Header().Set("Accept-Ranges", "bytes")
 	w.Header().Set("Cache-Control", "public, max-age=86400")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	fileSize := fileInfo.Size()
 
@@ -406,22 +446,28 @@ func parseRange(s string, size int64) ([]httpRange, error) {
 
 // GetProxyURL returns the full proxy URL for an audio stream
 func (ap *AudioProxy) GetProxyURL(audioURL string) string {
-	return fmt.Sprintf("http://127.0.0.1:%d/audio?u=%s", ap.port, url.QueryEscape(audioURL))
+	return ap.withToken(fmt.Sprintf("%s/audio?u=%s", ap.BaseURL(), url.QueryEscape(audioURL)))
 }
 
 // GetImageProxyURL returns the full proxy URL for an image
 func (ap *AudioProxy) GetImageProxyURL(imageURL string) string {
-	return fmt.Sprintf("http://127.0.0.1:%d/image?u=%s", ap.port, url.QueryEscape(imageURL))
+	return ap.withToken(fmt.Sprintf("%s/image?u=%s", ap.BaseURL(), url.QueryEscape(imageURL)))
+}
+
+// GetLocalURL returns the proxy URL of a cached or downloaded audio file
+func (ap *AudioProxy) GetLocalURL(name string) string {
+	return ap.withToken(fmt.Sprintf("%s/local?f=%s", ap.BaseURL(), url.QueryEscape(name)))
 }
 
 // handleLocal serves cached local audio files under baseDir/audio_cache via /local?f=filename
 func (ap *AudioProxy) handleLocal(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers first for all responses
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Range")
+	setCORS(w, r, "GET, OPTIONS")
 
-	// Handle preflight
+	// Preflight requests carry the token in the URL as well
+	if !ap.authorized(w, r) {
+		return
+	}
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
 		return
@@ -466,25 +512,18 @@ func (ap *AudioProxy) handleLocal(w http.ResponseWriter, r *http.Request) {
 // handleImage proxies image requests to bypass CORS and Referer restrictions
 func (ap *AudioProxy) handleImage(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers first for all responses
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Range")
+	setCORS(w, r, "GET, HEAD, OPTIONS")
 
-	// Handle preflight
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	// Preflight requests carry the token in the URL as well
+	if !ap.authorized(w, r) {
 		return
 	}
-
-	rawURL := r.URL.Query().Get("u")
-	if rawURL == "" {
-		http.Error(w, "missing u parameter", http.StatusBadRequest)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
 		return
 	}
-
-	decodedURL, err := url.QueryUnescape(rawURL)
-	if err != nil {
-		http.Error(w, "invalid URL encoding", http.StatusBadRequest)
+	decodedURL := upstreamURL(w, r)
+	if decodedURL == "" {
 		return
 	}
 
@@ -0,0 +1,167 @@
+package scrobbler
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLastFMURL is the Last.fm audioscrobbler 2.0 endpoint.
+const DefaultLastFMURL = "https://ws.audioscrobbler.com/2.0/"
+
+// LastFM implements the Last.fm audioscrobbler 2.0 protocol (also spoken by Libre.fm and Maloja).
+type LastFM struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string
+	SessionKey string
+	HTTPClient *http.Client
+}
+
+// Last.fm 错误码中只有服务暂不可用类需要重试
+var lastFMRetryableCodes = map[int]bool{11: true, 16: true, 29: true}
+
+// 鉴权类错误：权限不足、session key 无效、API key 无效、API key 被停用
+var lastFMAuthCodes = map[int]bool{4: true, 9: true, 10: true, 26: true}
+
+func (lf *LastFM) NowPlaying(ctx context.Context, t Track) error {
+	params := url.Values{}
+	params.Set("method", "track.updateNowPlaying")
+	params.Set("artist", t.Artist)
+	params.Set("track", t.Title)
+	if t.Album != "" {
+		params.Set("album", t.Album)
+	}
+	if t.DurationSec > 0 {
+		params.Set("duration", strconv.Itoa(t.DurationSec))
+	}
+	params.Set("sk", lf.SessionKey)
+	return lf.call(ctx, params, nil)
+}
+
+func (lf *LastFM) Scrobble(ctx context.Context, tracks []Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+	params := url.Values{}
+	params.Set("method", "track.scrobble")
+	for i, t := range tracks {
+		idx := "[" + strconv.Itoa(i) + "]"
+		params.Set("artist"+idx, t.Artist)
+		params.Set("track"+idx, t.Title)
+		params.Set("timestamp"+idx, strconv.FormatInt(t.ListenedAt.Unix(), 10))
+		if t.Album != "" {
+			params.Set("album"+idx, t.Album)
+		}
+		if t.DurationSec > 0 {
+			params.Set("duration"+idx, strconv.Itoa(t.DurationSec))
+		}
+	}
+	params.Set("sk", lf.SessionKey)
+	return lf.call(ctx, params, nil)
+}
+
+// MobileSession exchanges username/password for a session key (auth.getMobileSession).
+func (lf *LastFM) MobileSession(ctx context.Context, username, password string) (string, error) {
+	params := url.Values{}
+	params.Set("method", "auth.getMobileSession")
+	params.Set("username", username)
+	params.Set("password", password)
+
+	var res struct {
+		Session struct {
+			Key string `json:"key"`
+		} `json:"session"`
+	}
+	if err := lf.call(ctx, params, &res); err != nil {
+		return "", err
+	}
+	if res.Session.Key == "" {
+		return "", fmt.Errorf("last.fm: empty session key")
+	}
+	return res.Session.Key, nil
+}
+
+// sign computes api_sig: md5 of the sorted name/value pairs followed by the secret.
+func (lf *LastFM) sign(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k == "format" || k == "callback" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString(params.Get(k))
+	}
+	b.WriteString(lf.APISecret)
+	sum := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (lf *LastFM) call(ctx context.Context, params url.Values, out any) error {
+	params.Set("api_key", lf.APIKey)
+	params.Set("api_sig", lf.sign(params))
+	params.Set("format", "json")
+
+	endpoint := lf.BaseURL
+	if endpoint == "" {
+		endpoint = DefaultLastFMURL
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := lf.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("last.fm request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("last.fm http %d", resp.StatusCode)
+		}
+		return fmt.Errorf("last.fm decode error: %w", err)
+	}
+
+	var apiErr struct {
+		Error   int    `json:"error"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(raw, &apiErr)
+	if apiErr.Error != 0 {
+		reason := fmt.Sprintf("last.fm error %d: %s", apiErr.Error, apiErr.Message)
+		if lastFMRetryableCodes[apiErr.Error] {
+			return fmt.Errorf("%s", reason)
+		}
+		if lastFMAuthCodes[apiErr.Error] {
+			return &AuthError{Reason: reason}
+		}
+		return &RejectedError{Reason: reason}
+	}
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("last.fm http %d", resp.StatusCode)
+	}
+	if out != nil {
+		return json.Unmarshal(raw, out)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package scrobbler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultListenBrainzURL is the public ListenBrainz API root.
+const DefaultListenBrainzURL = "https://api.listenbrainz.org"
+
+// ListenBrainz implements the ListenBrainz JSON API (also served by Maloja under /apis/listenbrainz).
+type ListenBrainz struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+type lbTrackMetadata struct {
+	ArtistName     string         `json:"artist_name"`
+	TrackName      string         `json:"track_name"`
+	ReleaseName    string         `json:"release_name,omitempty"`
+	AdditionalInfo map[string]any `json:"additional_info,omitempty"`
+}
+
+type lbListen struct {
+	ListenedAt    int64           `json:"listened_at,omitempty"`
+	TrackMetadata lbTrackMetadata `json:"track_metadata"`
+}
+
+type lbSubmission struct {
+	ListenType string     `json:"listen_type"`
+	Payload    []lbListen `json:"payload"`
+}
+
+func (lb *ListenBrainz) NowPlaying(ctx context.Context, t Track) error {
+	return lb.submit(ctx, lbSubmission{
+		ListenType: "playing_now",
+		Payload:    []lbListen{{TrackMetadata: lbMetadata(t)}},
+	})
+}
+
+func (lb *ListenBrainz) Scrobble(ctx context.Context, tracks []Track) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+	listenType := "import"
+	if len(tracks) == 1 {
+		listenType = "single"
+	}
+	payload := make([]lbListen, 0, len(tracks))
+	for _, t := range tracks {
+		payload = append(payload, lbListen{ListenedAt: t.ListenedAt.Unix(), TrackMetadata: lbMetadata(t)})
+	}
+	return lb.submit(ctx, lbSubmission{ListenType: listenType, Payload: payload})
+}
+
+func lbMetadata(t Track) lbTrackMetadata {
+	info := map[string]any{"submission_client": "half-beat"}
+	if t.DurationSec > 0 {
+		info["duration_ms"] = t.DurationSec * 1000
+	}
+	if t.OriginURL != "" {
+		info["origin_url"] = t.OriginURL
+	}
+	return lbTrackMetadata{
+		ArtistName:     t.Artist,
+		TrackName:      t.Title,
+		ReleaseName:    t.Album,
+		AdditionalInfo: info,
+	}
+}
+
+func (lb *ListenBrainz) submit(ctx context.Context, body lbSubmission) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	base := strings.TrimRight(lb.BaseURL, "/")
+	if base == "" {
+		base = DefaultListenBrainzURL
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", base+"/1/submit-listens", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Token "+lb.Token)
+
+	client := lb.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("listenbrainz request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return &AuthError{Reason: fmt.Sprintf("listenbrainz http %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))}
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return &RejectedError{Reason: fmt.Sprintf("listenbrainz http %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))}
+	}
+	return fmt.Errorf("listenbrainz http %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
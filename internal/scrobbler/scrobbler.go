@@ -0,0 +1,290 @@
+// Package scrobbler submits listens to ListenBrainz or Last.fm compatible servers.
+//
+// Listens are written to the scrobble_queue_items table first and sent by a
+// background worker, so nothing is lost while the network is down.
+package scrobbler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	batchSize     = 50
+	flushInterval = time.Minute
+	maxBackoff    = 6 * time.Hour
+)
+
+// Track describes one listen.
+type Track struct {
+	Artist      string
+	Title       string
+	Album       string
+	DurationSec int
+	OriginURL   string
+	ListenedAt  time.Time
+}
+
+// Client is a scrobble protocol implementation.
+type Client interface {
+	NowPlaying(ctx context.Context, t Track) error
+	Scrobble(ctx context.Context, tracks []Track) error
+}
+
+// RejectedError marks a submission the server refused; retrying it will not help.
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "scrobble rejected: " + e.Reason
+}
+
+// AuthError marks a client whose credentials the server refused (bad token or
+// session key). Queued listens are kept until the client is reconfigured.
+type AuthError struct {
+	Reason string
+}
+
+func (e *AuthError) Error() string {
+	return "scrobble auth failed: " + e.Reason
+}
+
+// Scrobbler queues listens and submits them through the configured Client.
+type Scrobbler struct {
+	db *gorm.DB
+
+	mu       sync.RWMutex
+	client   Client
+	authFail error // 凭据被拒后暂停提交，直到 SetClient 更换客户端
+
+	wake chan struct{}
+}
+
+func New(db *gorm.DB) *Scrobbler {
+	return &Scrobbler{db: db, wake: make(chan struct{}, 1)}
+}
+
+// SetClient replaces the active client; nil disables submission (queued items are kept).
+func (s *Scrobbler) SetClient(c Client) {
+	s.mu.Lock()
+	s.client = c
+	s.authFail = nil
+	s.mu.Unlock()
+	s.notify()
+}
+
+func (s *Scrobbler) currentClient() Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client
+}
+
+// AuthFailure returns the error that paused submission, or nil.
+func (s *Scrobbler) AuthFailure() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.authFail
+}
+
+func (s *Scrobbler) pause(err error) {
+	s.mu.Lock()
+	s.authFail = err
+	s.mu.Unlock()
+	log.Printf("[Scrobble] paused until the client is reconfigured: %v", err)
+}
+
+// Enabled reports whether a client is configured.
+func (s *Scrobbler) Enabled() bool {
+	return s.currentClient() != nil
+}
+
+// NowPlaying sends a best-effort "now playing" notification. It is not queued.
+func (s *Scrobbler) NowPlaying(t Track) {
+	c := s.currentClient()
+	if c == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := c.NowPlaying(ctx, t); err != nil {
+			log.Printf("[Scrobble] now playing failed: %v", err)
+		}
+	}()
+}
+
+// Enqueue persists a listen and wakes the worker.
+func (s *Scrobbler) Enqueue(t Track) error {
+	if s.currentClient() == nil {
+		return nil
+	}
+	item := models.ScrobbleQueueItem{
+		Artist:        t.Artist,
+		Title:         t.Title,
+		Album:         t.Album,
+		DurationSec:   t.DurationSec,
+		OriginURL:     t.OriginURL,
+		ListenedAt:    t.ListenedAt,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.db.Create(&item).Error; err != nil {
+		return fmt.Errorf("queue scrobble: %w", err)
+	}
+	s.notify()
+	return nil
+}
+
+// Pending returns the number of queued listens.
+func (s *Scrobbler) Pending() (int64, error) {
+	var n int64
+	err := s.db.Model(&models.ScrobbleQueueItem{}).Count(&n).Error
+	return n, err
+}
+
+// Start runs the submission worker until ctx is done.
+func (s *Scrobbler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			if err := s.Flush(ctx, false); err != nil {
+				log.Printf("[Scrobble] flush failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// Flush submits due queued listens in batches. With force set, backoff and an
+// auth pause are ignored.
+func (s *Scrobbler) Flush(ctx context.Context, force bool) error {
+	for {
+		c := s.currentClient()
+		if c == nil {
+			return nil
+		}
+		if !force && s.AuthFailure() != nil {
+			return nil
+		}
+
+		q := s.db.Order("listened_at").Limit(batchSize)
+		if !force {
+			q = q.Where("next_attempt_at <= ?", time.Now())
+		}
+		var items []models.ScrobbleQueueItem
+		if err := q.Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		tracks := make([]Track, 0, len(items))
+		ids := make([]uint, 0, len(items))
+		for _, it := range items {
+			tracks = append(tracks, trackOf(it))
+			ids = append(ids, it.ID)
+		}
+
+		err := c.Scrobble(ctx, tracks)
+		var rejected *RejectedError
+		var authErr *AuthError
+		switch {
+		case err == nil:
+			if err := s.db.Delete(&models.ScrobbleQueueItem{}, ids).Error; err != nil {
+				return err
+			}
+		case errors.As(err, &authErr):
+			s.pause(err)
+			return err
+		case errors.As(err, &rejected) && len(items) > 1:
+			// 整批被拒时逐条重试，只丢弃服务器单独拒绝的记录
+			if err := s.submitEach(ctx, c, items); err != nil {
+				return err
+			}
+		case errors.As(err, &rejected):
+			log.Printf("[Scrobble] dropping listen %d: %v", ids[0], err)
+			if err := s.db.Delete(&models.ScrobbleQueueItem{}, ids).Error; err != nil {
+				return err
+			}
+		default:
+			s.backoff(items, err)
+			return err
+		}
+		if len(items) < batchSize {
+			return nil
+		}
+	}
+}
+
+// submitEach sends items one by one after their batch was rejected.
+func (s *Scrobbler) submitEach(ctx context.Context, c Client, items []models.ScrobbleQueueItem) error {
+	for i, it := range items {
+		err := c.Scrobble(ctx, []Track{trackOf(it)})
+		var rejected *RejectedError
+		var authErr *AuthError
+		switch {
+		case err == nil:
+		case errors.As(err, &rejected):
+			log.Printf("[Scrobble] dropping listen %d: %v", it.ID, err)
+		case errors.As(err, &authErr):
+			s.pause(err)
+			return err
+		default:
+			s.backoff(items[i:], err)
+			return err
+		}
+		if err := s.db.Delete(&models.ScrobbleQueueItem{}, it.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func trackOf(it models.ScrobbleQueueItem) Track {
+	return Track{
+		Artist:      it.Artist,
+		Title:       it.Title,
+		Album:       it.Album,
+		DurationSec: it.DurationSec,
+		OriginURL:   it.OriginURL,
+		ListenedAt:  it.ListenedAt,
+	}
+}
+
+// backoff schedules the next attempt of failed items with exponential delay.
+func (s *Scrobbler) backoff(items []models.ScrobbleQueueItem, cause error) {
+	for _, it := range items {
+		attempts := it.Attempts + 1
+		delay := time.Minute << min(attempts, 10)
+		if delay > maxBackoff {
+			delay = maxBackoff
+		}
+		_ = s.db.Model(&models.ScrobbleQueueItem{}).Where("id = ?", it.ID).Updates(map[string]any{
+			"attempts":        attempts,
+			"last_error":      cause.Error(),
+			"next_attempt_at": time.Now().Add(delay),
+		}).Error
+	}
+}
+
+func (s *Scrobbler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
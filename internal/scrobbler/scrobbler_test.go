@@ -0,0 +1,228 @@
+package scrobbler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestScrobbler(t *testing.T, c Client, titles ...string) *Scrobbler {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "q.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.AutoMigrate(&models.ScrobbleQueueItem{}); err != nil {
+		t.Fatal(err)
+	}
+	s := New(gdb)
+	s.SetClient(c)
+	base := time.Now().Add(-time.Hour)
+	for i, title := range titles {
+		if err := s.Enqueue(Track{Artist: "a", Title: title, ListenedAt: base.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func queuedTitles(t *testing.T, s *Scrobbler) []string {
+	t.Helper()
+	var items []models.ScrobbleQueueItem
+	if err := s.db.Order("listened_at").Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	out := make([]string, len(items))
+	for i, it := range items {
+		out[i] = it.Title
+	}
+	return out
+}
+
+func assertQueue(t *testing.T, s *Scrobbler, want ...string) {
+	t.Helper()
+	got := queuedTitles(t, s)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("queue = %v, want %v", got, want)
+	}
+}
+
+// listenBrainzServer answers status for every submission containing the title
+// "bad" and 200 otherwise.
+func listenBrainzServer(t *testing.T, status int, requests *int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		var body lbSubmission
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode submission: %v", err)
+		}
+		for _, l := range body.Payload {
+			if l.TrackMetadata.TrackName == "bad" {
+				http.Error(w, "invalid listen", status)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestListenBrainzAuthErrorKeepsQueue(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		t.Run(fmt.Sprint(status), func(t *testing.T) {
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				http.Error(w, "invalid token", status)
+			}))
+			defer srv.Close()
+			s := newTestScrobbler(t, &ListenBrainz{BaseURL: srv.URL, Token: "x"}, "one", "two")
+
+			var authErr *AuthError
+			if err := s.Flush(context.Background(), false); !errors.As(err, &authErr) {
+				t.Fatalf("Flush = %v, want AuthError", err)
+			}
+			assertQueue(t, s, "one", "two")
+			if s.AuthFailure() == nil {
+				t.Fatal("scrobbler not paused after auth failure")
+			}
+
+			// 暂停期间不再请求服务器
+			if err := s.Flush(context.Background(), false); err != nil {
+				t.Fatal(err)
+			}
+			if n := atomic.LoadInt32(&requests); n != 1 {
+				t.Fatalf("requests while paused = %d, want 1", n)
+			}
+
+			s.SetClient(&ListenBrainz{BaseURL: srv.URL, Token: "y"})
+			if s.AuthFailure() != nil {
+				t.Fatal("SetClient did not clear the pause")
+			}
+		})
+	}
+}
+
+func TestListenBrainzDropsOnlyRejectedListens(t *testing.T) {
+	var requests int32
+	srv := listenBrainzServer(t, http.StatusBadRequest, &requests)
+	s := newTestScrobbler(t, &ListenBrainz{BaseURL: srv.URL, Token: "x"}, "one", "bad", "three")
+
+	if err := s.Flush(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	assertQueue(t, s)
+	// 一次整批提交 + 三次逐条提交
+	if n := atomic.LoadInt32(&requests); n != 4 {
+		t.Fatalf("requests = %d, want 4", n)
+	}
+}
+
+func TestListenBrainzTransientErrorBacksOff(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway} {
+		t.Run(fmt.Sprint(status), func(t *testing.T) {
+			var requests int32
+			srv := listenBrainzServer(t, status, &requests)
+			s := newTestScrobbler(t, &ListenBrainz{BaseURL: srv.URL, Token: "x"}, "bad", "two")
+
+			if err := s.Flush(context.Background(), false); err == nil {
+				t.Fatal("Flush succeeded on a transient error")
+			}
+			assertQueue(t, s, "bad", "two")
+			var item models.ScrobbleQueueItem
+			s.db.First(&item)
+			if item.Attempts != 1 || !item.NextAttemptAt.After(time.Now()) {
+				t.Fatalf("item not backed off: %+v", item)
+			}
+			if s.AuthFailure() != nil {
+				t.Fatal("transient error paused the scrobbler")
+			}
+		})
+	}
+}
+
+// lastFMServer answers the Last.fm error code for scrobbles containing the title
+// "bad" and a success document otherwise.
+func lastFMServer(t *testing.T, code int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		for k, v := range r.PostForm {
+			if strings.HasPrefix(k, "track") && v[0] == "bad" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, `{"error":%d,"message":"failed"}`, code)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"scrobbles":{"@attr":{"accepted":1,"ignored":0}}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLastFMErrorClasses(t *testing.T) {
+	cases := []struct {
+		code   int
+		queue  []string
+		paused bool
+	}{
+		{4, []string{"bad", "two"}, true},
+		{9, []string{"bad", "two"}, true},
+		{10, []string{"bad", "two"}, true},
+		{26, []string{"bad", "two"}, true},
+		{11, []string{"bad", "two"}, false},
+		{16, []string{"bad", "two"}, false},
+		{29, []string{"bad", "two"}, false},
+		{6, nil, false}, // 参数错误：仅丢弃被拒的那条
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprint(tc.code), func(t *testing.T) {
+			srv := lastFMServer(t, tc.code)
+			s := newTestScrobbler(t, &LastFM{BaseURL: srv.URL, APIKey: "k", APISecret: "s", SessionKey: "sk"}, "bad", "two")
+			s.Flush(context.Background(), false)
+			assertQueue(t, s, tc.queue...)
+			if paused := s.AuthFailure() != nil; paused != tc.paused {
+				t.Fatalf("paused = %v, want %v", paused, tc.paused)
+			}
+		})
+	}
+}
+
+func TestLastFMSignsRequests(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+	lf := &LastFM{BaseURL: srv.URL, APIKey: "k", APISecret: "s", SessionKey: "sk"}
+	if err := lf.Scrobble(context.Background(), []Track{{Artist: "a", Title: "t", ListenedAt: time.Unix(100, 0)}}); err != nil {
+		t.Fatal(err)
+	}
+	sig := form.Get("api_sig")
+	form.Del("api_sig")
+	if want := lf.sign(form); sig != want {
+		t.Fatalf("api_sig = %s, want %s", sig, want)
+	}
+	if form.Get("timestamp[0]") != "100" || form.Get("sk") != "sk" {
+		t.Fatalf("form = %v", form)
+	}
+}
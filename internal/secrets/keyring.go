@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+)
+
+// ErrKeyNotFound is returned by a Keyring when no secret is stored for the given entry.
+var ErrKeyNotFound = errors.New("secrets: key not found")
+
+// Keyring stores small secrets (the credential encryption key) outside the database.
+type Keyring interface {
+	Get(service, user string) (string, error)
+	Set(service, user, secret string) error
+}
+
+// DefaultKeyring returns the OS keyring, or a file-based stand-in when
+// HALF_BEAT_KEYRING_FILE is set (useful for tests and headless machines).
+func DefaultKeyring() Keyring {
+	if p := os.Getenv("HALF_BEAT_KEYRING_FILE"); p != "" {
+		return &FileKeyring{Path: p}
+	}
+	return SystemKeyring{}
+}
+
+// SystemKeyring uses the platform keyring (Keychain, Credential Manager, Secret Service).
+type SystemKeyring struct{}
+
+func (SystemKeyring) Get(service, user string) (string, error) {
+	secret, err := keyring.Get(service, user)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrKeyNotFound
+	}
+	return secret, err
+}
+
+func (SystemKeyring) Set(service, user, secret string) error {
+	return keyring.Set(service, user, secret)
+}
+
+// FileKeyring keeps secrets in a JSON file readable only by the current user.
+// It is not a secure store; it stands in for the OS keyring where none is available.
+type FileKeyring struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (k *FileKeyring) Get(service, user string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entries, err := k.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := entries[service+"/"+user]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return secret, nil
+}
+
+func (k *FileKeyring) Set(service, user, secret string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entries, err := k.load()
+	if err != nil {
+		return err
+	}
+	entries[service+"/"+user] = secret
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(k.Path), 0o700); err != nil {
+		return fmt.Errorf("create keyring dir: %w", err)
+	}
+	return os.WriteFile(k.Path, data, 0o600)
+}
+
+func (k *FileKeyring) load() (map[string]string, error) {
+	entries := map[string]string{}
+	data, err := os.ReadFile(k.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("read keyring file: %w", err)
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse keyring file: %w", err)
+	}
+	return entries, nil
+}
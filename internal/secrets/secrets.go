@@ -0,0 +1,300 @@
+// Package secrets encrypts credentials at rest.
+//
+// Values are sealed with AES-256-GCM. The key lives in the OS keyring when one is
+// available. Otherwise it is derived with scrypt from HALF_BEAT_PASSPHRASE when
+// that is set, or else read from a random key file created with mode 0600 next to
+// the database. Sealed values carry the key source so any kind can be opened later.
+//
+// Without a keyring or passphrase, the key file protects credentials only as well
+// as the data directory is protected: anyone who can read both the database and
+// the key file can decrypt them.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keyringService = "half-beat"
+	keyringUser    = "credential-key"
+
+	sealPrefix       = "enc:v1:"
+	sourceKeyring    = "kr"
+	sourcePassphrase = "pp"
+	sourceKeyFile    = "kf"
+
+	keySize  = 32
+	saltSize = 16
+)
+
+// Box seals and opens credential strings.
+type Box struct {
+	keyring    Keyring
+	passphrase string
+	saltPath   string
+	keyPath    string
+
+	mu      sync.Mutex
+	krKey   []byte
+	ppKey   []byte
+	kfKey   []byte
+	primary string
+}
+
+// NewBox creates a Box. kr may be nil to skip the keyring; passphrase may be empty
+// to skip the passphrase. saltPath is where the passphrase salt is kept and keyPath
+// is the key file used when neither is available.
+func NewBox(kr Keyring, passphrase, saltPath, keyPath string) *Box {
+	return &Box{keyring: kr, passphrase: passphrase, saltPath: saltPath, keyPath: keyPath}
+}
+
+// DefaultPassphrase returns HALF_BEAT_PASSPHRASE, or "" when unset.
+func DefaultPassphrase() string {
+	return os.Getenv("HALF_BEAT_PASSPHRASE")
+}
+
+// legacyPassphrase is the machine-bound passphrase older versions used when
+// HALF_BEAT_PASSPHRASE was unset. It is guessable, so it is only used to open
+// values sealed back then.
+func legacyPassphrase() string {
+	host, _ := os.Hostname()
+	home, _ := os.UserHomeDir()
+	return "half-beat|" + host + "|" + home
+}
+
+// IsSealed reports whether v was produced by Seal.
+func IsSealed(v string) bool {
+	return strings.HasPrefix(v, sealPrefix)
+}
+
+// Seal encrypts plain. Empty strings are returned unchanged.
+func (b *Box) Seal(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	source, key, err := b.primaryKey()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return sealPrefix + source + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal. Values without the seal prefix are
+// legacy plain text and are returned unchanged.
+func (b *Box) Open(v string) (string, error) {
+	if !IsSealed(v) {
+		return v, nil
+	}
+	source, payload, ok := strings.Cut(strings.TrimPrefix(v, sealPrefix), ":")
+	if !ok {
+		return "", errors.New("secrets: malformed sealed value")
+	}
+	key, err := b.keyFor(source)
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("secrets: decode sealed value: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("secrets: sealed value too short")
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("secrets: decrypt: %w", err)
+	}
+	return string(plain), nil
+}
+
+// primaryKey picks the key used for new values: the keyring key when the keyring
+// works, then the passphrase-derived key when a passphrase is set, then the key file.
+func (b *Box) primaryKey() (string, []byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.primary == "" {
+		_, krErr := b.keyringKeyLocked(true)
+		switch {
+		case krErr == nil:
+			b.primary = sourceKeyring
+		case b.passphrase != "":
+			b.primary = sourcePassphrase
+		default:
+			b.primary = sourceKeyFile
+		}
+	}
+	if b.primary == sourceKeyFile {
+		key, err := b.keyFileKeyLocked(true)
+		return b.primary, key, err
+	}
+	key, err := b.keyForLocked(b.primary)
+	return b.primary, key, err
+}
+
+func (b *Box) keyFor(source string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.keyForLocked(source)
+}
+
+func (b *Box) keyForLocked(source string) ([]byte, error) {
+	switch source {
+	case sourceKeyring:
+		return b.keyringKeyLocked(false)
+	case sourcePassphrase:
+		return b.passphraseKeyLocked()
+	case sourceKeyFile:
+		return b.keyFileKeyLocked(false)
+	default:
+		return nil, fmt.Errorf("secrets: unknown key source %q", source)
+	}
+}
+
+// keyringKeyLocked loads the key from the keyring, generating and storing one if create is set.
+func (b *Box) keyringKeyLocked(create bool) ([]byte, error) {
+	if b.krKey != nil {
+		return b.krKey, nil
+	}
+	if b.keyring == nil {
+		return nil, errors.New("secrets: no keyring configured")
+	}
+	encoded, err := b.keyring.Get(keyringService, keyringUser)
+	if errors.Is(err, ErrKeyNotFound) && create {
+		key := make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("generate key: %w", err)
+		}
+		encoded = base64.StdEncoding.EncodeToString(key)
+		if err := b.keyring.Set(keyringService, keyringUser, encoded); err != nil {
+			return nil, fmt.Errorf("store key in keyring: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("read key from keyring: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != keySize {
+		return nil, errors.New("secrets: invalid key in keyring")
+	}
+	b.krKey = key
+	return key, nil
+}
+
+func (b *Box) passphraseKeyLocked() ([]byte, error) {
+	if b.ppKey != nil {
+		return b.ppKey, nil
+	}
+	passphrase := b.passphrase
+	if passphrase == "" {
+		passphrase = legacyPassphrase()
+	}
+	salt, err := b.loadSalt()
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	b.ppKey = key
+	return key, nil
+}
+
+// keyFileKeyLocked reads the random key file, generating it if create is set.
+func (b *Box) keyFileKeyLocked(create bool) ([]byte, error) {
+	if b.kfKey != nil {
+		return b.kfKey, nil
+	}
+	if b.keyPath == "" {
+		return nil, errors.New("secrets: no key file configured")
+	}
+	data, err := os.ReadFile(b.keyPath)
+	switch {
+	case err == nil:
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != keySize {
+			return nil, errors.New("secrets: invalid key file")
+		}
+		b.kfKey = key
+		return key, nil
+	case !os.IsNotExist(err) || !create:
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(b.keyPath), 0o700); err != nil {
+		return nil, fmt.Errorf("create key dir: %w", err)
+	}
+	// O_EXCL: never replace a key another process just wrote
+	f, err := os.OpenFile(b.keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("create key file: %w", err)
+	}
+	_, err = f.WriteString(base64.StdEncoding.EncodeToString(key))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(b.keyPath)
+		return nil, fmt.Errorf("write key file: %w", err)
+	}
+	b.kfKey = key
+	return key, nil
+}
+
+// loadSalt reads the passphrase salt, creating it on first use.
+func (b *Box) loadSalt() ([]byte, error) {
+	salt, err := os.ReadFile(b.saltPath)
+	if err == nil && len(salt) == saltSize {
+		return salt, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read salt: %w", err)
+	}
+	salt = make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(b.saltPath), 0o755); err != nil {
+		return nil, fmt.Errorf("create salt dir: %w", err)
+	}
+	if err := os.WriteFile(b.saltPath, salt, 0o600); err != nil {
+		return nil, fmt.Errorf("write salt: %w", err)
+	}
+	return salt, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,134 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// brokenKeyring fails like a machine without a usable OS keyring.
+type brokenKeyring struct{}
+
+func (brokenKeyring) Get(service, user string) (string, error) { return "", errors.New("no keyring") }
+func (brokenKeyring) Set(service, user, secret string) error   { return errors.New("no keyring") }
+
+func testPaths(t *testing.T) (dir, salt, key string) {
+	dir = t.TempDir()
+	return dir, filepath.Join(dir, "credentials.salt"), filepath.Join(dir, "credentials.key")
+}
+
+func roundTrip(t *testing.T, b *Box, wantSource string) string {
+	t.Helper()
+	sealed, err := b.Seal("SESSDATA=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || !strings.HasPrefix(sealed, sealPrefix+wantSource+":") {
+		t.Fatalf("sealed = %q, want source %q", sealed, wantSource)
+	}
+	if strings.Contains(sealed, "secret") {
+		t.Fatal("plain text visible in sealed value")
+	}
+	plain, err := b.Open(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != "SESSDATA=secret" {
+		t.Fatalf("Open = %q", plain)
+	}
+	return sealed
+}
+
+func TestFileKeyringRoundTrip(t *testing.T) {
+	dir, salt, key := testPaths(t)
+	kr := &FileKeyring{Path: filepath.Join(dir, "keyring.json")}
+	sealed := roundTrip(t, NewBox(kr, "", salt, key), sourceKeyring)
+
+	// 新实例从 keyring 读回同一密钥
+	plain, err := NewBox(&FileKeyring{Path: kr.Path}, "", salt, key).Open(sealed)
+	if err != nil || plain != "SESSDATA=secret" {
+		t.Fatalf("reopen = %q, %v", plain, err)
+	}
+	if _, err := os.Stat(key); !os.IsNotExist(err) {
+		t.Fatalf("key file created while the keyring works: %v", err)
+	}
+}
+
+func TestFileKeyringMissingEntry(t *testing.T) {
+	kr := &FileKeyring{Path: filepath.Join(t.TempDir(), "keyring.json")}
+	if _, err := kr.Get(keyringService, keyringUser); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Get on empty keyring = %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestFallbackToKeyFile(t *testing.T) {
+	_, salt, key := testPaths(t)
+	sealed := roundTrip(t, NewBox(brokenKeyring{}, "", salt, key), sourceKeyFile)
+
+	info, err := os.Stat(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Fatalf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	plain, err := NewBox(nil, "", salt, key).Open(sealed)
+	if err != nil || plain != "SESSDATA=secret" {
+		t.Fatalf("reopen = %q, %v", plain, err)
+	}
+
+	// 密钥文件丢失后解密失败，且不会生成新的密钥文件
+	if err := os.Remove(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBox(nil, "", salt, key).Open(sealed); err == nil {
+		t.Fatal("opened without the key file")
+	}
+	if _, err := os.Stat(key); !os.IsNotExist(err) {
+		t.Fatalf("Open regenerated the key file: %v", err)
+	}
+}
+
+func TestFallbackToPassphrase(t *testing.T) {
+	_, salt, key := testPaths(t)
+	sealed := roundTrip(t, NewBox(brokenKeyring{}, "correct horse", salt, key), sourcePassphrase)
+
+	if _, err := os.Stat(key); !os.IsNotExist(err) {
+		t.Fatalf("key file created while a passphrase is set: %v", err)
+	}
+	if _, err := NewBox(nil, "wrong", salt, key).Open(sealed); err == nil {
+		t.Fatal("opened with the wrong passphrase")
+	}
+}
+
+func TestOpenLegacyMachinePassphrase(t *testing.T) {
+	_, salt, key := testPaths(t)
+	sealed, err := NewBox(nil, legacyPassphrase(), salt, "").Seal("old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := NewBox(nil, "", salt, key).Open(sealed)
+	if err != nil || plain != "old" {
+		t.Fatalf("legacy value = %q, %v", plain, err)
+	}
+}
+
+func TestOpenPlainAndMalformed(t *testing.T) {
+	_, salt, key := testPaths(t)
+	b := NewBox(nil, "", salt, key)
+	if plain, err := b.Open("legacy-plain"); err != nil || plain != "legacy-plain" {
+		t.Fatalf("plain value = %q, %v", plain, err)
+	}
+	if sealed, err := b.Seal(""); err != nil || sealed != "" {
+		t.Fatalf("Seal(\"\") = %q, %v", sealed, err)
+	}
+	for _, v := range []string{sealPrefix + "kf", sealPrefix + "xx:AAAA", sealPrefix + "kf:AA"} {
+		if _, err := b.Open(v); err == nil {
+			t.Fatalf("Open(%q) succeeded", v)
+		}
+	}
+}
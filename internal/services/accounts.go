@@ -0,0 +1,247 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"time"
+
+	"half-beat-player/internal/models"
+	"half-beat-player/internal/secrets"
+
+	"gorm.io/gorm"
+)
+
+// ===== Accounts =====
+
+// Account is the UI-facing view of a saved login; credentials are never exposed.
+type Account struct {
+	ID       uint      `json:"id"`
+	UID      int64     `json:"uid"`
+	Username string    `json:"username"`
+	Face     string    `json:"face"`
+	Active   bool      `json:"active"`
+	SavedAt  time.Time `json:"savedAt"`
+}
+
+// swappableJar wraps a cookie jar so that switching accounts replaces the
+// whole cookie set at once while requests keep using the same http.Client.
+type swappableJar struct {
+	mu  sync.RWMutex
+	jar http.CookieJar
+}
+
+func (j *swappableJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.RLock()
+	inner := j.jar
+	j.mu.RUnlock()
+	inner.SetCookies(u, cookies)
+}
+
+func (j *swappableJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.RLock()
+	inner := j.jar
+	j.mu.RUnlock()
+	return inner.Cookies(u)
+}
+
+func (j *swappableJar) swap(next http.CookieJar) {
+	j.mu.Lock()
+	j.jar = next
+	j.mu.Unlock()
+}
+
+// sessionCookie is the persisted form of one bilibili.com cookie.
+type sessionCookie struct {
+	Name    string    `json:"name"`
+	Value   string    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// sessionCookiesOf decodes the stored cookie set of a session.
+// Rows saved before full cookie persistence only carry SESSDATA.
+func sessionCookiesOf(session models.LoginSession) []sessionCookie {
+	var cookies []sessionCookie
+	if session.Cookies != "" {
+		if err := json.Unmarshal([]byte(session.Cookies), &cookies); err == nil && len(cookies) > 0 {
+			return cookies
+		}
+	}
+	if session.Sessdata == "" {
+		return nil
+	}
+	return []sessionCookie{{Name: "SESSDATA", Value: session.Sessdata, Expires: time.Now().AddDate(0, 1, 0)}}
+}
+
+// newSessionJar builds a fresh cookie jar holding the given cookies (nil = logged out).
+func newSessionJar(cookies []sessionCookie) http.CookieJar {
+	jar, _ := cookiejar.New(nil)
+	if len(cookies) == 0 {
+		return jar
+	}
+	biliURL := &url.URL{Scheme: "https", Host: "www.bilibili.com"}
+	httpCookies := make([]*http.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		expires := c.Expires
+		if expires.IsZero() {
+			expires = time.Now().AddDate(0, 1, 0) // 默认一个月有效期
+		}
+		httpCookies = append(httpCookies, &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     "/",
+			Domain:   ".bilibili.com",
+			Expires:  expires,
+			HttpOnly: c.Name == "SESSDATA",
+			Secure:   true,
+		})
+	}
+	jar.SetCookies(biliURL, httpCookies)
+	return jar
+}
+
+// credentialSaltFile holds the salt for the passphrase-derived credential key.
+const credentialSaltFile = "credentials.salt"
+
+// credentialKeyFile holds the credential key when no keyring or passphrase is available.
+const credentialKeyFile = "credentials.key"
+
+// sealSession encrypts the credential fields of a session before it is written.
+func (s *Service) sealSession(session *models.LoginSession) error {
+	for _, field := range []*string{&session.Sessdata, &session.Cookies, &session.RefreshToken} {
+		if secrets.IsSealed(*field) {
+			continue
+		}
+		sealed, err := s.secrets.Seal(*field)
+		if err != nil {
+			return fmt.Errorf("encrypt login session: %w", err)
+		}
+		*field = sealed
+	}
+	return nil
+}
+
+// openSession decrypts the credential fields of a session loaded from the DB.
+func (s *Service) openSession(session *models.LoginSession) error {
+	for _, field := range []*string{&session.Sessdata, &session.Cookies, &session.RefreshToken} {
+		plain, err := s.secrets.Open(*field)
+		if err != nil {
+			return fmt.Errorf("decrypt login session: %w", err)
+		}
+		*field = plain
+	}
+	return nil
+}
+
+// encryptLoginSessions seals credentials still stored in plain text by older versions.
+func (s *Service) encryptLoginSessions() error {
+	var sessions []models.LoginSession
+	if err := s.db.Find(&sessions).Error; err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if (session.Sessdata == "" || secrets.IsSealed(session.Sessdata)) &&
+			(session.Cookies == "" || secrets.IsSealed(session.Cookies)) &&
+			(session.RefreshToken == "" || secrets.IsSealed(session.RefreshToken)) {
+			continue
+		}
+		if err := s.sealSession(&session); err != nil {
+			return err
+		}
+		if err := s.db.Model(&models.LoginSession{}).Where("id = ?", session.ID).Updates(map[string]any{
+			"sessdata":      session.Sessdata,
+			"cookies":       session.Cookies,
+			"refresh_token": session.RefreshToken,
+		}).Error; err != nil {
+			return fmt.Errorf("save encrypted login session: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListAccounts returns every saved account, the active one first.
+func (s *Service) ListAccounts() ([]Account, error) {
+	var sessions []models.LoginSession
+	if err := s.db.Order("active desc").Order("saved_at desc").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	out := make([]Account, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, Account{
+			ID:       sess.ID,
+			UID:      sess.UID,
+			Username: sess.Username,
+			Face:     sess.Face,
+			Active:   sess.Active,
+			SavedAt:  sess.SavedAt,
+		})
+	}
+	return out, nil
+}
+
+// SwitchAccount makes the given saved account current and swaps the cookie jar to its session.
+func (s *Service) SwitchAccount(accountID uint) error {
+	s.accountMu.Lock()
+	defer s.accountMu.Unlock()
+
+	var target models.LoginSession
+	if err := s.db.First(&target, accountID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("账号不存在: %d", accountID)
+		}
+		return err
+	}
+	if err := s.openSession(&target); err != nil {
+		return err
+	}
+	cookies := sessionCookiesOf(target)
+	if len(cookies) == 0 {
+		return fmt.Errorf("账号登录信息已失效，请重新登录")
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.LoginSession{}).Where("id <> ?", target.ID).Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&target).Update("active", true).Error
+	}); err != nil {
+		return fmt.Errorf("switch account: %w", err)
+	}
+
+	s.cookieJar.swap(newSessionJar(cookies))
+	return nil
+}
+
+// RemoveAccount deletes a saved account. Removing the active account logs out.
+func (s *Service) RemoveAccount(accountID uint) error {
+	s.accountMu.Lock()
+	defer s.accountMu.Unlock()
+
+	var target models.LoginSession
+	if err := s.db.First(&target, accountID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := s.db.Delete(&target).Error; err != nil {
+		return fmt.Errorf("remove account: %w", err)
+	}
+	if target.Active {
+		s.cookieJar.swap(newSessionJar(nil))
+	}
+	return nil
+}
+
+// activeAccountUID returns the UID of the current account, or 0 when logged out.
+func (s *Service) activeAccountUID() int64 {
+	var session models.LoginSession
+	if err := s.db.Select("uid").Where("active = ?", true).First(&session).Error; err != nil {
+		return 0
+	}
+	return session.UID
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ===== Local audio proxy =====
+
+const (
+	proxyPortKey     = "proxyPort"
+	defaultProxyPort = 9999
+
+	// proxyStatusEvent is emitted with a ProxyStatus whenever the proxy starts,
+	// fails to start or moves to another port.
+	proxyStatusEvent = "proxy:status"
+)
+
+// ProxyStatus describes the local proxy that serves audio, images and local files.
+type ProxyStatus struct {
+	Running bool   `json:"running"`
+	BaseURL string `json:"baseUrl"` // 例如 http://127.0.0.1:9999
+	Port    int    `json:"port"`    // 设置中的端口，0 为自动
+	Token   string `json:"token"`   // 本次运行的访问令牌，代理 URL 需带上 t 参数
+	Error   string `json:"error"`
+}
+
+// GetProxyStatus returns the proxy state; the frontend builds proxy URLs from BaseURL.
+func (s *Service) GetProxyStatus() ProxyStatus {
+	s.proxyMu.Lock()
+	defer s.proxyMu.Unlock()
+	return s.proxyStatusLocked()
+}
+
+func (s *Service) proxyStatusLocked() ProxyStatus {
+	return ProxyStatus{
+		Running: s.audioProxy.IsRunning(),
+		BaseURL: s.audioProxy.BaseURL(),
+		Port:    s.audioProxy.Port(),
+		Token:   s.audioProxy.Token(),
+		Error:   s.proxyErr,
+	}
+}
+
+// StartAudioProxy starts the local proxy on the configured port, falling back to a
+// free port when it is taken. Failures are kept for GetProxyStatus and emitted to
+// the UI.
+func (s *Service) StartAudioProxy() error {
+	s.proxyMu.Lock()
+	err := s.audioProxy.Start()
+	s.proxyErr = ""
+	if err != nil {
+		s.proxyErr = err.Error()
+	}
+	status := s.proxyStatusLocked()
+	s.proxyMu.Unlock()
+
+	s.emitProxyStatus(status)
+	if err != nil {
+		return fmt.Errorf("音频代理启动失败: %w", err)
+	}
+	return nil
+}
+
+// StopAudioProxy stops the local proxy.
+func (s *Service) StopAudioProxy() error {
+	s.proxyMu.Lock()
+	defer s.proxyMu.Unlock()
+	return s.audioProxy.Stop()
+}
+
+func (s *Service) emitProxyStatus(status ProxyStatus) {
+	if s.appCtx != nil {
+		runtime.EventsEmit(s.appCtx, proxyStatusEvent, status)
+	}
+}
+
+// applyProxySettings moves a running proxy to the newly configured port.
+func (s *Service) applyProxySettings(cfg map[string]any) {
+	port := int(getConfigFloat(cfg, proxyPortKey, defaultProxyPort))
+	s.proxyMu.Lock()
+	changed := port != s.audioProxy.Port()
+	s.audioProxy.SetPort(port)
+	running := s.audioProxy.IsRunning()
+	s.proxyMu.Unlock()
+	if !changed || !running {
+		return
+	}
+	_ = s.StopAudioProxy()
+	if err := s.StartAudioProxy(); err != nil {
+		fmt.Printf("[Settings] restart audio proxy: %v\n", err)
+	}
+}
+
+// isProxyStreamURL reports whether a stored stream URL points at the local proxy's
+// /audio endpoint, on any port a previous session may have used.
+func isProxyStreamURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return u.Hostname() == "127.0.0.1" && u.Path == "/audio"
+}
@@ -0,0 +1,703 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"half-beat-player/internal/db"
+	"half-beat-player/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ===== Backup archives =====
+
+// AppVersion is recorded in backup manifests; set at build time with
+// -ldflags "-X half-beat-player/internal/services.AppVersion=x.y.z".
+var AppVersion = "dev"
+
+const (
+	backupFormat        = "half-beat-backup"
+	backupSchemaVersion = 2
+	backupManifestName  = "manifest.json"
+	backupExportsDir    = "exports"
+
+	// legacyExportFile holds an unversioned ExportData JSON (schema version 0).
+	legacyExportFile = "export.json"
+)
+
+// BackupOptions selects optional content of a backup archive.
+type BackupOptions struct {
+	IncludeCovers    bool `json:"includeCovers"`
+	IncludeDownloads bool `json:"includeDownloads"`
+}
+
+// BackupManifestFile describes one file inside a backup archive.
+type BackupManifestFile struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+	Entries int    `json:"entries,omitempty"` // 表文件中的记录数
+}
+
+// BackupManifest is stored as manifest.json at the root of a backup archive.
+type BackupManifest struct {
+	Format        string               `json:"format"`
+	SchemaVersion int                  `json:"schemaVersion"`
+	AppVersion    string               `json:"appVersion"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	Files         []BackupManifestFile `json:"files"`
+}
+
+// BackupImportResult reports what ImportBackup restored.
+type BackupImportResult struct {
+	SchemaVersion int            `json:"schemaVersion"` // 备份原始的 schema 版本
+	Migrated      bool           `json:"migrated"`
+	Tables        map[string]int `json:"tables"` // 表文件 -> 恢复的记录数
+	Files         int            `json:"files"`  // 恢复的封面与下载文件数
+}
+
+// BackupValidationError points at the archive entry that failed validation.
+type BackupValidationError struct {
+	File    string `json:"file"`
+	Index   int    `json:"index"` // 记录下标，-1 表示整个文件
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *BackupValidationError) Error() string {
+	loc := e.File
+	if e.Index >= 0 {
+		loc += fmt.Sprintf("[%d]", e.Index)
+	}
+	if e.Field != "" {
+		loc += "." + e.Field
+	}
+	return fmt.Sprintf("备份校验失败 %s: %s", loc, e.Message)
+}
+
+// backupTables holds every table of the library. Login sessions are not
+// included because their credentials are encrypted with a machine-bound key;
+// for the same reason the scrobble secrets are stripped from the settings.
+type backupTables struct {
+	StreamSources       []models.StreamSource
+	Songs               []models.Song
+	Favorites           []models.Favorite
+	SongRefs            []models.SongRef
+	PlayerSettings      []models.PlayerSetting
+	LyricMappings       []models.LyricMapping
+	Playlists           []models.Playlist
+	QueueEntries        []models.QueueEntry
+	QueueHistoryEntries []models.QueueHistoryEntry
+	PlayHistories       []models.PlayHistory
+	PlayEvents          []models.PlayEvent
+	ResumePositions     []models.ResumePosition
+	ScrobbleQueueItems  []models.ScrobbleQueueItem
+	Themes              []models.Theme
+}
+
+// backupTable binds an archive file to a table model and its rows.
+type backupTable struct {
+	file  string
+	model any
+	rows  any // 指向切片的指针
+}
+
+func (t *backupTables) list() []backupTable {
+	return []backupTable{
+		{"tables/stream_sources.json", &models.StreamSource{}, &t.StreamSources},
+		{"tables/songs.json", &models.Song{}, &t.Songs},
+		{"tables/favorites.json", &models.Favorite{}, &t.Favorites},
+		{"tables/song_refs.json", &models.SongRef{}, &t.SongRefs},
+		{"tables/player_settings.json", &models.PlayerSetting{}, &t.PlayerSettings},
+		{"tables/lyric_mappings.json", &models.LyricMapping{}, &t.LyricMappings},
+		{"tables/playlists.json", &models.Playlist{}, &t.Playlists},
+		{"tables/queue_entries.json", &models.QueueEntry{}, &t.QueueEntries},
+		{"tables/queue_history_entries.json", &models.QueueHistoryEntry{}, &t.QueueHistoryEntries},
+		{"tables/play_histories.json", &models.PlayHistory{}, &t.PlayHistories},
+		{"tables/play_events.json", &models.PlayEvent{}, &t.PlayEvents},
+		{"tables/resume_positions.json", &models.ResumePosition{}, &t.ResumePositions},
+		{"tables/scrobble_queue_items.json", &models.ScrobbleQueueItem{}, &t.ScrobbleQueueItems},
+		{"tables/themes.json", &models.Theme{}, &t.Themes},
+	}
+}
+
+// scrobbleSecretKeys are the encrypted fields of the "scrobble" settings entry.
+var scrobbleSecretKeys = []string{"token", "apiSecret", "sessionKey"}
+
+// withScrobbleSecrets replaces the scrobble secrets of every settings row with those
+// of from; a nil from clears them.
+func withScrobbleSecrets(settings []models.PlayerSetting, from map[string]any) {
+	for i := range settings {
+		cfg, ok := settings[i].Config["scrobble"].(map[string]any)
+		if !ok {
+			continue
+		}
+		out := make(map[string]any, len(cfg))
+		for k, v := range cfg {
+			out[k] = v
+		}
+		for _, k := range scrobbleSecretKeys {
+			if v, ok := from[k]; ok {
+				out[k] = v
+			} else {
+				delete(out, k)
+			}
+		}
+		settings[i].Config["scrobble"] = out
+	}
+}
+
+func sliceLen(rows any) int {
+	return reflect.ValueOf(rows).Elem().Len()
+}
+
+// zipWriter writes archive entries and records them for the manifest.
+type zipWriter struct {
+	zw    *zip.Writer
+	files []BackupManifestFile
+}
+
+func (w *zipWriter) add(name string, r io.Reader, entries int) error {
+	dst, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(dst, h), r)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	w.files = append(w.files, BackupManifestFile{Name: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil)), Entries: entries})
+	return nil
+}
+
+func (w *zipWriter) addDir(prefix, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		err = w.add(prefix+"/"+e.Name(), f, 0)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportBackup writes a backup archive of the whole library to dst. An empty dst
+// writes to the exports directory under the data directory. Returns the file path.
+func (s *Service) ExportBackup(dst string, opts BackupOptions) (string, error) {
+	if dst == "" {
+		dst = filepath.Join(s.dataDir, backupExportsDir, "half-beat-"+time.Now().Format("20060102-150405")+".zip")
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+
+	var tables backupTables
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range tables.list() {
+			if err := tx.Find(t.rows).Error; err != nil {
+				return fmt.Errorf("read %s: %w", t.file, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	withScrobbleSecrets(tables.PlayerSettings, nil)
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	w := &zipWriter{zw: zip.NewWriter(out)}
+	err = func() error {
+		for _, t := range tables.list() {
+			data, err := json.MarshalIndent(t.rows, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := w.add(t.file, bytes.NewReader(data), sliceLen(t.rows)); err != nil {
+				return err
+			}
+		}
+		if opts.IncludeCovers {
+			if err := w.addDir(coversDir, filepath.Join(s.dataDir, coversDir)); err != nil {
+				return err
+			}
+		}
+		if opts.IncludeDownloads {
+			if err := w.addDir(downloadsDir, filepath.Join(s.dataDir, downloadsDir)); err != nil {
+				return err
+			}
+		}
+		manifest := BackupManifest{
+			Format:        backupFormat,
+			SchemaVersion: backupSchemaVersion,
+			AppVersion:    AppVersion,
+			CreatedAt:     time.Now(),
+			Files:         w.files,
+		}
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		mw, err := w.zw.Create(backupManifestName)
+		if err != nil {
+			return err
+		}
+		if _, err := mw.Write(data); err != nil {
+			return err
+		}
+		return w.zw.Close()
+	}()
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("写入备份失败: %w", err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return dst, nil
+}
+
+// ===== Reading and migrating archives =====
+
+// backupArchive is a decoded archive: table files in memory, media left in the zip.
+type backupArchive struct {
+	manifest BackupManifest
+	files    map[string][]byte    // tables/*.json
+	media    map[string]*zip.File // covers/*, downloads/*
+	zr       *zip.ReadCloser
+}
+
+func (a *backupArchive) Close() error {
+	if a.zr != nil {
+		return a.zr.Close()
+	}
+	return nil
+}
+
+// backupMigrations[i] upgrades an archive from schema version i to i+1.
+var backupMigrations = []func(a *backupArchive) error{
+	migrateBackupV0,
+	migrateBackupV1,
+}
+
+// migrateBackupV0 converts a legacy ExportData JSON into per-table files.
+func migrateBackupV0(a *backupArchive) error {
+	raw, ok := a.files[legacyExportFile]
+	if !ok {
+		return &BackupValidationError{File: legacyExportFile, Index: -1, Message: "缺少导出数据"}
+	}
+	var legacy ExportData
+	if err := json.Unmarshal(raw, &legacy); err != nil {
+		return &BackupValidationError{File: legacyExportFile, Index: -1, Message: err.Error()}
+	}
+	var t backupTables
+	t.Songs = legacy.Songs
+	t.LyricMappings = legacy.Lyrics
+	if legacy.Settings.ID != 0 {
+		t.PlayerSettings = []models.PlayerSetting{legacy.Settings}
+	}
+	for _, fav := range legacy.Favorites {
+		for i, ref := range fav.SongIDs {
+			ref.ID = 0
+			ref.FavoriteID = fav.ID
+			ref.Position = i
+			t.SongRefs = append(t.SongRefs, ref)
+		}
+		fav.SongIDs = nil
+		t.Favorites = append(t.Favorites, fav)
+	}
+	for i := range t.SongRefs {
+		t.SongRefs[i].ID = uint(i + 1)
+	}
+
+	delete(a.files, legacyExportFile)
+	for _, tb := range t.list() {
+		data, err := json.Marshal(tb.rows)
+		if err != nil {
+			return err
+		}
+		a.files[tb.file] = data
+	}
+	return nil
+}
+
+// migrateBackupV1 moves custom themes out of the settings JSON into themes.json.
+func migrateBackupV1(a *backupArchive) error {
+	const file = "tables/player_settings.json"
+	raw, ok := a.files[file]
+	if !ok {
+		return nil
+	}
+	var settings []models.PlayerSetting
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return &BackupValidationError{File: file, Index: -1, Message: err.Error()}
+	}
+	var themes []models.Theme
+	for i := range settings {
+		themes = append(themes, db.SplitLegacyThemes(settings[i].Config)...)
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	a.files[file] = data
+	if data, err = json.Marshal(themes); err != nil {
+		return err
+	}
+	a.files["tables/themes.json"] = data
+	return nil
+}
+
+// isZipSafeMediaName accepts only covers/<name> and downloads/<name>.
+func isZipSafeMediaName(name string) bool {
+	dir, base := path.Split(name)
+	if base == "" || base == "." || base == ".." || strings.ContainsAny(base, `/\`) {
+		return false
+	}
+	return dir == coversDir+"/" || dir == downloadsDir+"/"
+}
+
+func hashZipFile(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// openBackup reads an archive (or a legacy JSON export) and verifies checksums.
+func openBackup(src string) (*backupArchive, error) {
+	head := make([]byte, 4)
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := io.ReadFull(f, head)
+	f.Close()
+
+	if n < 4 || !bytes.Equal(head, []byte("PK\x03\x04")) {
+		// 旧版 ExportData JSON
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return nil, err
+		}
+		return &backupArchive{
+			manifest: BackupManifest{Format: backupFormat, SchemaVersion: 0},
+			files:    map[string][]byte{legacyExportFile: data},
+			media:    map[string]*zip.File{},
+		}, nil
+	}
+
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("打开备份失败: %w", err)
+	}
+	a := &backupArchive{files: map[string][]byte{}, media: map[string]*zip.File{}, zr: zr}
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, zf := range zr.File {
+		entries[zf.Name] = zf
+	}
+
+	mf, ok := entries[backupManifestName]
+	if !ok {
+		a.Close()
+		return nil, &BackupValidationError{File: backupManifestName, Index: -1, Message: "缺少清单文件"}
+	}
+	rc, err := mf.Open()
+	if err == nil {
+		err = json.NewDecoder(rc).Decode(&a.manifest)
+		rc.Close()
+	}
+	if err != nil {
+		a.Close()
+		return nil, &BackupValidationError{File: backupManifestName, Index: -1, Message: err.Error()}
+	}
+	if a.manifest.Format != backupFormat {
+		a.Close()
+		return nil, &BackupValidationError{File: backupManifestName, Index: -1, Field: "format", Message: "不是 half-beat 备份"}
+	}
+	if a.manifest.SchemaVersion > backupSchemaVersion {
+		a.Close()
+		return nil, fmt.Errorf("备份来自更新的版本（schema %d，当前支持 %d），请先升级应用", a.manifest.SchemaVersion, backupSchemaVersion)
+	}
+
+	for i, mfile := range a.manifest.Files {
+		zf, ok := entries[mfile.Name]
+		if !ok {
+			a.Close()
+			return nil, &BackupValidationError{File: backupManifestName, Index: i, Field: "files", Message: "归档中缺少 " + mfile.Name}
+		}
+		if strings.HasPrefix(mfile.Name, "tables/") || mfile.Name == legacyExportFile {
+			rc, err := zf.Open()
+			if err != nil {
+				a.Close()
+				return nil, err
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				a.Close()
+				return nil, err
+			}
+			sum := sha256.Sum256(data)
+			if hex.EncodeToString(sum[:]) != mfile.SHA256 {
+				a.Close()
+				return nil, &BackupValidationError{File: mfile.Name, Index: -1, Message: "校验和不匹配"}
+			}
+			a.files[mfile.Name] = data
+			continue
+		}
+		if !isZipSafeMediaName(mfile.Name) {
+			a.Close()
+			return nil, &BackupValidationError{File: mfile.Name, Index: -1, Message: "非法的文件路径"}
+		}
+		sum, err := hashZipFile(zf)
+		if err != nil {
+			a.Close()
+			return nil, err
+		}
+		if sum != mfile.SHA256 {
+			a.Close()
+			return nil, &BackupValidationError{File: mfile.Name, Index: -1, Message: "校验和不匹配"}
+		}
+		a.media[mfile.Name] = zf
+	}
+	return a, nil
+}
+
+// decodeTables migrates the archive to the current schema and decodes every table.
+func (a *backupArchive) decodeTables() (*backupTables, error) {
+	for v := a.manifest.SchemaVersion; v < backupSchemaVersion; v++ {
+		if err := backupMigrations[v](a); err != nil {
+			return nil, fmt.Errorf("升级备份 schema %d -> %d: %w", v, v+1, err)
+		}
+	}
+	t := &backupTables{}
+	for _, tb := range t.list() {
+		data, ok := a.files[tb.file]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(data, tb.rows); err != nil {
+			return nil, &BackupValidationError{File: tb.file, Index: -1, Message: err.Error()}
+		}
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// validate checks keys and references between tables.
+func (t *backupTables) validate() error {
+	checkIDs := func(file string, n int, id func(i int) string) (map[string]bool, error) {
+		seen := make(map[string]bool, n)
+		for i := 0; i < n; i++ {
+			key := id(i)
+			if key == "" {
+				return nil, &BackupValidationError{File: file, Index: i, Field: "id", Message: "不能为空"}
+			}
+			if seen[key] {
+				return nil, &BackupValidationError{File: file, Index: i, Field: "id", Message: "重复的 ID " + key}
+			}
+			seen[key] = true
+		}
+		return seen, nil
+	}
+
+	if _, err := checkIDs("tables/stream_sources.json", len(t.StreamSources), func(i int) string { return t.StreamSources[i].ID }); err != nil {
+		return err
+	}
+	songs, err := checkIDs("tables/songs.json", len(t.Songs), func(i int) string { return t.Songs[i].ID })
+	if err != nil {
+		return err
+	}
+	favs, err := checkIDs("tables/favorites.json", len(t.Favorites), func(i int) string { return t.Favorites[i].ID })
+	if err != nil {
+		return err
+	}
+	if _, err := checkIDs("tables/lyric_mappings.json", len(t.LyricMappings), func(i int) string { return t.LyricMappings[i].ID }); err != nil {
+		return err
+	}
+	if _, err := checkIDs("tables/themes.json", len(t.Themes), func(i int) string { return t.Themes[i].ID }); err != nil {
+		return err
+	}
+	if _, err := checkIDs("tables/resume_positions.json", len(t.ResumePositions), func(i int) string { return t.ResumePositions[i].SongID }); err != nil {
+		return err
+	}
+
+	for i, ref := range t.SongRefs {
+		if !favs[ref.FavoriteID] {
+			return &BackupValidationError{File: "tables/song_refs.json", Index: i, Field: "favoriteId", Message: "引用了不存在的歌单 " + ref.FavoriteID}
+		}
+		if !songs[ref.SongID] {
+			return &BackupValidationError{File: "tables/song_refs.json", Index: i, Field: "songId", Message: "引用了不存在的歌曲 " + ref.SongID}
+		}
+	}
+	playlists := make(map[uint]bool, len(t.Playlists))
+	for _, p := range t.Playlists {
+		playlists[p.ID] = true
+	}
+	for i, e := range t.QueueEntries {
+		if !playlists[e.PlaylistID] {
+			return &BackupValidationError{File: "tables/queue_entries.json", Index: i, Field: "playlistId", Message: fmt.Sprintf("引用了不存在的队列 %d", e.PlaylistID)}
+		}
+	}
+	return nil
+}
+
+// InspectBackup reads and validates a backup without restoring it.
+func (s *Service) InspectBackup(src string) (BackupManifest, error) {
+	a, err := openBackup(src)
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	defer a.Close()
+	manifest := a.manifest
+	if _, err := a.decodeTables(); err != nil {
+		return manifest, err
+	}
+	return manifest, nil
+}
+
+// ImportBackup replaces the library with the content of a backup archive. Older
+// archives (including legacy ExportData JSON files) are migrated forward first, and
+// nothing is changed unless the whole archive validates. Media files are extracted
+// to a staging directory first and only moved into place after the database
+// restore succeeds. The local scrobble secrets are kept.
+func (s *Service) ImportBackup(src string) (BackupImportResult, error) {
+	result := BackupImportResult{Tables: map[string]int{}}
+	a, err := openBackup(src)
+	if err != nil {
+		return result, err
+	}
+	defer a.Close()
+	result.SchemaVersion = a.manifest.SchemaVersion
+	result.Migrated = a.manifest.SchemaVersion < backupSchemaVersion
+
+	tables, err := a.decodeTables()
+	if err != nil {
+		return result, err
+	}
+
+	staging, err := os.MkdirTemp(s.dataDir, ".restore-")
+	if err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(staging)
+	for name, zf := range a.media {
+		if err := extractZipFile(zf, filepath.Join(staging, filepath.FromSlash(name))); err != nil {
+			return result, fmt.Errorf("恢复 %s 失败: %w", name, err)
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var current []models.PlayerSetting
+		if err := tx.Find(&current).Error; err != nil {
+			return err
+		}
+		var localScrobble map[string]any
+		if len(current) > 0 {
+			localScrobble, _ = current[0].Config["scrobble"].(map[string]any)
+		}
+		withScrobbleSecrets(tables.PlayerSettings, localScrobble)
+
+		all := tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		for _, tb := range tables.list() {
+			if err := all.Delete(tb.model).Error; err != nil {
+				return fmt.Errorf("clear %s: %w", tb.file, err)
+			}
+			n := sliceLen(tb.rows)
+			result.Tables[tb.file] = n
+			if n == 0 {
+				continue
+			}
+			if err := tx.CreateInBatches(tb.rows, 200).Error; err != nil {
+				return fmt.Errorf("restore %s: %w", tb.file, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for name := range a.media {
+		rel := filepath.FromSlash(name)
+		dst := filepath.Join(s.dataDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return result, err
+		}
+		if err := os.Rename(filepath.Join(staging, rel), dst); err != nil {
+			return result, fmt.Errorf("恢复 %s 失败: %w", name, err)
+		}
+		result.Files++
+	}
+	s.reloadSettings()
+	return result, nil
+}
+
+func extractZipFile(zf *zip.File, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -56,7 +56,7 @@ func (s *Service) GetPlayURL(bvid string, p int) (PlayInfo, error) {
 		return PlayInfo{}, err
 	}
 
-	proxyURL := fmt.Sprintf("http://127.0.0.1:9999/audio?u=%s", url.QueryEscape(audioURL))
+	proxyURL := s.audioProxy.GetProxyURL(audioURL)
 
 	return PlayInfo{
 		RawURL:    audioURL,
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"sort"
+
+	"half-beat-player/internal/models"
+
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+// ===== Themes generated from cover art =====
+
+const (
+	paletteSize        = 6   // 提取的颜色数
+	paletteMaxSamples  = 64  // 采样网格边长上限
+	kmeansIterations   = 8   // median-cut 初值之后的 k-means 迭代次数
+	minPrimaryContrast = 3.0 // 主题色与背景（WCAG 非文本元素）
+	minTextContrast    = 7.0 // 主要文字（WCAG AAA）
+	minSubtextContrast = 4.5 // 次要文字（WCAG AA）
+)
+
+// PaletteSwatch is one quantized colour of a cover with its share of the pixels.
+type PaletteSwatch struct {
+	Color      string  `json:"color"`
+	Population float64 `json:"population"` // 0~1
+}
+
+type rgb struct{ r, g, b float64 } // 0~255
+
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", clampByte(c.r), clampByte(c.g), clampByte(c.b))
+}
+
+func clampByte(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(v))))
+}
+
+// luminance is the WCAG relative luminance.
+func (c rgb) luminance() float64 {
+	lin := func(v float64) float64 {
+		v /= 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*lin(c.r) + 0.7152*lin(c.g) + 0.0722*lin(c.b)
+}
+
+// contrastRatio is the WCAG contrast ratio of two colours (1~21).
+func contrastRatio(a, b rgb) float64 {
+	la, lb := a.luminance(), b.luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// saturation is the HSL saturation (0~1).
+func (c rgb) saturation() float64 {
+	hi := math.Max(c.r, math.Max(c.g, c.b)) / 255
+	lo := math.Min(c.r, math.Min(c.g, c.b)) / 255
+	if hi == lo {
+		return 0
+	}
+	l := (hi + lo) / 2
+	if l > 0.5 {
+		return (hi - lo) / (2 - hi - lo)
+	}
+	return (hi - lo) / (hi + lo)
+}
+
+func mix(a, b rgb, t float64) rgb {
+	return rgb{a.r + (b.r-a.r)*t, a.g + (b.g-a.g)*t, a.b + (b.b-a.b)*t}
+}
+
+func dist2(a, b rgb) float64 {
+	dr, dg, db := a.r-b.r, a.g-b.g, a.b-b.b
+	return dr*dr + dg*dg + db*db
+}
+
+var (
+	colorWhite = rgb{255, 255, 255}
+	colorBlack = rgb{0, 0, 0}
+)
+
+// ensureContrast mixes c toward toward in small steps until it reaches ratio
+// against bg, returning the closest colour that does.
+func ensureContrast(c, bg, toward rgb, ratio float64) rgb {
+	for t := 0.0; t <= 1; t += 0.05 {
+		if m := mix(c, toward, t); contrastRatio(m, bg) >= ratio {
+			return m
+		}
+	}
+	return toward
+}
+
+// sampleImage reads at most paletteMaxSamples² opaque pixels on an even grid.
+func sampleImage(img image.Image) []rgb {
+	bounds := img.Bounds()
+	step := max(1, max(bounds.Dx(), bounds.Dy())/paletteMaxSamples)
+	var px []rgb
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+			// 还原预乘 alpha
+			f := 255.0 / float64(a)
+			px = append(px, rgb{float64(r) * f, float64(g) * f, float64(b) * f})
+		}
+	}
+	return px
+}
+
+// medianCut splits the pixels into up to k boxes along their widest channel and
+// returns the box means.
+func medianCut(px []rgb, k int) []rgb {
+	channel := func(c rgb, ch int) float64 {
+		switch ch {
+		case 0:
+			return c.r
+		case 1:
+			return c.g
+		}
+		return c.b
+	}
+	widest := func(box []rgb) (int, float64) {
+		best, span := 0, -1.0
+		for ch := 0; ch < 3; ch++ {
+			lo, hi := math.Inf(1), math.Inf(-1)
+			for _, c := range box {
+				v := channel(c, ch)
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+			if hi-lo > span {
+				best, span = ch, hi-lo
+			}
+		}
+		return best, span
+	}
+
+	boxes := [][]rgb{px}
+	for len(boxes) < k {
+		// 拆分跨度最大的盒子
+		idx, ch, span := -1, 0, 0.0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if c, s := widest(box); s > span {
+				idx, ch, span = i, c, s
+			}
+		}
+		if idx < 0 {
+			break
+		}
+		box := boxes[idx]
+		sort.Slice(box, func(i, j int) bool { return channel(box[i], ch) < channel(box[j], ch) })
+		mid := len(box) / 2
+		boxes[idx] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	centers := make([]rgb, len(boxes))
+	for i, box := range boxes {
+		var sum rgb
+		for _, c := range box {
+			sum.r, sum.g, sum.b = sum.r+c.r, sum.g+c.g, sum.b+c.b
+		}
+		n := float64(len(box))
+		centers[i] = rgb{sum.r / n, sum.g / n, sum.b / n}
+	}
+	return centers
+}
+
+// quantize clusters the pixels with k-means seeded by median cut and returns the
+// clusters ordered by population.
+func quantize(px []rgb, k int) []PaletteSwatch {
+	if len(px) == 0 {
+		return nil
+	}
+	centers := medianCut(append([]rgb(nil), px...), k)
+	counts := make([]int, len(centers))
+	for iter := 0; iter < kmeansIterations; iter++ {
+		sums := make([]rgb, len(centers))
+		for i := range counts {
+			counts[i] = 0
+		}
+		for _, c := range px {
+			best := 0
+			for i := 1; i < len(centers); i++ {
+				if dist2(c, centers[i]) < dist2(c, centers[best]) {
+					best = i
+				}
+			}
+			sums[best].r += c.r
+			sums[best].g += c.g
+			sums[best].b += c.b
+			counts[best]++
+		}
+		for i := range centers {
+			if counts[i] > 0 {
+				n := float64(counts[i])
+				centers[i] = rgb{sums[i].r / n, sums[i].g / n, sums[i].b / n}
+			}
+		}
+	}
+
+	var swatches []PaletteSwatch
+	for i, c := range centers {
+		if counts[i] == 0 {
+			continue
+		}
+		swatches = append(swatches, PaletteSwatch{Color: c.hex(), Population: float64(counts[i]) / float64(len(px))})
+	}
+	sort.SliceStable(swatches, func(i, j int) bool { return swatches[i].Population > swatches[j].Population })
+	return swatches
+}
+
+func parseHexRGB(s string) rgb {
+	var r, g, b uint8
+	fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b)
+	return rgb{float64(r), float64(g), float64(b)}
+}
+
+// paletteThemeData derives theme colours from a palette: the dominant colour sets
+// the scheme and background, the most vivid one becomes the theme colour, and
+// every foreground colour is adjusted until it meets its contrast ratio.
+func paletteThemeData(swatches []PaletteSwatch) map[string]any {
+	dominant := parseHexRGB(swatches[0].Color)
+	dark := dominant.luminance() < 0.4
+
+	var bg, text rgb
+	if dark {
+		bg = mix(dominant, colorBlack, 0.75)
+		text = colorWhite
+	} else {
+		bg = mix(dominant, colorWhite, 0.85)
+		text = colorBlack
+	}
+	panel := mix(bg, text, 0.06)
+
+	// 主题色：饱和度与占比综合最高，且与背景可区分
+	primary, bestScore := dominant, -1.0
+	for _, sw := range swatches {
+		c := parseHexRGB(sw.Color)
+		score := c.saturation() * (0.3 + math.Sqrt(sw.Population)) * math.Min(contrastRatio(c, bg)/minPrimaryContrast, 1)
+		if score > bestScore {
+			primary, bestScore = c, score
+		}
+	}
+	primary = ensureContrast(primary, bg, text, minPrimaryContrast)
+
+	textPrimary := ensureContrast(mix(text, dominant, 0.1), bg, text, minTextContrast)
+	textSecondary := ensureContrast(mix(text, bg, 0.45), bg, text, minSubtextContrast)
+
+	palette := make([]string, len(swatches))
+	for i, sw := range swatches {
+		palette[i] = sw.Color
+	}
+	scheme := "light"
+	if dark {
+		scheme = "dark"
+	}
+	return map[string]any{
+		"colorScheme": scheme, "themeColor": primary.hex(),
+		"backgroundColor": bg.hex(), "backgroundOpacity": 1, "backgroundBlur": 0,
+		"panelColor": panel.hex(), "panelOpacity": 0.92, "panelBlur": 0, "panelRadius": 8,
+		"controlColor": panel.hex(), "controlOpacity": 1, "controlBlur": 0,
+		"textColorPrimary": textPrimary.hex(), "textColorSecondary": textSecondary.hex(),
+		"favoriteCardColor": panel.hex(), "cardOpacity": 1,
+		"componentRadius": 8, "modalRadius": 12, "notificationRadius": 8, "coverRadius": 8,
+		"modalColor": panel.hex(), "modalOpacity": 0.92, "modalBlur": 0,
+		"windowControlsPos": "right",
+		"palette":           palette,
+	}
+}
+
+// ExtractCoverPalette returns the main colours of a song's cover, most common first.
+func (s *Service) ExtractCoverPalette(songID string) ([]PaletteSwatch, error) {
+	_, swatches, err := s.coverPalette(songID)
+	return swatches, err
+}
+
+// coverPalette loads the song and extracts its cover palette. B站 covers are often
+// webp, hence the extra decoder registered above.
+func (s *Service) coverPalette(songID string) (models.Song, []PaletteSwatch, error) {
+	var song models.Song
+	if err := s.db.First(&song, "id = ?", songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return song, nil, fmt.Errorf("未找到歌曲: %s", songID)
+		}
+		return song, nil, fmt.Errorf("查询歌曲失败: %w", err)
+	}
+	path, err := s.ensureCoverCached(&song)
+	if err != nil {
+		return song, nil, err
+	}
+	if path == "" {
+		return song, nil, fmt.Errorf("歌曲没有封面")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return song, nil, fmt.Errorf("读取封面失败: %w", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return song, nil, fmt.Errorf("无法解析封面图片: %w", err)
+	}
+	swatches := quantize(sampleImage(img), paletteSize)
+	if len(swatches) == 0 {
+		return song, nil, fmt.Errorf("封面没有可用的颜色")
+	}
+	return song, swatches, nil
+}
+
+// GenerateCoverTheme builds an unsaved theme from a song's cover so the UI can follow
+// the playing song; pass it to CreateTheme to keep it.
+func (s *Service) GenerateCoverTheme(songID string) (models.Theme, error) {
+	song, swatches, err := s.coverPalette(songID)
+	if err != nil {
+		return models.Theme{}, err
+	}
+	return models.Theme{
+		ID:         "cover-" + songID,
+		Name:       song.Name,
+		Data:       mustThemeJSON(paletteThemeData(swatches)),
+		IsReadOnly: true,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func repeatColor(c rgb, n int) []rgb {
+	px := make([]rgb, n)
+	for i := range px {
+		px[i] = c
+	}
+	return px
+}
+
+func TestQuantize(t *testing.T) {
+	var px []rgb
+	px = append(px, repeatColor(rgb{200, 30, 30}, 60)...)
+	px = append(px, repeatColor(rgb{20, 20, 180}, 30)...)
+	px = append(px, repeatColor(rgb{240, 240, 240}, 10)...)
+
+	swatches := quantize(px, paletteSize)
+	if len(swatches) != 3 {
+		t.Fatalf("swatches = %+v, want the 3 distinct colours", swatches)
+	}
+	want := []PaletteSwatch{{"#c81e1e", 0.6}, {"#1414b4", 0.3}, {"#f0f0f0", 0.1}}
+	total := 0.0
+	for i, sw := range swatches {
+		if sw.Color != want[i].Color || math.Abs(sw.Population-want[i].Population) > 1e-9 {
+			t.Fatalf("swatch %d = %+v, want %+v", i, sw, want[i])
+		}
+		total += sw.Population
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Fatalf("populations sum to %f", total)
+	}
+
+	if quantize(nil, paletteSize) != nil {
+		t.Fatal("quantize(nil) returned swatches")
+	}
+	if got := quantize(repeatColor(rgb{1, 2, 3}, 5), paletteSize); len(got) != 1 || got[0].Population != 1 {
+		t.Fatalf("single colour = %+v", got)
+	}
+}
+
+func TestPaletteThemeDataContrast(t *testing.T) {
+	palettes := map[string][]PaletteSwatch{
+		"dark dominant":        {{"#101820", 0.7}, {"#f2aa4c", 0.3}},
+		"light dominant":       {{"#f5f0e1", 0.8}, {"#ffd700", 0.2}},
+		"low contrast accent":  {{"#ffffff", 0.5}, {"#fafafa", 0.5}},
+		"mid grey":             {{"#777777", 1}},
+		"saturated mid colour": {{"#ff0000", 0.5}, {"#00ff00", 0.3}, {"#0000ff", 0.2}},
+	}
+	for name, swatches := range palettes {
+		t.Run(name, func(t *testing.T) {
+			data := paletteThemeData(swatches)
+			if err := validateThemeData(mustThemeJSON(data)); err != nil {
+				t.Fatalf("generated theme is invalid: %v", err)
+			}
+			bg := parseHexRGB(data["backgroundColor"].(string))
+			for key, ratio := range map[string]float64{
+				"themeColor":         minPrimaryContrast,
+				"textColorPrimary":   minTextContrast,
+				"textColorSecondary": minSubtextContrast,
+			} {
+				c := parseHexRGB(data[key].(string))
+				// hex 取整可能带来极小误差
+				if r := contrastRatio(c, bg); r < ratio-0.05 {
+					t.Errorf("%s %s on %s: contrast %.2f < %.1f", key, data[key], data["backgroundColor"], r, ratio)
+				}
+			}
+			wantScheme := "light"
+			if parseHexRGB(swatches[0].Color).luminance() < 0.4 {
+				wantScheme = "dark"
+			}
+			if data["colorScheme"] != wantScheme {
+				t.Errorf("colorScheme = %v, want %s", data["colorScheme"], wantScheme)
+			}
+		})
+	}
+}
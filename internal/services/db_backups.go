@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"half-beat-player/internal/db"
+	"half-beat-player/internal/models"
+)
+
+// ===== Automatic database backups =====
+
+const (
+	dbBackupDir        = "backups"
+	dbBackupPrefix     = "half-beat-"
+	dbBackupTimeLayout = "20060102-150405"
+	dbBackupConfigKey  = "dbBackup"
+
+	// 启动后稍等片刻再备份，避免拖慢启动
+	dbBackupStartupDelay = 15 * time.Second
+	// 手动编辑的配置可能低于下限，调度时至少间隔这么久
+	dbBackupMinInterval = time.Hour
+	// 恢复前、迁移前的安全备份各保留最新的几份
+	dbBackupKeepSafety = 5
+)
+
+// DBBackupConfig controls the backup scheduler.
+type DBBackupConfig struct {
+	Enabled       bool    `json:"enabled"`
+	IntervalHours float64 `json:"intervalHours"`
+	KeepDaily     int     `json:"keepDaily"`  // 保留最近 N 天每天最新的一份
+	KeepWeekly    int     `json:"keepWeekly"` // 保留最近 N 周每周最新的一份
+}
+
+func defaultDBBackupConfig() DBBackupConfig {
+	return DBBackupConfig{Enabled: true, IntervalHours: 24, KeepDaily: 7, KeepWeekly: 4}
+}
+
+// DBBackup describes one backup file in the backups directory.
+type DBBackup struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+	Kind      string    `json:"kind"` // auto | manual | pre-restore | pre-migrate
+}
+
+// interval is the time between scheduled backups, never below dbBackupMinInterval.
+func (c DBBackupConfig) interval() time.Duration {
+	return max(time.Duration(c.IntervalHours*float64(time.Hour)), dbBackupMinInterval)
+}
+
+// GetDBBackupConfig returns the backup scheduler configuration.
+func (s *Service) GetDBBackupConfig() (DBBackupConfig, error) {
+	cfg := defaultDBBackupConfig()
+	setting, err := s.GetPlayerSetting()
+	if err != nil {
+		return cfg, err
+	}
+	if raw, ok := setting.Config[dbBackupConfigKey]; ok && raw != nil {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return cfg, err
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("parse backup config: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// SaveDBBackupConfig stores the backup scheduler configuration; it applies from the next run.
+func (s *Service) SaveDBBackupConfig(cfg DBBackupConfig) error {
+	if cfg.IntervalHours < 1 {
+		return fmt.Errorf("备份间隔不能小于 1 小时")
+	}
+	if cfg.KeepDaily < 1 || cfg.KeepWeekly < 0 {
+		return fmt.Errorf("保留份数无效")
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return s.savePlayerSetting(models.PlayerSetting{Config: map[string]any{dbBackupConfigKey: raw}})
+}
+
+// startBackupScheduler backs up the database shortly after startup and then every
+// IntervalHours, rotating old copies.
+func (s *Service) startBackupScheduler(ctx context.Context) {
+	go func() {
+		timer := time.NewTimer(dbBackupStartupDelay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			cfg, err := s.GetDBBackupConfig()
+			if err != nil {
+				log.Printf("load backup config: %v", err)
+				cfg = defaultDBBackupConfig()
+			}
+			if cfg.Enabled {
+				if _, err := s.createDBBackup("auto"); err != nil {
+					log.Printf("database backup failed: %v", err)
+				} else if err := s.rotateDBBackups(cfg); err != nil {
+					log.Printf("rotate database backups: %v", err)
+				}
+			}
+			timer.Reset(cfg.interval())
+		}
+	}()
+}
+
+// createDBBackup writes a verified copy of the database into the backups directory.
+func (s *Service) createDBBackup(kind string) (DBBackup, error) {
+	s.backupMu.Lock()
+	defer s.backupMu.Unlock()
+
+	now := time.Now()
+	dst := dbBackupPathAt(s.dataDir, kind, now)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return DBBackup{}, err
+	}
+	name := filepath.Base(dst)
+	if err := db.BackupTo(s.db, dst); err != nil {
+		return DBBackup{}, err
+	}
+	if err := db.CheckIntegrity(dst); err != nil {
+		os.Remove(dst)
+		return DBBackup{}, err
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		return DBBackup{}, err
+	}
+	log.Printf("database backup written to %s", dst)
+	return DBBackup{Name: name, Path: dst, Size: info.Size(), CreatedAt: now, Kind: kind}, nil
+}
+
+// DBBackupPath returns a new backup file path in dataDir's backups directory.
+// kind is auto, manual, pre-restore or pre-migrate.
+func DBBackupPath(dataDir, kind string) string {
+	return dbBackupPathAt(dataDir, kind, time.Now())
+}
+
+func dbBackupPathAt(dataDir, kind string, t time.Time) string {
+	name := dbBackupPrefix + t.Format(dbBackupTimeLayout)
+	if kind != "auto" {
+		name += "-" + kind
+	}
+	return filepath.Join(dataDir, dbBackupDir, name+".db")
+}
+
+// parseDBBackupName extracts the creation time and kind from a backup file name.
+func parseDBBackupName(name string) (time.Time, string, bool) {
+	if !strings.HasPrefix(name, dbBackupPrefix) || !strings.HasSuffix(name, ".db") {
+		return time.Time{}, "", false
+	}
+	rest := strings.TrimSuffix(strings.TrimPrefix(name, dbBackupPrefix), ".db")
+	if len(rest) < len(dbBackupTimeLayout) {
+		return time.Time{}, "", false
+	}
+	t, err := time.ParseInLocation(dbBackupTimeLayout, rest[:len(dbBackupTimeLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	kind := strings.TrimPrefix(rest[len(dbBackupTimeLayout):], "-")
+	if kind == "" {
+		kind = "auto"
+	}
+	return t, kind, true
+}
+
+// ListBackups returns database backups, newest first.
+func (s *Service) ListBackups() ([]DBBackup, error) {
+	dir := filepath.Join(s.dataDir, dbBackupDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []DBBackup{}, nil
+		}
+		return nil, err
+	}
+	out := []DBBackup{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		created, kind, ok := parseDBBackupName(e.Name())
+		if !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, DBBackup{Name: e.Name(), Path: filepath.Join(dir, e.Name()), Size: info.Size(), CreatedAt: created, Kind: kind})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// rotateDBBackups applies retention per kind. Scheduled backups keep the newest
+// one of each of the last KeepDaily days and KeepWeekly ISO weeks, and always the
+// newest one. Pre-restore and pre-migrate backups keep the newest
+// dbBackupKeepSafety each. Manual backups are never deleted.
+func (s *Service) rotateDBBackups(cfg DBBackupConfig) error {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	safety := make(map[string]int)
+	autoSeen := false
+	for _, b := range backups {
+		switch b.Kind {
+		case "auto":
+		case "pre-restore", "pre-migrate":
+			if safety[b.Kind] < dbBackupKeepSafety {
+				safety[b.Kind]++
+				keep[b.Name] = true
+			}
+			continue
+		default:
+			keep[b.Name] = true
+			continue
+		}
+		if !autoSeen {
+			autoSeen = true
+			keep[b.Name] = true
+		}
+		day := b.CreatedAt.Format("2006-01-02")
+		if !days[day] && len(days) < cfg.KeepDaily {
+			days[day] = true
+			keep[b.Name] = true
+		}
+		y, w := b.CreatedAt.ISOWeek()
+		week := fmt.Sprintf("%d-%02d", y, w)
+		if !weeks[week] && len(weeks) < cfg.KeepWeekly {
+			weeks[week] = true
+			keep[b.Name] = true
+		}
+	}
+	for _, b := range backups {
+		if keep[b.Name] {
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackupDatabaseNow creates a backup immediately.
+func (s *Service) BackupDatabaseNow() (DBBackup, error) {
+	return s.createDBBackup("manual")
+}
+
+// RestoreBackup replaces the database with a backup from the backups directory.
+// The current database is backed up first. The frontend should reload afterwards.
+func (s *Service) RestoreBackup(name string) error {
+	if name != filepath.Base(name) {
+		return fmt.Errorf("无效的备份名称: %s", name)
+	}
+	if _, _, ok := parseDBBackupName(name); !ok {
+		return fmt.Errorf("无效的备份名称: %s", name)
+	}
+	src := filepath.Join(s.dataDir, dbBackupDir, name)
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("备份不存在: %s", name)
+	}
+	if err := db.CheckIntegrity(src); err != nil {
+		return fmt.Errorf("备份已损坏: %w", err)
+	}
+	if _, err := s.createDBBackup("pre-restore"); err != nil {
+		return fmt.Errorf("恢复前备份失败: %w", err)
+	}
+
+	s.backupMu.Lock()
+	err := db.RestoreFrom(s.db, src)
+	s.backupMu.Unlock()
+	if err != nil {
+		return err
+	}
+	// 旧版本的备份需要升级到当前结构；恢复前的备份已在上面完成
+	if err := db.Migrate(s.db, ""); err != nil {
+		return fmt.Errorf("迁移恢复的数据库失败: %w", err)
+	}
+
+	// 重新加载依赖数据库内容的状态
+	_ = s.restoreLogin()
+	s.applyScrobbleConfig()
+	s.reloadSettings()
+	if err := s.migrateLegacyQueue(); err != nil {
+		log.Printf("migrate legacy queue: %v", err)
+	}
+	return nil
+}
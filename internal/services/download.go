@@ -137,6 +137,9 @@ func (s *Service) DownloadSong(songID string) (string, error) {
 		return "", fmt.Errorf("查询歌曲失败: %w", err)
 	}
 
+	s.downloadSlots.acquire()
+	defer s.downloadSlots.release()
+
 	// 封面本地缓存（最佳努力，不阻断音频下载）
 	if _, err := s.ensureCoverCached(&song); err != nil {
 		fmt.Printf("[Download] 封面缓存失败: %v\n", err)
@@ -145,7 +148,7 @@ func (s *Service) DownloadSong(songID string) (string, error) {
 	// Ensure we have a valid audio URL
 	var audioURL string
 	if song.StreamURL != "" && song.StreamURLExpiresAt.After(time.Now().Add(30*time.Second)) {
-		if strings.Contains(song.StreamURL, "127.0.0.1:9999/audio") {
+		if isProxyStreamURL(song.StreamURL) {
 			if song.BVID == "" {
 				return "", fmt.Errorf("歌曲缺少 BVID，无法解析播放地址")
 			}
@@ -336,13 +339,11 @@ func (s *Service) GetLocalAudioURL(songID string) (string, error) {
 	for _, candidate := range candidates {
 		path := filepath.Join(s.dataDir, cacheDir, candidate)
 		if _, err := os.Stat(path); err == nil {
-			urlStr := fmt.Sprintf("http://127.0.0.1:%d/local?f=%s", 9999, url.QueryEscape(candidate))
-			return urlStr, nil
+			return s.audioProxy.GetLocalURL(candidate), nil
 		}
 		path2 := filepath.Join(s.dataDir, downloadsDir, candidate)
 		if _, err := os.Stat(path2); err == nil {
-			urlStr := fmt.Sprintf("http://127.0.0.1:%d/local?f=%s", 9999, url.QueryEscape(candidate))
-			return urlStr, nil
+			return s.audioProxy.GetLocalURL(candidate), nil
 		}
 	}
 
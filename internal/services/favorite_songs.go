@@ -0,0 +1,321 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ===== Ordered favorite songs =====
+
+// Favorite sort keys. A leading "-" sorts descending.
+const (
+	favoriteSortManual   = ""
+	favoriteSortName     = "name"
+	favoriteSortSinger   = "singer"
+	favoriteSortAdded    = "added"
+	favoriteSortDuration = "duration"
+)
+
+// FavoriteSortDef describes a sort order for the favorite sort menu.
+type FavoriteSortDef struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+var favoriteSortDefs = []FavoriteSortDef{
+	{Key: favoriteSortManual, Description: "手动排序"},
+	{Key: favoriteSortName, Description: "按歌名"},
+	{Key: favoriteSortSinger, Description: "按歌手"},
+	{Key: favoriteSortAdded, Description: "按添加时间"},
+	{Key: favoriteSortDuration, Description: "按时长。时长取自播放记录，从未播放过的歌曲视为未知，正序时排在最前、倒序时排在最后"},
+}
+
+// GetFavoriteSortDefs returns the sort orders a favorite can use; a leading "-"
+// on the key sorts descending.
+func (s *Service) GetFavoriteSortDefs() []FavoriteSortDef {
+	out := make([]FavoriteSortDef, len(favoriteSortDefs))
+	copy(out, favoriteSortDefs)
+	return out
+}
+
+// orderedSongRefs preloads song refs in playlist order.
+func orderedSongRefs(db *gorm.DB) *gorm.DB {
+	return db.Order("song_refs.position, song_refs.id")
+}
+
+// favoriteWork is a favorite loaded inside a transaction for editing its songs.
+type favoriteWork struct {
+	fav  models.Favorite
+	refs []models.SongRef // 按 Position 排序
+}
+
+// loadFavoriteForEdit loads a manual favorite and its refs in order.
+func loadFavoriteForEdit(tx *gorm.DB, favID string) (*favoriteWork, error) {
+	w := &favoriteWork{}
+	if err := tx.First(&w.fav, "id = ?", favID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("歌单不存在: %s", favID)
+		}
+		return nil, err
+	}
+	if w.fav.SmartRules != "" {
+		return nil, fmt.Errorf("智能歌单为只读，请修改规则")
+	}
+	if err := orderedSongRefs(tx.Where("favorite_id = ?", favID)).Find(&w.refs).Error; err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// renumber writes back positions that changed.
+func (w *favoriteWork) renumber(tx *gorm.DB) error {
+	for i := range w.refs {
+		if w.refs[i].Position == i && w.refs[i].ID != 0 {
+			continue
+		}
+		w.refs[i].Position = i
+		if err := tx.Save(&w.refs[i]).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(&w.fav).Update("updated_at", time.Now()).Error
+}
+
+// sortRefs orders refs by the favorite's sort key using song metadata.
+func (w *favoriteWork) sortRefs(tx *gorm.DB) error {
+	key := strings.TrimPrefix(w.fav.SortBy, "-")
+	dir := "ASC"
+	if strings.HasPrefix(w.fav.SortBy, "-") {
+		dir = "DESC"
+	}
+	var expr string
+	switch key {
+	case favoriteSortManual:
+		return nil
+	case favoriteSortName:
+		expr = "songs.name COLLATE NOCASE"
+	case favoriteSortSinger:
+		expr = "songs.singer COLLATE NOCASE"
+	case favoriteSortAdded:
+		expr = "song_refs.added_at"
+	case favoriteSortDuration:
+		// 歌曲表没有时长，使用播放记录中上报的时长
+		expr = "(SELECT MAX(play_events.duration_seconds) FROM play_events WHERE play_events.song_id = song_refs.song_id)"
+	default:
+		return fmt.Errorf("未知的排序方式: %s", w.fav.SortBy)
+	}
+
+	var ids []uint
+	if err := tx.Model(&models.SongRef{}).
+		Joins("LEFT JOIN songs ON songs.id = song_refs.song_id").
+		Where("song_refs.favorite_id = ?", w.fav.ID).
+		Order(expr+" "+dir+", song_refs.position, song_refs.id").
+		Pluck("song_refs.id", &ids).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]models.SongRef, len(w.refs))
+	for _, r := range w.refs {
+		byID[r.ID] = r
+	}
+	sorted := make([]models.SongRef, 0, len(w.refs))
+	for _, id := range ids {
+		if r, ok := byID[id]; ok {
+			sorted = append(sorted, r)
+		}
+	}
+	w.refs = sorted
+	return nil
+}
+
+// replaceRefs makes the favorite hold exactly the given songs in that order.
+// Songs already present keep their first ref and added time; duplicates are
+// skipped and every other existing ref is deleted.
+func (w *favoriteWork) replaceRefs(tx *gorm.DB, songRefs []models.SongRef) error {
+	existing := make(map[string]models.SongRef, len(w.refs))
+	for _, r := range w.refs {
+		if _, ok := existing[r.SongID]; !ok {
+			existing[r.SongID] = r
+		}
+	}
+	now := time.Now()
+	seen := make(map[string]bool, len(songRefs))
+	kept := make(map[uint]bool, len(songRefs))
+	refs := make([]models.SongRef, 0, len(songRefs))
+	for _, in := range songRefs {
+		if in.SongID == "" || seen[in.SongID] {
+			continue
+		}
+		seen[in.SongID] = true
+		if r, ok := existing[in.SongID]; ok {
+			kept[r.ID] = true
+			refs = append(refs, r)
+			continue
+		}
+		r := models.SongRef{FavoriteID: w.fav.ID, SongID: in.SongID, Position: len(refs), AddedAt: in.AddedAt}
+		if r.AddedAt.IsZero() {
+			r.AddedAt = now
+		}
+		if err := tx.Create(&r).Error; err != nil {
+			return err
+		}
+		refs = append(refs, r)
+	}
+
+	var removed []uint
+	// 按引用 ID 比较，同一歌曲多余的重复引用也一并删除
+	for _, r := range w.refs {
+		if !kept[r.ID] {
+			removed = append(removed, r.ID)
+		}
+	}
+	if len(removed) > 0 {
+		if err := tx.Delete(&models.SongRef{}, removed).Error; err != nil {
+			return err
+		}
+	}
+
+	w.refs = refs
+	if err := w.sortRefs(tx); err != nil {
+		return err
+	}
+	return w.renumber(tx)
+}
+
+// editFavoriteSongs runs fn on a favorite's refs in a transaction and persists the new order.
+func (s *Service) editFavoriteSongs(favID string, fn func(tx *gorm.DB, w *favoriteWork) error) ([]models.SongRef, error) {
+	var out []models.SongRef
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		w, err := loadFavoriteForEdit(tx, favID)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx, w); err != nil {
+			return err
+		}
+		if err := w.renumber(tx); err != nil {
+			return err
+		}
+		out = w.refs
+		return nil
+	})
+	return out, err
+}
+
+// FavoriteAddSongs inserts songs at index (negative or past the end appends).
+// Songs already in the favorite are skipped. Sorted favorites stay sorted.
+func (s *Service) FavoriteAddSongs(favID string, songIDs []string, index int) ([]models.SongRef, error) {
+	return s.editFavoriteSongs(favID, func(tx *gorm.DB, w *favoriteWork) error {
+		existing := make(map[string]bool, len(w.refs))
+		for _, r := range w.refs {
+			existing[r.SongID] = true
+		}
+		now := time.Now()
+		added := make([]models.SongRef, 0, len(songIDs))
+		for _, id := range songIDs {
+			if id == "" || existing[id] {
+				continue
+			}
+			existing[id] = true
+			ref := models.SongRef{FavoriteID: w.fav.ID, SongID: id, Position: -1, AddedAt: now}
+			if err := tx.Create(&ref).Error; err != nil {
+				return err
+			}
+			added = append(added, ref)
+		}
+		if index < 0 || index > len(w.refs) {
+			index = len(w.refs)
+		}
+		refs := make([]models.SongRef, 0, len(w.refs)+len(added))
+		refs = append(refs, w.refs[:index]...)
+		refs = append(refs, added...)
+		refs = append(refs, w.refs[index:]...)
+		w.refs = refs
+		return w.sortRefs(tx)
+	})
+}
+
+// FavoriteRemoveSongs removes songs from a favorite.
+func (s *Service) FavoriteRemoveSongs(favID string, songIDs []string) ([]models.SongRef, error) {
+	return s.editFavoriteSongs(favID, func(tx *gorm.DB, w *favoriteWork) error {
+		remove := make(map[string]bool, len(songIDs))
+		for _, id := range songIDs {
+			remove[id] = true
+		}
+		kept := w.refs[:0]
+		for _, r := range w.refs {
+			if remove[r.SongID] {
+				if err := tx.Delete(&models.SongRef{}, r.ID).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			kept = append(kept, r)
+		}
+		w.refs = kept
+		return nil
+	})
+}
+
+// FavoriteMoveSong moves the song at index from to index to and switches the favorite to manual order.
+func (s *Service) FavoriteMoveSong(favID string, from, to int) ([]models.SongRef, error) {
+	return s.editFavoriteSongs(favID, func(tx *gorm.DB, w *favoriteWork) error {
+		n := len(w.refs)
+		if from < 0 || from >= n || to < 0 || to >= n {
+			return fmt.Errorf("歌单位置越界: %d -> %d", from, to)
+		}
+		moved := w.refs[from]
+		w.refs = append(w.refs[:from], w.refs[from+1:]...)
+		w.refs = append(w.refs[:to], append([]models.SongRef{moved}, w.refs[to:]...)...)
+		return setFavoriteSortBy(tx, w, favoriteSortManual)
+	})
+}
+
+// FavoriteReorder sets the complete order of a favorite's songs and switches it to manual order.
+// songIDs must contain exactly the songs currently in the favorite.
+func (s *Service) FavoriteReorder(favID string, songIDs []string) ([]models.SongRef, error) {
+	return s.editFavoriteSongs(favID, func(tx *gorm.DB, w *favoriteWork) error {
+		if len(songIDs) != len(w.refs) {
+			return fmt.Errorf("排序列表与歌单歌曲数量不一致")
+		}
+		bySong := make(map[string]models.SongRef, len(w.refs))
+		for _, r := range w.refs {
+			bySong[r.SongID] = r
+		}
+		refs := make([]models.SongRef, 0, len(songIDs))
+		for _, id := range songIDs {
+			r, ok := bySong[id]
+			if !ok {
+				return fmt.Errorf("歌曲不在歌单中或重复: %s", id)
+			}
+			delete(bySong, id)
+			refs = append(refs, r)
+		}
+		w.refs = refs
+		return setFavoriteSortBy(tx, w, favoriteSortManual)
+	})
+}
+
+// SetFavoriteSort persists the sort order of a favorite and reorders its songs.
+// An empty sortBy keeps the current order and switches to manual ordering.
+func (s *Service) SetFavoriteSort(favID, sortBy string) ([]models.SongRef, error) {
+	return s.editFavoriteSongs(favID, func(tx *gorm.DB, w *favoriteWork) error {
+		if err := setFavoriteSortBy(tx, w, sortBy); err != nil {
+			return err
+		}
+		return w.sortRefs(tx)
+	})
+}
+
+func setFavoriteSortBy(tx *gorm.DB, w *favoriteWork, sortBy string) error {
+	if w.fav.SortBy == sortBy {
+		return nil
+	}
+	w.fav.SortBy = sortBy
+	return tx.Model(&w.fav).Update("sort_by", sortBy).Error
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"half-beat-player/internal/models"
+)
+
+func TestSaveFavoriteRemovesDuplicateRefs(t *testing.T) {
+	s := newTestService(t)
+	if err := s.UpsertSongs([]models.Song{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}}); err != nil {
+		t.Fatal(err)
+	}
+	added := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := []any{
+		&models.Favorite{ID: "fav", Title: "F"},
+		&models.SongRef{FavoriteID: "fav", SongID: "a", Position: 0, AddedAt: added},
+		&models.SongRef{FavoriteID: "fav", SongID: "b", Position: 1, AddedAt: added},
+		&models.SongRef{FavoriteID: "fav", SongID: "a", Position: 2, AddedAt: added},
+		&models.SongRef{FavoriteID: "fav", SongID: "a", Position: 3, AddedAt: added},
+	}
+	for _, row := range rows {
+		if err := s.db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := s.SaveFavorite(models.Favorite{ID: "fav", Title: "F", SongIDs: []models.SongRef{{SongID: "b"}, {SongID: "a"}, {SongID: "a"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []models.SongRef
+	if err := s.db.Where("favorite_id = ?", "fav").Order("position").Find(&refs).Error; err != nil {
+		t.Fatal(err)
+	}
+	ids := pluck(refs, func(r models.SongRef) string { return r.SongID })
+	if len(ids) != 2 || ids[0] != "b" || ids[1] != "a" {
+		t.Fatalf("refs = %v, want [b a]", ids)
+	}
+	// 复用每首歌最早的引用，保留添加时间
+	if refs[1].ID != 1 || !refs[1].AddedAt.Equal(added) {
+		t.Fatalf("ref for a = %+v, want the first ref reused", refs[1])
+	}
+}
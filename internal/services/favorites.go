@@ -1,6 +1,8 @@
 package services
 
 import (
+	"fmt"
+
 	"half-beat-player/internal/models"
 
 	"github.com/google/uuid"
@@ -9,11 +11,14 @@ import (
 )
 
 // ListFavorites returns favorites with song ids only (frontend can hydrate).
+// Favorites owned by other accounts are hidden; smart favorites are evaluated and marked read-only.
 func (s *Service) ListFavorites() ([]models.Favorite, error) {
 	var favs []models.Favorite
-	if err := s.db.Preload("SongIDs").Find(&favs).Error; err != nil {
+	uid := s.activeAccountUID()
+	if err := s.db.Preload("SongIDs", orderedSongRefs).Where("owner_uid = 0 OR owner_uid = ?", uid).Find(&favs).Error; err != nil {
 		return nil, err
 	}
+	s.fillSmartFavorites(favs)
 	return favs, nil
 }
 
@@ -21,24 +26,37 @@ func (s *Service) ListFavorites() ([]models.Favorite, error) {
 func (s *Service) SaveFavorite(fav models.Favorite) error {
 	if fav.ID == "" {
 		fav.ID = "FavList-" + uuid.NewString()
+		// 从 B 站收藏夹导入的歌单默认只对当前账号可见
+		if fav.BiliMediaID != 0 && fav.OwnerUID == 0 {
+			fav.OwnerUID = s.activeAccountUID()
+		}
 	}
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Clauses(clauseOnConflictID()).Create(&fav).Error; err != nil {
-			return err
-		}
-		if err := tx.Where("favorite_id = ?", fav.ID).Delete(&models.SongRef{}).Error; err != nil {
-			return err
-		}
-		for i := range fav.SongIDs {
-			fav.SongIDs[i].FavoriteID = fav.ID
-		}
-		if len(fav.SongIDs) == 0 {
-			return nil
-		}
-		return tx.Create(&fav.SongIDs).Error
+		return s.saveFavorite(tx, fav)
 	})
 }
 
+// saveFavorite upserts fav and replaces its song refs within tx.
+func (s *Service) saveFavorite(tx *gorm.DB, fav models.Favorite) error {
+	smart, err := s.isSmartFavorite(tx, fav.ID)
+	if err != nil {
+		return err
+	}
+	if smart {
+		return fmt.Errorf("智能歌单为只读，请修改规则")
+	}
+	fav.SmartRules = ""
+	songRefs := fav.SongIDs
+	if err := tx.Omit("SongIDs").Clauses(clauseOnConflictID()).Create(&fav).Error; err != nil {
+		return err
+	}
+	w, err := loadFavoriteForEdit(tx, fav.ID)
+	if err != nil {
+		return err
+	}
+	return w.replaceRefs(tx, songRefs)
+}
+
 // DeleteFavorite deletes a favorite and its song refs.
 func (s *Service) DeleteFavorite(id string) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
@@ -49,10 +67,30 @@ func (s *Service) DeleteFavorite(id string) error {
 	})
 }
 
+// SetFavoriteShared makes a favorite visible to every account, or only to the current one.
+func (s *Service) SetFavoriteShared(id string, shared bool) error {
+	var owner int64
+	if !shared {
+		owner = s.activeAccountUID()
+		if owner == 0 {
+			return fmt.Errorf("未登录")
+		}
+	}
+	return s.db.Model(&models.Favorite{}).Where("id = ?", id).Update("owner_uid", owner).Error
+}
+
 // clauseOnConflictID is a small helper to update on PK conflict.
+// Ownership and import source are only overwritten by non-zero values: most saves
+// come from the UI, which does not send them (use SetFavoriteShared to share).
+// The sort key is left alone; it is changed through SetFavoriteSort.
 func clauseOnConflictID() clause.Expression {
 	return clause.OnConflict{
-		Columns:   []clause.Column{{Name: "id"}},
-		DoUpdates: clause.Assignments(map[string]interface{}{"title": clause.Expr{SQL: "excluded.title"}, "updated_at": clause.Expr{SQL: "excluded.updated_at"}}),
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"title":         clause.Expr{SQL: "excluded.title"},
+			"owner_uid":     clause.Expr{SQL: "CASE WHEN excluded.owner_uid <> 0 THEN excluded.owner_uid ELSE favorites.owner_uid END"},
+			"bili_media_id": clause.Expr{SQL: "CASE WHEN excluded.bili_media_id <> 0 THEN excluded.bili_media_id ELSE favorites.bili_media_id END"},
+			"updated_at":    clause.Expr{SQL: "excluded.updated_at"},
+		}),
 	}
 }
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"half-beat-player/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ===== Import from other players =====
+
+// azusaSong is one song in an Azusa Player export.
+type azusaSong struct {
+	ID            string  `json:"id"`
+	BVID          string  `json:"bvid"`
+	Name          string  `json:"name"`
+	Singer        string  `json:"singer"`
+	SingerID      any     `json:"singerId"`
+	Cover         string  `json:"cover"`
+	Lyric         string  `json:"lyric"`
+	LyricOffset   float64 `json:"lyricOffset"` // 毫秒
+	Page          int     `json:"page"`
+	SkipStartTime float64 `json:"skipStartTime"`
+	SkipEndTime   float64 `json:"skipEndTime"`
+	StartTime     float64 `json:"startTime"` // 部分版本使用 startTime/endTime
+	EndTime       float64 `json:"endTime"`
+}
+
+// azusaPlaylist is one favorite list in an Azusa Player export.
+type azusaPlaylist struct {
+	Info struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	} `json:"info"`
+	ID       string      `json:"id"`
+	Title    string      `json:"title"`
+	SongList []azusaSong `json:"songList"`
+}
+
+// AzusaImportResult summarises an Azusa Player import.
+type AzusaImportResult struct {
+	Favorites    []models.Favorite `json:"favorites"`
+	SongsCreated int               `json:"songsCreated"`
+	SongsUpdated int               `json:"songsUpdated"`
+}
+
+// parseAzusaExport accepts a playlist array, an object with favLists/playlists,
+// or Azusa Player's storage dump ({"MyFavList": [...], "FavList-xxx": {...}}).
+func parseAzusaExport(content string) ([]azusaPlaylist, error) {
+	data := []byte(strings.TrimPrefix(strings.TrimSpace(content), "\ufeff"))
+	var list []azusaPlaylist
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("解析 Azusa Player 导出文件失败: %w", err)
+	}
+	for _, key := range []string{"favLists", "playlists"} {
+		if raw, ok := root[key]; ok {
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return nil, fmt.Errorf("解析 %s 失败: %w", key, err)
+			}
+			return list, nil
+		}
+	}
+
+	var order []string
+	if raw, ok := root["MyFavList"]; ok {
+		_ = json.Unmarshal(raw, &order)
+	}
+	if len(order) == 0 {
+		for key := range root {
+			order = append(order, key)
+		}
+		sort.Strings(order)
+	}
+	for _, key := range order {
+		raw, ok := root[key]
+		if !ok {
+			continue
+		}
+		var pl azusaPlaylist
+		if err := json.Unmarshal(raw, &pl); err != nil || pl.SongList == nil {
+			continue
+		}
+		if pl.Info.ID == "" && pl.ID == "" {
+			pl.ID = key
+		}
+		list = append(list, pl)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("未找到 Azusa Player 歌单")
+	}
+	return list, nil
+}
+
+// azusaSongModel maps an Azusa song onto a new library song.
+func azusaSongModel(in azusaSong) models.Song {
+	song := models.Song{
+		ID:            uuid.NewString(),
+		BVID:          in.BVID,
+		Name:          strings.TrimSpace(in.Name),
+		Singer:        in.Singer,
+		Cover:         normalizeBiliPic(in.Cover),
+		Lyric:         in.Lyric,
+		LyricOffset:   int(in.LyricOffset),
+		SkipStartTime: in.SkipStartTime,
+		SkipEndTime:   in.SkipEndTime,
+		PageNumber:    in.Page,
+	}
+	if in.SingerID != nil {
+		song.SingerID = strings.TrimSpace(fmt.Sprint(in.SingerID))
+	}
+	if song.SkipStartTime == 0 {
+		song.SkipStartTime = in.StartTime
+	}
+	if song.SkipEndTime == 0 {
+		song.SkipEndTime = in.EndTime
+	}
+	if song.Name == "" {
+		song.Name = in.BVID
+	}
+	return song
+}
+
+// ImportAzusaPlayer imports favorites from an Azusa Player JSON export. Songs are
+// matched by BVID+page; existing songs only receive skip times and lyric offsets
+// they do not have yet.
+func (s *Service) ImportAzusaPlayer(content string) (AzusaImportResult, error) {
+	result := AzusaImportResult{Favorites: []models.Favorite{}}
+	playlists, err := parseAzusaExport(content)
+	if err != nil {
+		return result, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for _, pl := range playlists {
+			id := pl.Info.ID
+			if id == "" {
+				id = pl.ID
+			}
+			title := pl.Info.Title
+			if title == "" {
+				title = pl.Title
+			}
+			if title == "" {
+				title = "Azusa Player 歌单"
+			}
+
+			var exists int64
+			if err := tx.Model(&models.Favorite{}).Where("id = ?", id).Count(&exists).Error; err != nil {
+				return err
+			}
+			if id == "" || exists > 0 {
+				id = "FavList-" + uuid.NewString()
+			}
+			fav := models.Favorite{ID: id, Title: title}
+			seen := make(map[string]bool)
+			for _, in := range pl.SongList {
+				if in.BVID == "" {
+					continue
+				}
+				imported := azusaSongModel(in)
+				song, ok, err := findSongByBVIDPage(tx, in.BVID, in.Page)
+				if err != nil {
+					return err
+				}
+				if ok {
+					updates := map[string]any{}
+					if song.SkipStartTime == 0 && imported.SkipStartTime != 0 {
+						updates["skip_start_time"] = imported.SkipStartTime
+					}
+					if song.SkipEndTime == 0 && imported.SkipEndTime != 0 {
+						updates["skip_end_time"] = imported.SkipEndTime
+					}
+					if song.LyricOffset == 0 && imported.LyricOffset != 0 {
+						updates["lyric_offset"] = imported.LyricOffset
+					}
+					if song.Lyric == "" && imported.Lyric != "" {
+						updates["lyric"] = imported.Lyric
+					}
+					if len(updates) > 0 {
+						if err := tx.Model(&song).Updates(updates).Error; err != nil {
+							return err
+						}
+						result.SongsUpdated++
+					}
+				} else {
+					song = imported
+					if err := tx.Create(&song).Error; err != nil {
+						return err
+					}
+					result.SongsCreated++
+				}
+				if seen[song.ID] {
+					continue
+				}
+				seen[song.ID] = true
+				fav.SongIDs = append(fav.SongIDs, models.SongRef{SongID: song.ID, Position: len(fav.SongIDs), AddedAt: now})
+			}
+			if err := tx.Create(&fav).Error; err != nil {
+				return err
+			}
+			result.Favorites = append(result.Favorites, fav)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// ===== Plain "title - artist" lists =====
+
+// textMatchReviewThreshold 低于该置信度的匹配需要用户确认
+const textMatchReviewThreshold = 0.6
+
+// TextImportCandidate is the search result for one line of a text playlist.
+type TextImportCandidate struct {
+	Line         string        `json:"line"`
+	Title        string        `json:"title"`
+	Artist       string        `json:"artist"`
+	Match        *models.Song  `json:"match"` // 最佳匹配，可能为空
+	Alternatives []models.Song `json:"alternatives"`
+	Confidence   float64       `json:"confidence"` // 0..1
+	NeedsReview  bool          `json:"needsReview"`
+}
+
+// parseTextLine splits "title - artist" (also en dash, em dash and tab separators).
+func parseTextLine(line string) (title, artist string) {
+	for _, sep := range []string{" - ", " – ", " — ", "\t"} {
+		if t, a, ok := strings.Cut(line, sep); ok {
+			return strings.TrimSpace(t), strings.TrimSpace(a)
+		}
+	}
+	return strings.TrimSpace(line), ""
+}
+
+// normalizeForMatch keeps only lower-cased letters and digits.
+func normalizeForMatch(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// diceSimilarity is the Sørensen–Dice coefficient over rune bigrams.
+func diceSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 2 || len(rb) < 2 {
+		if a == b && a != "" {
+			return 1
+		}
+		return 0
+	}
+	grams := make(map[string]int, len(ra))
+	for i := 0; i+1 < len(ra); i++ {
+		grams[string(ra[i:i+2])]++
+	}
+	common := 0
+	for i := 0; i+1 < len(rb); i++ {
+		g := string(rb[i : i+2])
+		if grams[g] > 0 {
+			grams[g]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(ra)+len(rb)-2)
+}
+
+// textMatchScore rates how well a search result matches a title and artist.
+func textMatchScore(title, artist string, song models.Song) float64 {
+	nt, nn := normalizeForMatch(title), normalizeForMatch(song.Name)
+	titleScore := diceSimilarity(nt, nn)
+	if nt != "" && strings.Contains(nn, nt) {
+		titleScore = 1
+	}
+	if artist == "" {
+		return titleScore
+	}
+	na, ns := normalizeForMatch(artist), normalizeForMatch(song.Singer)
+	artistScore := diceSimilarity(na, ns)
+	if na != "" && (strings.Contains(ns, na) || strings.Contains(nn, na)) {
+		artistScore = 1
+	}
+	return 0.7*titleScore + 0.3*artistScore
+}
+
+// MatchTextPlaylist searches a song for every "title - artist" line. Local songs are
+// preferred; otherwise Bilibili search results are ranked and low-confidence matches
+// are flagged for review before ImportTextPlaylist.
+func (s *Service) MatchTextPlaylist(content string) ([]TextImportCandidate, error) {
+	out := []TextImportCandidate{}
+	for _, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		c := TextImportCandidate{Line: line, Alternatives: []models.Song{}}
+		c.Title, c.Artist = parseTextLine(line)
+
+		var results []models.Song
+		local, err := s.SearchLocalSongs(c.Title)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, local...)
+		if remote, err := s.SearchBiliVideos(strings.TrimSpace(c.Title+" "+c.Artist), 1, 5, ""); err != nil {
+			fmt.Printf("[Import] 搜索 %q 失败: %v\n", line, err)
+		} else {
+			results = append(results, remote...)
+		}
+
+		scores := make([]float64, len(results))
+		for i, song := range results {
+			scores[i] = textMatchScore(c.Title, c.Artist, song)
+			if song.ID != "" {
+				scores[i] += 0.05 // 同分时优先库内歌曲
+			}
+		}
+		idx := make([]int, len(results))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+		for rank, i := range idx {
+			if rank == 0 {
+				best := results[i]
+				c.Match = &best
+				c.Confidence = scores[i]
+				if c.Confidence > 1 {
+					c.Confidence = 1
+				}
+				continue
+			}
+			c.Alternatives = append(c.Alternatives, results[i])
+		}
+		c.NeedsReview = c.Match == nil || c.Confidence < textMatchReviewThreshold
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// ImportTextPlaylist creates a favorite from the reviewed matches. Songs with an ID
+// are used as-is; search results are matched by BVID+page or added to the library.
+func (s *Service) ImportTextPlaylist(title string, picks []models.Song) (models.Favorite, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = "导入的歌单"
+	}
+	fav := models.Favorite{ID: "FavList-" + uuid.NewString(), Title: title}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		seen := make(map[string]bool)
+		for _, pick := range picks {
+			song := pick
+			if pick.ID != "" {
+				var count int64
+				if err := tx.Model(&models.Song{}).Where("id = ?", pick.ID).Count(&count).Error; err != nil {
+					return err
+				}
+				if count == 0 {
+					return fmt.Errorf("歌曲不存在: %s", pick.ID)
+				}
+			} else {
+				if pick.BVID == "" {
+					continue
+				}
+				existing, ok, err := findSongByBVIDPage(tx, pick.BVID, pick.PageNumber)
+				if err != nil {
+					return err
+				}
+				if ok {
+					song = existing
+				} else {
+					song.ID = uuid.NewString()
+					if song.Name == "" {
+						song.Name = pick.BVID
+					}
+					if err := tx.Create(&song).Error; err != nil {
+						return err
+					}
+				}
+			}
+			if seen[song.ID] {
+				continue
+			}
+			seen[song.ID] = true
+			fav.SongIDs = append(fav.SongIDs, models.SongRef{SongID: song.ID, Position: len(fav.SongIDs), AddedAt: now})
+		}
+		return tx.Create(&fav).Error
+	})
+	return fav, err
+}
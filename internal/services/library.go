@@ -20,7 +20,7 @@ func (s *Service) ExportData() (ExportData, error) {
 	if err := s.db.Find(&out.Songs).Error; err != nil {
 		return out, err
 	}
-	if err := s.db.Preload("SongIDs").Find(&out.Favorites).Error; err != nil {
+	if err := s.db.Preload("SongIDs", orderedSongRefs).Find(&out.Favorites).Error; err != nil {
 		return out, err
 	}
 	out.Settings, _ = s.GetPlayerSetting()
@@ -58,6 +58,7 @@ func (s *Service) ImportData(in ExportData) error {
 		for i := range in.Favorites {
 			for j := range in.Favorites[i].SongIDs {
 				in.Favorites[i].SongIDs[j].FavoriteID = in.Favorites[i].ID
+				in.Favorites[i].SongIDs[j].Position = j
 			}
 			if err := tx.Create(&in.Favorites[i].SongIDs).Error; err != nil {
 				return err
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ===== Library health checks =====
+
+const (
+	healthSampleLimit = 20
+
+	healthSeverityError   = "error"
+	healthSeverityWarning = "warning"
+)
+
+// errRepairDryRun rolls back the repair transaction after a dry run.
+var errRepairDryRun = errors.New("repair dry run")
+
+// LibraryIssue is the result of one consistency check.
+type LibraryIssue struct {
+	Check      string   `json:"check"`
+	Severity   string   `json:"severity"` // error | warning
+	Message    string   `json:"message"`
+	Count      int      `json:"count"`
+	Samples    []string `json:"samples"` // 最多 20 个相关 ID
+	Repairable bool     `json:"repairable"`
+}
+
+// LibraryHealthReport is returned by DiagnoseLibrary.
+type LibraryHealthReport struct {
+	IntegrityOK bool           `json:"integrityOk"`
+	Integrity   []string       `json:"integrity"` // PRAGMA integrity_check 输出
+	Issues      []LibraryIssue `json:"issues"`    // 仅包含发现问题的检查
+	CheckedAt   time.Time      `json:"checkedAt"`
+}
+
+// LibraryRepairResult is returned by RepairLibrary.
+type LibraryRepairResult struct {
+	DryRun bool                `json:"dryRun"`
+	Fixed  map[string]int      `json:"fixed"` // 每项检查修复的行数
+	Before LibraryHealthReport `json:"before"`
+	After  LibraryHealthReport `json:"after"` // 演练模式下为假定修复后的结果
+}
+
+// libraryCheck finds problem rows with sampleSQL (first column is the sample ID,
+// one row per problem) and fixes them with repair when set.
+type libraryCheck struct {
+	name      string
+	severity  string
+	message   string
+	sampleSQL string
+	repair    func(tx *gorm.DB) (int64, error)
+}
+
+var libraryChecks = []libraryCheck{
+	{
+		name:      "orphan_song_refs",
+		severity:  healthSeverityError,
+		message:   "歌单引用了已删除的歌曲",
+		sampleSQL: `SELECT CAST(id AS TEXT) FROM song_refs WHERE song_id NOT IN (SELECT id FROM songs)`,
+		repair: func(tx *gorm.DB) (int64, error) {
+			res := tx.Exec(`DELETE FROM song_refs WHERE song_id NOT IN (SELECT id FROM songs)`)
+			return res.RowsAffected, res.Error
+		},
+	},
+	{
+		name:      "detached_song_refs",
+		severity:  healthSeverityError,
+		message:   "歌曲引用指向不存在的歌单",
+		sampleSQL: `SELECT CAST(id AS TEXT) FROM song_refs WHERE favorite_id NOT IN (SELECT id FROM favorites)`,
+		repair: func(tx *gorm.DB) (int64, error) {
+			res := tx.Exec(`DELETE FROM song_refs WHERE favorite_id NOT IN (SELECT id FROM favorites)`)
+			return res.RowsAffected, res.Error
+		},
+	},
+	{
+		name:     "duplicate_song_refs",
+		severity: healthSeverityWarning,
+		message:  "同一歌单中重复收录了同一歌曲实例",
+		sampleSQL: `SELECT CAST(r.id AS TEXT) FROM song_refs r WHERE EXISTS (
+			SELECT 1 FROM song_refs o WHERE o.favorite_id = r.favorite_id AND o.song_id = r.song_id
+			AND (o.position < r.position OR (o.position = r.position AND o.id < r.id)))`,
+		repair: func(tx *gorm.DB) (int64, error) {
+			res := tx.Exec(`DELETE FROM song_refs WHERE EXISTS (
+				SELECT 1 FROM song_refs o WHERE o.favorite_id = song_refs.favorite_id AND o.song_id = song_refs.song_id
+				AND (o.position < song_refs.position OR (o.position = song_refs.position AND o.id < song_refs.id)))`)
+			return res.RowsAffected, res.Error
+		},
+	},
+	{
+		name:      "orphan_stream_sources",
+		severity:  healthSeverityWarning,
+		message:   "流源没有任何歌曲使用",
+		sampleSQL: `SELECT id FROM stream_sources WHERE id NOT IN (SELECT source_id FROM songs WHERE source_id IS NOT NULL)`,
+		repair: func(tx *gorm.DB) (int64, error) {
+			res := tx.Exec(`DELETE FROM stream_sources WHERE id NOT IN (SELECT source_id FROM songs WHERE source_id IS NOT NULL)`)
+			return res.RowsAffected, res.Error
+		},
+	},
+	{
+		name:      "dangling_source_ids",
+		severity:  healthSeverityError,
+		message:   "歌曲指向不存在的流源",
+		sampleSQL: `SELECT id FROM songs WHERE source_id <> '' AND source_id NOT IN (SELECT id FROM stream_sources)`,
+		repair: func(tx *gorm.DB) (int64, error) {
+			// 清空后播放时会重新解析流地址
+			res := tx.Exec(`UPDATE songs SET source_id = '' WHERE source_id <> '' AND source_id NOT IN (SELECT id FROM stream_sources)`)
+			return res.RowsAffected, res.Error
+		},
+	},
+	{
+		name:      "orphan_resume_positions",
+		severity:  healthSeverityWarning,
+		message:   "续播进度属于已删除的歌曲",
+		sampleSQL: `SELECT song_id FROM resume_positions WHERE song_id NOT IN (SELECT id FROM songs)`,
+		repair: func(tx *gorm.DB) (int64, error) {
+			res := tx.Exec(`DELETE FROM resume_positions WHERE song_id NOT IN (SELECT id FROM songs)`)
+			return res.RowsAffected, res.Error
+		},
+	},
+	{
+		name:      "orphan_lyric_mappings",
+		severity:  healthSeverityWarning,
+		message:   "歌词属于已删除的歌曲",
+		sampleSQL: `SELECT id FROM lyric_mappings WHERE id NOT IN (SELECT id FROM songs)`,
+		repair: func(tx *gorm.DB) (int64, error) {
+			res := tx.Exec(`DELETE FROM lyric_mappings WHERE id NOT IN (SELECT id FROM songs)`)
+			return res.RowsAffected, res.Error
+		},
+	},
+	{
+		name:      "orphan_play_events",
+		severity:  healthSeverityWarning,
+		message:   "播放记录属于已删除的歌曲",
+		sampleSQL: `SELECT CAST(id AS TEXT) FROM play_events WHERE song_id NOT IN (SELECT id FROM songs)`,
+		repair: func(tx *gorm.DB) (int64, error) {
+			res := tx.Exec(`DELETE FROM play_events WHERE song_id NOT IN (SELECT id FROM songs)`)
+			return res.RowsAffected, res.Error
+		},
+	},
+	{
+		name:      "orphan_queue_entries",
+		severity:  healthSeverityError,
+		message:   "播放队列包含已删除的歌曲",
+		sampleSQL: `SELECT CAST(id AS TEXT) FROM queue_entries WHERE song_id NOT IN (SELECT id FROM songs)`,
+		repair:    repairOrphanQueueEntries,
+	},
+	{
+		name:      "empty_bvid",
+		severity:  healthSeverityWarning,
+		message:   "歌曲缺少 BVID，无法在线播放",
+		sampleSQL: `SELECT id FROM songs WHERE bvid IS NULL OR bvid = ''`,
+	},
+	{
+		// 每次添加都会生成新的歌曲实例，只有同一歌单内出现多个实例才算问题
+		name:     "duplicate_pages_in_favorite",
+		severity: healthSeverityWarning,
+		message:  "同一歌单中收录了同一 BVID 分P 的多个歌曲实例，可合并重复歌曲",
+		sampleSQL: `SELECT CAST(r.id AS TEXT) FROM song_refs r JOIN songs s ON s.id = r.song_id
+			WHERE s.bvid <> '' AND EXISTS (
+				SELECT 1 FROM song_refs o JOIN songs os ON os.id = o.song_id
+				WHERE o.favorite_id = r.favorite_id AND o.song_id <> r.song_id
+				AND os.bvid = s.bvid AND os.page_number = s.page_number)`,
+	},
+}
+
+// repairOrphanQueueEntries drops queue entries of deleted songs, keeping each
+// queue's current entry in place.
+func repairOrphanQueueEntries(tx *gorm.DB) (int64, error) {
+	var playlistIDs []uint
+	if err := tx.Model(&models.QueueEntry{}).Distinct("playlist_id").
+		Where("song_id NOT IN (SELECT id FROM songs) AND playlist_id IN (SELECT id FROM playlists)").
+		Pluck("playlist_id", &playlistIDs).Error; err != nil {
+		return 0, err
+	}
+	var removed int64
+	for _, id := range playlistIDs {
+		w, err := loadQueue(tx, id)
+		if err != nil {
+			return removed, err
+		}
+		var songIDs []string
+		if err := tx.Model(&models.Song{}).Where("id IN (SELECT song_id FROM queue_entries WHERE playlist_id = ?)", id).
+			Pluck("id", &songIDs).Error; err != nil {
+			return removed, err
+		}
+		exists := make(map[string]bool, len(songIDs))
+		for _, sid := range songIDs {
+			exists[sid] = true
+		}
+		kept := w.entries[:0]
+		for i, e := range w.entries {
+			if exists[e.SongID] {
+				kept = append(kept, e)
+				continue
+			}
+			removed++
+			if i < w.playlist.CurrentIndex {
+				w.playlist.CurrentIndex--
+			}
+		}
+		w.entries = kept
+		if err := saveQueue(tx, w); err != nil {
+			return removed, err
+		}
+	}
+	// 所属队列也已不存在的条目直接删除
+	res := tx.Exec(`DELETE FROM queue_entries WHERE song_id NOT IN (SELECT id FROM songs)`)
+	return removed + res.RowsAffected, res.Error
+}
+
+// DiagnoseLibrary runs SQLite's integrity check and the library consistency checks.
+func (s *Service) DiagnoseLibrary() (LibraryHealthReport, error) {
+	return diagnoseLibrary(s.db)
+}
+
+func diagnoseLibrary(tx *gorm.DB) (LibraryHealthReport, error) {
+	report := LibraryHealthReport{Issues: []LibraryIssue{}, CheckedAt: time.Now()}
+	if err := tx.Raw("PRAGMA integrity_check").Scan(&report.Integrity).Error; err != nil {
+		return report, fmt.Errorf("integrity check: %w", err)
+	}
+	report.IntegrityOK = len(report.Integrity) == 1 && report.Integrity[0] == "ok"
+
+	for _, c := range libraryChecks {
+		var ids []string
+		if err := tx.Raw(c.sampleSQL).Scan(&ids).Error; err != nil {
+			return report, fmt.Errorf("check %s: %w", c.name, err)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		issue := LibraryIssue{
+			Check:      c.name,
+			Severity:   c.severity,
+			Message:    c.message,
+			Count:      len(ids),
+			Samples:    ids,
+			Repairable: c.repair != nil,
+		}
+		if len(issue.Samples) > healthSampleLimit {
+			issue.Samples = issue.Samples[:healthSampleLimit]
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+	return report, nil
+}
+
+// RepairLibrary fixes the repairable issues found by DiagnoseLibrary in one
+// transaction. Integrity errors are not repaired here; restore a backup instead.
+// With dryRun the changes are rolled back and only reported.
+func (s *Service) RepairLibrary(dryRun bool) (LibraryRepairResult, error) {
+	result := LibraryRepairResult{DryRun: dryRun, Fixed: map[string]int{}}
+	before, err := diagnoseLibrary(s.db)
+	if err != nil {
+		return result, err
+	}
+	result.Before = before
+	if !before.IntegrityOK {
+		return result, fmt.Errorf("数据库完整性检查未通过，请从备份恢复")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range libraryChecks {
+			if c.repair == nil {
+				continue
+			}
+			n, err := c.repair(tx)
+			if err != nil {
+				return fmt.Errorf("repair %s: %w", c.name, err)
+			}
+			if n > 0 {
+				result.Fixed[c.name] = int(n)
+			}
+		}
+		after, err := diagnoseLibrary(tx)
+		if err != nil {
+			return err
+		}
+		result.After = after
+		if dryRun {
+			return errRepairDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRepairDryRun) {
+		return result, err
+	}
+	if !dryRun && result.Fixed["orphan_queue_entries"] > 0 {
+		s.emitQueueChanged()
+	}
+	return result, nil
+}
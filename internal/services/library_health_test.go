@@ -0,0 +1,148 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"half-beat-player/internal/models"
+)
+
+// seedBrokenLibrary writes one example of every repairable problem.
+func seedBrokenLibrary(t *testing.T, s *Service) {
+	t.Helper()
+	now := time.Now()
+	rows := []any{
+		&models.StreamSource{ID: "src-used"},
+		&models.StreamSource{ID: "src-unused"},
+		&models.Song{ID: "a", BVID: "BV1", SourceID: "src-used"},
+		&models.Song{ID: "b", BVID: "BV2", SourceID: "src-missing"},
+		&models.Favorite{ID: "fav", Title: "F"},
+		&models.SongRef{FavoriteID: "fav", SongID: "a", Position: 0, AddedAt: now},
+		&models.SongRef{FavoriteID: "fav", SongID: "gone", Position: 1, AddedAt: now},
+		&models.SongRef{FavoriteID: "fav", SongID: "a", Position: 2, AddedAt: now},
+		&models.SongRef{FavoriteID: "no-such-fav", SongID: "b", Position: 0, AddedAt: now},
+		&models.ResumePosition{SongID: "gone", PositionSeconds: 10},
+		&models.LyricMapping{ID: "gone", Lyric: "x"},
+		&models.PlayEvent{SongID: "gone", StartedAt: now},
+		&models.Playlist{ID: 1, CurrentIndex: 2},
+		&models.QueueEntry{PlaylistID: 1, Position: 0, SongID: "a"},
+		&models.QueueEntry{PlaylistID: 1, Position: 1, SongID: "gone"},
+		&models.QueueEntry{PlaylistID: 1, Position: 2, SongID: "b"},
+		&models.QueueEntry{PlaylistID: 99, Position: 0, SongID: "gone"},
+	}
+	for _, row := range rows {
+		if err := s.db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+var wantLibraryFixes = map[string]int{
+	"orphan_song_refs":        1,
+	"detached_song_refs":      1,
+	"duplicate_song_refs":     1,
+	"orphan_stream_sources":   1,
+	"dangling_source_ids":     1,
+	"orphan_resume_positions": 1,
+	"orphan_lyric_mappings":   1,
+	"orphan_play_events":      1,
+	"orphan_queue_entries":    2,
+}
+
+func assertFixes(t *testing.T, got map[string]int) {
+	t.Helper()
+	if len(got) != len(wantLibraryFixes) {
+		t.Fatalf("fixed = %v, want %v", got, wantLibraryFixes)
+	}
+	for check, n := range wantLibraryFixes {
+		if got[check] != n {
+			t.Fatalf("fixed[%s] = %d, want %d (all: %v)", check, got[check], n, got)
+		}
+	}
+}
+
+func TestDiagnoseLibrary(t *testing.T) {
+	s := newTestService(t)
+	seedBrokenLibrary(t, s)
+
+	report, err := s.DiagnoseLibrary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.IntegrityOK {
+		t.Fatalf("integrity = %v", report.Integrity)
+	}
+	found := map[string]LibraryIssue{}
+	for _, issue := range report.Issues {
+		found[issue.Check] = issue
+	}
+	for check, n := range wantLibraryFixes {
+		if found[check].Count != n || !found[check].Repairable {
+			t.Errorf("%s = %+v, want %d repairable", check, found[check], n)
+		}
+	}
+	if found["orphan_lyric_mappings"].Samples[0] != "gone" {
+		t.Errorf("samples = %v", found["orphan_lyric_mappings"].Samples)
+	}
+}
+
+func TestRepairLibraryDryRunChangesNothing(t *testing.T) {
+	s := newTestService(t)
+	seedBrokenLibrary(t, s)
+
+	result, err := s.RepairLibrary(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFixes(t, result.Fixed)
+	if len(result.After.Issues) != 0 {
+		t.Fatalf("issues after dry run = %+v", result.After.Issues)
+	}
+	report, err := s.DiagnoseLibrary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != len(result.Before.Issues) {
+		t.Fatalf("dry run changed the library: %+v", report.Issues)
+	}
+}
+
+func TestRepairLibrary(t *testing.T) {
+	s := newTestService(t)
+	seedBrokenLibrary(t, s)
+
+	result, err := s.RepairLibrary(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFixes(t, result.Fixed)
+	report, err := s.DiagnoseLibrary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 0 {
+		t.Fatalf("issues after repair = %+v", report.Issues)
+	}
+
+	// 重复引用保留位置靠前的那条
+	var refs []models.SongRef
+	s.db.Where("favorite_id = ?", "fav").Order("position").Find(&refs)
+	if len(refs) != 1 || refs[0].Position != 0 {
+		t.Fatalf("refs = %+v", refs)
+	}
+	var b models.Song
+	s.db.First(&b, "id = ?", "b")
+	if b.SourceID != "" {
+		t.Fatalf("dangling source id kept: %q", b.SourceID)
+	}
+
+	// 当前歌曲前的条目被删除，当前索引随之前移
+	w, err := loadQueue(s.db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := pluck(w.entries, func(e models.QueueEntry) string { return e.SongID })
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" || w.playlist.CurrentIndex != 1 {
+		t.Fatalf("queue = %v, current %d", ids, w.playlist.CurrentIndex)
+	}
+}
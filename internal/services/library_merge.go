@@ -0,0 +1,312 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ===== Merge-mode import =====
+
+const (
+	importActionAdd    = "add"
+	importActionUpdate = "update"
+	importActionSkip   = "skip"
+)
+
+// errImportDryRun rolls back the merge transaction after a dry run.
+var errImportDryRun = errors.New("import dry run")
+
+// ImportReportItem describes what the merge does with one entity.
+type ImportReportItem struct {
+	Kind   string `json:"kind"` // song | favorite | lyric
+	ID     string `json:"id"`   // 导入数据中的 ID
+	Name   string `json:"name"`
+	Action string `json:"action"` // add | update | skip
+	Reason string `json:"reason,omitempty"`
+}
+
+// ImportReportCounts counts the actions for one kind of entity.
+type ImportReportCounts struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+// ImportReport summarises a merge import.
+type ImportReport struct {
+	DryRun    bool               `json:"dryRun"`
+	Songs     ImportReportCounts `json:"songs"`
+	Favorites ImportReportCounts `json:"favorites"`
+	Lyrics    ImportReportCounts `json:"lyrics"`
+	Items     []ImportReportItem `json:"items"`
+}
+
+func (r *ImportReport) record(counts *ImportReportCounts, item ImportReportItem) {
+	switch item.Action {
+	case importActionAdd:
+		counts.Added++
+	case importActionUpdate:
+		counts.Updated++
+	default:
+		counts.Skipped++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// ImportDataMerge merges an export into the library instead of replacing it.
+// Songs are matched by ID, then BVID+page, and updated only when the incoming copy
+// is newer. Favorites are matched by ID, then title, and gain the songs they lack.
+// Lyric mappings keep the newer copy. Settings are left untouched. With dryRun the
+// report is computed and nothing is written.
+func (s *Service) ImportDataMerge(in ExportData, dryRun bool) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun, Items: []ImportReportItem{}}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		songIDs, err := mergeImportSongs(tx, in.Songs, &report)
+		if err != nil {
+			return err
+		}
+		if err := mergeImportFavorites(tx, in.Favorites, songIDs, &report); err != nil {
+			return err
+		}
+		if err := mergeImportLyrics(tx, in.Lyrics, songIDs, &report); err != nil {
+			return err
+		}
+		if dryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportDryRun) {
+		return report, err
+	}
+	return report, nil
+}
+
+// mergeImportSongs upserts songs and returns a map from incoming ID to library ID.
+func mergeImportSongs(tx *gorm.DB, songs []models.Song, report *ImportReport) (map[string]string, error) {
+	ids := make(map[string]string, len(songs))
+	for _, in := range songs {
+		item := ImportReportItem{Kind: "song", ID: in.ID, Name: in.Name}
+
+		var existing models.Song
+		found := false
+		if in.ID != "" {
+			err := tx.First(&existing, "id = ?", in.ID).Error
+			if err == nil {
+				found = true
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+		}
+		if !found && in.BVID != "" {
+			var err error
+			existing, found, err = findSongByBVIDPage(tx, in.BVID, in.PageNumber)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case !found:
+			if in.ID == "" {
+				in.ID = uuid.NewString()
+			}
+			if in.Name == "" {
+				item.Action, item.Reason = importActionSkip, "缺少歌名"
+				report.record(&report.Songs, item)
+				continue
+			}
+			// 流地址缓存不随导入迁移
+			in.SourceID, in.StreamURL, in.StreamURLExpiresAt = "", "", time.Time{}
+			if err := tx.Create(&in).Error; err != nil {
+				return nil, err
+			}
+			item.Action = importActionAdd
+		case in.UpdatedAt.After(existing.UpdatedAt):
+			in.ID = existing.ID
+			in.SourceID, in.StreamURL, in.StreamURLExpiresAt = existing.SourceID, existing.StreamURL, existing.StreamURLExpiresAt
+			in.CreatedAt = existing.CreatedAt
+			// Save 会刷新 updated_at，保留导入数据的时间以便下次比较
+			updatedAt := in.UpdatedAt
+			if err := tx.Save(&in).Error; err != nil {
+				return nil, err
+			}
+			if err := tx.Model(&in).UpdateColumn("updated_at", updatedAt).Error; err != nil {
+				return nil, err
+			}
+			item.Action = importActionUpdate
+		default:
+			item.Action, item.Reason = importActionSkip, "本地版本较新"
+		}
+		if found {
+			ids[item.ID] = existing.ID
+		} else {
+			ids[item.ID] = in.ID
+		}
+		report.record(&report.Songs, item)
+	}
+	return ids, nil
+}
+
+// mergeImportFavorites adds new favorites and appends missing songs to matching ones.
+func mergeImportFavorites(tx *gorm.DB, favs []models.Favorite, songIDs map[string]string, report *ImportReport) error {
+	now := time.Now()
+	for _, in := range favs {
+		item := ImportReportItem{Kind: "favorite", ID: in.ID, Name: in.Title}
+
+		var existing models.Favorite
+		found := false
+		if in.ID != "" {
+			err := tx.First(&existing, "id = ?", in.ID).Error
+			if err == nil {
+				found = true
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		if !found && in.Title != "" {
+			err := tx.Where("title = ?", in.Title).Order("created_at").First(&existing).Error
+			if err == nil {
+				found = true
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		// 导入数据中的歌曲 ID 映射为本地 ID
+		var incoming []string
+		for _, ref := range in.SongIDs {
+			id, ok, err := resolveImportSongID(tx, songIDs, ref.SongID)
+			if err != nil {
+				return err
+			}
+			if ok {
+				incoming = append(incoming, id)
+			}
+		}
+
+		if !found {
+			fav := models.Favorite{ID: in.ID, Title: in.Title, SmartRules: in.SmartRules, SortBy: in.SortBy}
+			if fav.ID == "" {
+				fav.ID = "FavList-" + uuid.NewString()
+			}
+			seen := make(map[string]bool, len(incoming))
+			for _, id := range incoming {
+				if seen[id] || fav.SmartRules != "" {
+					continue
+				}
+				seen[id] = true
+				fav.SongIDs = append(fav.SongIDs, models.SongRef{SongID: id, Position: len(fav.SongIDs), AddedAt: now})
+			}
+			if err := tx.Create(&fav).Error; err != nil {
+				return err
+			}
+			item.Action = importActionAdd
+			report.record(&report.Favorites, item)
+			continue
+		}
+
+		if existing.SmartRules != "" {
+			item.Action, item.Reason = importActionSkip, "智能歌单"
+			report.record(&report.Favorites, item)
+			continue
+		}
+		var have []models.SongRef
+		if err := orderedSongRefs(tx.Where("favorite_id = ?", existing.ID)).Find(&have).Error; err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(have))
+		for _, r := range have {
+			seen[r.SongID] = true
+		}
+		added := 0
+		for _, id := range incoming {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			ref := models.SongRef{FavoriteID: existing.ID, SongID: id, Position: len(have) + added, AddedAt: now}
+			if err := tx.Create(&ref).Error; err != nil {
+				return err
+			}
+			added++
+		}
+		if added == 0 {
+			item.Action, item.Reason = importActionSkip, "没有新歌曲"
+		} else {
+			item.Action = importActionUpdate
+			if err := tx.Model(&existing).Update("updated_at", now).Error; err != nil {
+				return err
+			}
+		}
+		report.record(&report.Favorites, item)
+	}
+	return nil
+}
+
+// resolveImportSongID maps an incoming song ID to the library song it was merged
+// into, or keeps it when the library already has that song. ok is false when the
+// song is in neither.
+func resolveImportSongID(tx *gorm.DB, songIDs map[string]string, id string) (string, bool, error) {
+	if local, ok := songIDs[id]; ok {
+		return local, true, nil
+	}
+	var count int64
+	if err := tx.Model(&models.Song{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return "", false, err
+	}
+	return id, count > 0, nil
+}
+
+// mergeImportLyrics keeps whichever lyric mapping was updated last. Mappings are
+// keyed by song ID, so they follow songs that were matched to a local song; those
+// of songs missing from the library are skipped.
+func mergeImportLyrics(tx *gorm.DB, lyrics []models.LyricMapping, songIDs map[string]string, report *ImportReport) error {
+	for _, in := range lyrics {
+		item := ImportReportItem{Kind: "lyric", ID: in.ID, Name: in.ID}
+		if in.ID == "" {
+			item.Action, item.Reason = importActionSkip, "缺少 ID"
+			report.record(&report.Lyrics, item)
+			continue
+		}
+		id, ok, err := resolveImportSongID(tx, songIDs, in.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			item.Action, item.Reason = importActionSkip, "歌曲不在曲库中"
+			report.record(&report.Lyrics, item)
+			continue
+		}
+		in.ID = id
+		var existing models.LyricMapping
+		err = tx.First(&existing, "id = ?", in.ID).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(&in).Error; err != nil {
+				return err
+			}
+			item.Action = importActionAdd
+		case err != nil:
+			return err
+		case in.UpdatedAt.After(existing.UpdatedAt):
+			updatedAt := in.UpdatedAt
+			if err := tx.Save(&in).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&in).UpdateColumn("updated_at", updatedAt).Error; err != nil {
+				return err
+			}
+			item.Action = importActionUpdate
+		default:
+			item.Action, item.Reason = importActionSkip, "本地版本较新"
+		}
+		report.record(&report.Lyrics, item)
+	}
+	return nil
+}
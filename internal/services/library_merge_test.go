@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"half-beat-player/internal/models"
+)
+
+func TestImportDataMergeLyricsFollowSongs(t *testing.T) {
+	s := newTestService(t)
+	if err := s.UpsertSongs([]models.Song{
+		{ID: "local", Name: "Local", BVID: "BV1", PageNumber: 1},
+		{ID: "kept", Name: "Kept"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	in := ExportData{
+		Songs: []models.Song{
+			{ID: "remote", Name: "Remote", BVID: "BV1", PageNumber: 1}, // 按 BVID 匹配到 local
+			{ID: "new", Name: "New"},
+			{ID: "nameless"}, // 缺少歌名被跳过
+		},
+		Lyrics: []models.LyricMapping{
+			{ID: "remote", Lyric: "remote lyric"},
+			{ID: "new", Lyric: "new lyric"},
+			{ID: "kept", Lyric: "kept lyric"},
+			{ID: "nameless", Lyric: "x"},
+			{ID: "ghost", Lyric: "x"},
+		},
+	}
+	report, err := s.ImportDataMerge(in, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Lyrics.Added != 3 || report.Lyrics.Skipped != 2 {
+		t.Fatalf("lyric counts = %+v", report.Lyrics)
+	}
+
+	var lyrics []models.LyricMapping
+	if err := s.db.Order("id").Find(&lyrics).Error; err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, l := range lyrics {
+		got[l.ID] = l.Lyric
+	}
+	want := map[string]string{"local": "remote lyric", "new": "new lyric", "kept": "kept lyric"}
+	if len(got) != len(want) {
+		t.Fatalf("lyric mappings = %v, want %v", got, want)
+	}
+	for id, lyric := range want {
+		if got[id] != lyric {
+			t.Fatalf("lyric mappings = %v, want %v", got, want)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"half-beat-player/internal/models"
@@ -127,8 +128,8 @@ func (s *Service) PollLogin(qrcodeKey string) (LoginPollResponse, error) {
 	switch res.Data.Code {
 	case 0:
 		// 登录成功，Cookie 会自动保存到 CookieJar
-		// 保存登录状态到文件
-		_ = s.saveCookies()
+		// 保存完整 cookie 与 refresh_token 到数据库
+		_ = s.saveCookies(res.Data.RefreshToken, resp.Cookies())
 		return LoginPollResponse{LoggedIn: true, Message: "登录成功"}, nil
 	case 86038:
 		return LoginPollResponse{LoggedIn: false, Message: "二维码已失效"}, nil
@@ -147,44 +148,75 @@ func (s *Service) PollLogin(qrcodeKey string) (LoginPollResponse, error) {
 
 func (s *Service) IsLoggedIn() bool {
 	// Check if we have SESSDATA cookie
-	cookies := s.cookieJar.Cookies(&url.URL{Scheme: "https", Host: "www.bilibili.com"})
-	for _, c := range cookies {
-		if c.Name == "SESSDATA" && c.Value != "" {
-			return true
-		}
-	}
-	return false
+	return s.biliCookieValue("SESSDATA") != ""
 }
 
-// saveCookies 将 SESSDATA cookie 保存到文件
-func (s *Service) saveCookies() error {
-	cookies := s.cookieJar.Cookies(&url.URL{Scheme: "https", Host: "www.bilibili.com"})
+// saveCookies 将当前 cookie jar 中的登录状态保存为一个账号，并设为当前账号。
+// issued 为登录响应中下发的 cookie，用于保留服务端给出的过期时间。
+func (s *Service) saveCookies(refreshToken string, issued []*http.Cookie) error {
+	s.accountMu.Lock()
+	defer s.accountMu.Unlock()
+	return s.saveSessionLocked(refreshToken, issued)
+}
 
-	var sessdataValue string
-	for _, c := range cookies {
-		if c.Name == "SESSDATA" && c.Value != "" {
-			sessdataValue = c.Value
-			break
+// saveSessionLocked persists the jar's cookies as the active account. Callers hold accountMu.
+func (s *Service) saveSessionLocked(refreshToken string, issued []*http.Cookie) error {
+	sessdata := s.biliCookieValue("SESSDATA")
+	if sessdata == "" {
+		return fmt.Errorf("登录 Cookie 中缺少 SESSDATA")
+	}
+	uid, _ := strconv.ParseInt(s.biliCookieValue("DedeUserID"), 10, 64)
+
+	session := models.LoginSession{}
+	info, infoErr := s.fetchUserInfo()
+	if infoErr == nil {
+		uid = info.UID
+		session.Username = info.Username
+		session.Face = info.Face
+	}
+
+	// 同一 UID 重新登录时复用原有账号记录
+	var previous []sessionCookie
+	if uid != 0 {
+		var existing models.LoginSession
+		if err := s.db.Where("uid = ?", uid).First(&existing).Error; err == nil {
+			// 解密失败时当作全新登录处理，旧凭据将被覆盖
+			_ = s.openSession(&existing)
+			session.ID = existing.ID
+			previous = sessionCookiesOf(existing)
+			if infoErr != nil {
+				session.Username = existing.Username
+				session.Face = existing.Face
+			}
+			if refreshToken == "" {
+				refreshToken = existing.RefreshToken
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("load login session from db: %w", err)
 		}
 	}
 
-	data := map[string]string{
-		"sessdata": sessdataValue,
-		"saved_at": time.Now().Format(time.RFC3339),
-	}
-
-	jsonData, err := json.Marshal(data)
+	cookiesJSON, err := json.Marshal(collectSessionCookies(s.cookieJar, issued, previous))
 	if err != nil {
 		return fmt.Errorf("encode cookie payload: %w", err)
 	}
-	_ = jsonData // keep the payload shape stable for migration/debug (not persisted as file anymore)
 
-	session := models.LoginSession{
-		ID:       1,
-		Sessdata: sessdataValue,
-		SavedAt:  time.Now(),
+	session.UID = uid
+	session.Sessdata = sessdata
+	session.Cookies = string(cookiesJSON)
+	session.RefreshToken = refreshToken
+	session.Active = true
+	session.SavedAt = time.Now()
+	if err := s.sealSession(&session); err != nil {
+		return err
 	}
-	if err := s.db.Save(&session).Error; err != nil {
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.LoginSession{}).Where("active = ?", true).Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Save(&session).Error
+	}); err != nil {
 		return fmt.Errorf("save login session to db: %w", err)
 	}
 
@@ -194,25 +226,50 @@ func (s *Service) saveCookies() error {
 	return nil
 }
 
-// restoreLogin 从文件恢复之前保存的登录状态
+// collectSessionCookies snapshots every bilibili.com cookie in the jar. Expiry comes from
+// the freshly issued Set-Cookie headers when present, otherwise from the previously stored set.
+func collectSessionCookies(jar http.CookieJar, issued []*http.Cookie, previous []sessionCookie) []sessionCookie {
+	expires := map[string]time.Time{}
+	for _, c := range previous {
+		expires[c.Name] = c.Expires
+	}
+	for _, c := range issued {
+		if !c.Expires.IsZero() {
+			expires[c.Name] = c.Expires
+		} else if c.MaxAge > 0 {
+			expires[c.Name] = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+		}
+	}
+
+	jarCookies := jar.Cookies(&url.URL{Scheme: "https", Host: "www.bilibili.com"})
+	out := make([]sessionCookie, 0, len(jarCookies))
+	for _, c := range jarCookies {
+		if c.Name == "" || c.Value == "" {
+			continue
+		}
+		out = append(out, sessionCookie{Name: c.Name, Value: c.Value, Expires: expires[c.Name]})
+	}
+	return out
+}
+
+// restoreLogin 从数据库恢复当前账号的登录状态
 func (s *Service) restoreLogin() error {
 	// 1) Prefer DB session
 	var session models.LoginSession
-	if err := s.db.First(&session, 1).Error; err == nil {
-		if session.Sessdata != "" {
-			biliURL := &url.URL{Scheme: "https", Host: "www.bilibili.com"}
-			cookies := []*http.Cookie{
-				{
-					Name:     "SESSDATA",
-					Value:    session.Sessdata,
-					Path:     "/",
-					Domain:   ".bilibili.com",
-					Expires:  time.Now().AddDate(0, 1, 0), // 默认一个月有效期
-					HttpOnly: true,
-					Secure:   true,
-				},
-			}
-			s.cookieJar.SetCookies(biliURL, cookies)
+	err := s.db.Where("active = ?", true).Order("saved_at desc").First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 旧版本的单行记录没有 active 标记，取最近保存的一条作为当前账号
+		err = s.db.Order("saved_at desc").First(&session).Error
+		if err == nil {
+			_ = s.db.Model(&session).Update("active", true).Error
+		}
+	}
+	if err == nil {
+		if err := s.openSession(&session); err != nil {
+			return err
+		}
+		if cookies := sessionCookiesOf(session); len(cookies) > 0 {
+			s.cookieJar.swap(newSessionJar(cookies))
 		}
 		return nil
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -238,22 +295,13 @@ func (s *Service) restoreLogin() error {
 	}
 
 	// 恢复 SESSDATA cookie
-	biliURL := &url.URL{Scheme: "https", Host: "www.bilibili.com"}
-	cookies := []*http.Cookie{
-		{
-			Name:     "SESSDATA",
-			Value:    sessdata,
-			Path:     "/",
-			Domain:   ".bilibili.com",
-			Expires:  time.Now().AddDate(0, 1, 0), // 默认一个月有效期
-			HttpOnly: true,
-			Secure:   true,
-		},
-	}
-	s.cookieJar.SetCookies(biliURL, cookies)
+	s.cookieJar.swap(newSessionJar([]sessionCookie{{Name: "SESSDATA", Value: sessdata}}))
 
 	// Migrate to DB and remove legacy file best-effort.
-	migrated := models.LoginSession{ID: 1, Sessdata: sessdata, SavedAt: time.Now()}
+	migrated := models.LoginSession{Sessdata: sessdata, Active: true, SavedAt: time.Now()}
+	if err := s.sealSession(&migrated); err != nil {
+		return err
+	}
 	if err := s.db.Save(&migrated).Error; err != nil {
 		return fmt.Errorf("migrate legacy cookie to db: %w", err)
 	}
@@ -262,6 +310,22 @@ func (s *Service) restoreLogin() error {
 	return nil
 }
 
+// biliCookieValue returns the value of the named cookie for bilibili.com, or "".
+func (s *Service) biliCookieValue(name string) string {
+	return jarCookieValue(s.cookieJar, name)
+}
+
+// jarCookieValue returns the value of a bilibili.com cookie in jar, or "".
+func jarCookieValue(jar http.CookieJar, name string) string {
+	cookies := jar.Cookies(&url.URL{Scheme: "https", Host: "www.bilibili.com"})
+	for _, c := range cookies {
+		if c.Name == name && c.Value != "" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
 type UserInfo struct {
 	UID      int64  `json:"uid"`
 	Username string `json:"username"`
@@ -271,6 +335,19 @@ type UserInfo struct {
 }
 
 func (s *Service) GetUserInfo() (*UserInfo, error) {
+	info, err := s.fetchUserInfo()
+	if err != nil {
+		return nil, err
+	}
+	// 顺带刷新当前账号的昵称与头像（旧版本迁移的账号没有这些信息）
+	_ = s.db.Model(&models.LoginSession{}).
+		Where("active = ? AND (uid = ? OR uid = 0)", true, info.UID).
+		Updates(map[string]any{"uid": info.UID, "username": info.Username, "face": info.Face}).Error
+	return info, nil
+}
+
+// fetchUserInfo queries the nav API with the current cookie jar.
+func (s *Service) fetchUserInfo() (*UserInfo, error) {
 	if !s.IsLoggedIn() {
 		return nil, fmt.Errorf("未登录")
 	}
@@ -331,12 +408,17 @@ func (s *Service) GetUserInfo() (*UserInfo, error) {
 	}, nil
 }
 
+// Logout signs out the current account and forgets its stored session.
+// Other saved accounts are kept and can be restored with SwitchAccount.
 func (s *Service) Logout() error {
+	s.accountMu.Lock()
+	defer s.accountMu.Unlock()
+
 	// Clear all cookies
-	s.cookieJar.SetCookies(&url.URL{Scheme: "https", Host: "www.bilibili.com"}, []*http.Cookie{})
+	s.cookieJar.swap(newSessionJar(nil))
 
 	// Clear persisted session in DB
-	if err := s.db.Delete(&models.LoginSession{}, 1).Error; err != nil {
+	if err := s.db.Delete(&models.LoginSession{}, "active = ?", true).Error; err != nil {
 		return fmt.Errorf("clear login session in db: %w", err)
 	}
 
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ===== SMS & password login =====
+// 两种方式都需要先通过极验（geetest）人机验证：后端下发 gt/challenge，
+// 前端完成验证后回传 validate/seccode。
+
+// LoginCaptcha is the geetest challenge the UI has to solve before SMS or password login.
+type LoginCaptcha struct {
+	Token     string `json:"token"`
+	GT        string `json:"gt"`
+	Challenge string `json:"challenge"`
+}
+
+// CaptchaResult is the solved geetest challenge returned by the UI.
+type CaptchaResult struct {
+	Token     string `json:"token"`
+	Challenge string `json:"challenge"`
+	Validate  string `json:"validate"`
+	Seccode   string `json:"seccode"`
+}
+
+// GetLoginCaptcha requests a new geetest challenge.
+func (s *Service) GetLoginCaptcha() (LoginCaptcha, error) {
+	req, err := http.NewRequest("GET", "https://passport.bilibili.com/x/passport-login/captcha?source=main_web", nil)
+	if err != nil {
+		return LoginCaptcha{}, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://www.bilibili.com/")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return LoginCaptcha{}, err
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			Type    string `json:"type"`
+			Token   string `json:"token"`
+			Geetest struct {
+				GT        string `json:"gt"`
+				Challenge string `json:"challenge"`
+			} `json:"geetest"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return LoginCaptcha{}, fmt.Errorf("parse response failed: %w", err)
+	}
+	if res.Code != 0 {
+		return LoginCaptcha{}, fmt.Errorf("get captcha failed: code=%d, message=%s", res.Code, res.Message)
+	}
+	if res.Data.Type != "" && res.Data.Type != "geetest" {
+		return LoginCaptcha{}, fmt.Errorf("不支持的验证码类型: %s", res.Data.Type)
+	}
+
+	return LoginCaptcha{
+		Token:     res.Data.Token,
+		GT:        res.Data.Geetest.GT,
+		Challenge: res.Data.Geetest.Challenge,
+	}, nil
+}
+
+// SendSMSCode sends a login code to the phone and returns the captcha_key needed by LoginWithSMS.
+// countryCode defaults to 86 (mainland China).
+func (s *Service) SendSMSCode(countryCode int, phone string, captcha CaptchaResult) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", fmt.Errorf("手机号不能为空")
+	}
+	if countryCode <= 0 {
+		countryCode = 86
+	}
+
+	form := url.Values{}
+	form.Set("cid", strconv.Itoa(countryCode))
+	form.Set("tel", phone)
+	form.Set("source", "main_web")
+	form.Set("token", captcha.Token)
+	form.Set("challenge", captcha.Challenge)
+	form.Set("validate", captcha.Validate)
+	form.Set("seccode", captcha.Seccode)
+
+	body, _, err := s.postLoginForm("https://passport.bilibili.com/x/passport-login/web/sms/send", form)
+	if err != nil {
+		return "", err
+	}
+
+	var res struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			CaptchaKey string `json:"captcha_key"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", fmt.Errorf("parse response failed: %w", err)
+	}
+	if res.Code != 0 {
+		return "", fmt.Errorf("发送验证码失败: code=%d, message=%s", res.Code, res.Message)
+	}
+	return res.Data.CaptchaKey, nil
+}
+
+// LoginWithSMS verifies the SMS code and, on success, persists the session like QR login.
+func (s *Service) LoginWithSMS(countryCode int, phone, code, captchaKey string) (LoginPollResponse, error) {
+	if countryCode <= 0 {
+		countryCode = 86
+	}
+
+	form := url.Values{}
+	form.Set("cid", strconv.Itoa(countryCode))
+	form.Set("tel", strings.TrimSpace(phone))
+	form.Set("code", strings.TrimSpace(code))
+	form.Set("source", "main_web")
+	form.Set("captcha_key", captchaKey)
+
+	body, cookies, err := s.postLoginForm("https://passport.bilibili.com/x/passport-login/web/login/sms", form)
+	if err != nil {
+		return LoginPollResponse{}, err
+	}
+	return s.finishLogin(body, cookies)
+}
+
+// LoginWithPassword logs in with account name and password. The password is
+// RSA-encrypted with the salt and public key issued by Bilibili.
+func (s *Service) LoginWithPassword(username, password string, captcha CaptchaResult) (LoginPollResponse, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || password == "" {
+		return LoginPollResponse{}, fmt.Errorf("账号和密码不能为空")
+	}
+
+	encrypted, err := s.encryptLoginPassword(password)
+	if err != nil {
+		return LoginPollResponse{}, err
+	}
+
+	form := url.Values{}
+	form.Set("username", username)
+	form.Set("password", encrypted)
+	form.Set("keep", "0")
+	form.Set("source", "main_web")
+	form.Set("token", captcha.Token)
+	form.Set("challenge", captcha.Challenge)
+	form.Set("validate", captcha.Validate)
+	form.Set("seccode", captcha.Seccode)
+
+	body, cookies, err := s.postLoginForm("https://passport.bilibili.com/x/passport-login/web/login", form)
+	if err != nil {
+		return LoginPollResponse{}, err
+	}
+	return s.finishLogin(body, cookies)
+}
+
+// encryptLoginPassword fetches the login key and returns base64(RSA(hash + password)).
+func (s *Service) encryptLoginPassword(password string) (string, error) {
+	req, err := http.NewRequest("GET", "https://passport.bilibili.com/x/passport-login/web/key", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://www.bilibili.com/")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			Hash string `json:"hash"`
+			Key  string `json:"key"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", fmt.Errorf("parse response failed: %w", err)
+	}
+	if res.Code != 0 {
+		return "", fmt.Errorf("get login key failed: code=%d, message=%s", res.Code, res.Message)
+	}
+
+	block, _ := pem.Decode([]byte(res.Data.Key))
+	if block == nil {
+		return "", fmt.Errorf("invalid login public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("parse login public key: %w", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("login public key is not RSA")
+	}
+	encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, rsaPub, []byte(res.Data.Hash+password))
+	if err != nil {
+		return "", fmt.Errorf("encrypt password: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+func (s *Service) postLoginForm(endpoint string, form url.Values) ([]byte, []*http.Cookie, error) {
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://www.bilibili.com/")
+	req.Header.Set("Origin", "https://www.bilibili.com")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, resp.Cookies(), nil
+}
+
+// finishLogin interprets an SMS/password login response and persists the session on success.
+func (s *Service) finishLogin(body []byte, cookies []*http.Cookie) (LoginPollResponse, error) {
+	var res struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			Status       int    `json:"status"`
+			Message      string `json:"message"`
+			RefreshToken string `json:"refresh_token"`
+			URL          string `json:"url"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return LoginPollResponse{}, fmt.Errorf("parse response failed: %w", err)
+	}
+	if res.Code != 0 {
+		msg := res.Message
+		if msg == "" {
+			msg = fmt.Sprintf("未知错误码: %d", res.Code)
+		}
+		return LoginPollResponse{LoggedIn: false, Message: msg}, nil
+	}
+	if res.Data.Status != 0 {
+		// 账号存在风险时需要在网页端完成二次验证
+		msg := res.Data.Message
+		if msg == "" {
+			msg = "需要进行二次验证，请使用扫码登录"
+		}
+		return LoginPollResponse{LoggedIn: false, Message: msg}, nil
+	}
+
+	if err := s.saveCookies(res.Data.RefreshToken, cookies); err != nil {
+		return LoginPollResponse{}, err
+	}
+	return LoginPollResponse{LoggedIn: true, Message: "登录成功"}, nil
+}
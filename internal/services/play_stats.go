@@ -0,0 +1,280 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"half-beat-player/internal/models"
+)
+
+// ===== Play events & listening statistics =====
+
+const (
+	playSourceCache  = "cache"
+	playSourceStream = "stream"
+)
+
+// SongStat is one row of a top-songs list.
+type SongStat struct {
+	Song            models.Song `json:"song"`
+	Plays           int64       `json:"plays"`
+	ListenedSeconds float64     `json:"listenedSeconds"`
+}
+
+// ArtistStat is one row of a top-artists list.
+type ArtistStat struct {
+	Singer          string  `json:"singer"`
+	Plays           int64   `json:"plays"`
+	ListenedSeconds float64 `json:"listenedSeconds"`
+}
+
+// ListeningSummary aggregates all play events in a period.
+type ListeningSummary struct {
+	Plays           int64   `json:"plays"`
+	CompletedPlays  int64   `json:"completedPlays"`
+	SkippedPlays    int64   `json:"skippedPlays"`
+	SkipRate        float64 `json:"skipRate"` // 0..1
+	ListenedSeconds float64 `json:"listenedSeconds"`
+}
+
+// HeatmapDay is the listening total of one local calendar day.
+type HeatmapDay struct {
+	Date            string  `json:"date"` // YYYY-MM-DD
+	Plays           int     `json:"plays"`
+	ListenedSeconds float64 `json:"listenedSeconds"`
+}
+
+// RecentPlay is a song with the time it was last played.
+type RecentPlay struct {
+	Song       models.Song `json:"song"`
+	FavoriteID string      `json:"favoriteId"`
+	PlayedAt   time.Time   `json:"playedAt"`
+}
+
+// RecordPlayEvent appends a play event. The frontend calls it when a song stops
+// playing (finished, skipped or replaced).
+func (s *Service) RecordPlayEvent(event models.PlayEvent) (models.PlayEvent, error) {
+	if event.SongID == "" {
+		return event, fmt.Errorf("songID 不能为空")
+	}
+	if event.ListenedSeconds < 0 {
+		event.ListenedSeconds = 0
+	}
+	switch event.Source {
+	case playSourceCache, playSourceStream:
+	case "":
+		event.Source = playSourceStream
+	default:
+		return event, fmt.Errorf("未知播放来源: %s", event.Source)
+	}
+	if event.StartedAt.IsZero() {
+		event.StartedAt = time.Now().Add(-time.Duration(event.ListenedSeconds * float64(time.Second)))
+	}
+	event.ID = 0
+	if err := s.db.Create(&event).Error; err != nil {
+		return event, fmt.Errorf("save play event: %w", err)
+	}
+	if event.Completed {
+		// 播放完成后不再续播
+		if err := s.ClearResumePosition(event.SongID); err != nil {
+			log.Printf("clear resume position: %v", err)
+		}
+	}
+	s.scrobblePlayEvent(event)
+	return event, nil
+}
+
+// periodStart maps a period name (day, week, month, year, all) to its start time.
+func periodStart(period string) (time.Time, error) {
+	now := time.Now()
+	switch period {
+	case "day":
+		return now.AddDate(0, 0, -1), nil
+	case "week":
+		return now.AddDate(0, 0, -7), nil
+	case "month":
+		return now.AddDate(0, -1, 0), nil
+	case "year":
+		return now.AddDate(-1, 0, 0), nil
+	case "all", "":
+		return time.Time{}, nil
+	default:
+		return time.Time{}, fmt.Errorf("未知统计周期: %s", period)
+	}
+}
+
+// GetTopSongs returns the most played songs in the period.
+func (s *Service) GetTopSongs(period string, limit int) ([]SongStat, error) {
+	since, err := periodStart(period)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var rows []struct {
+		SongID          string
+		Plays           int64
+		ListenedSeconds float64
+	}
+	if err := s.db.Model(&models.PlayEvent{}).
+		Select("song_id, COUNT(*) AS plays, SUM(listened_seconds) AS listened_seconds").
+		Where("started_at >= ?", since).
+		// 已删除的歌曲统计仍保留但不再展示，先排除再取前 limit 首
+		Where("song_id IN (SELECT id FROM songs)").
+		Group("song_id").
+		Order("plays DESC, listened_seconds DESC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	songs, err := s.songsByID(len(rows), func(i int) string { return rows[i].SongID })
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]SongStat, 0, len(rows))
+	for _, r := range rows {
+		song, ok := songs[r.SongID]
+		if !ok {
+			continue
+		}
+		out = append(out, SongStat{Song: song, Plays: r.Plays, ListenedSeconds: r.ListenedSeconds})
+	}
+	return out, nil
+}
+
+// GetTopArtists returns the most played singers in the period.
+func (s *Service) GetTopArtists(period string, limit int) ([]ArtistStat, error) {
+	since, err := periodStart(period)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var out []ArtistStat
+	if err := s.db.Table("play_events").
+		Select("songs.singer AS singer, COUNT(*) AS plays, SUM(play_events.listened_seconds) AS listened_seconds").
+		Joins("JOIN songs ON songs.id = play_events.song_id").
+		Where("play_events.started_at >= ? AND songs.singer <> ''", since).
+		Group("songs.singer").
+		Order("plays DESC, listened_seconds DESC").
+		Limit(limit).
+		Scan(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetListeningSummary returns total listening time, play counts and skip rate for the period.
+func (s *Service) GetListeningSummary(period string) (ListeningSummary, error) {
+	var out ListeningSummary
+	since, err := periodStart(period)
+	if err != nil {
+		return out, err
+	}
+
+	if err := s.db.Model(&models.PlayEvent{}).
+		Select("COUNT(*) AS plays, "+
+			"COALESCE(SUM(CASE WHEN completed THEN 1 ELSE 0 END), 0) AS completed_plays, "+
+			"COALESCE(SUM(CASE WHEN skipped THEN 1 ELSE 0 END), 0) AS skipped_plays, "+
+			"COALESCE(SUM(listened_seconds), 0) AS listened_seconds").
+		Where("started_at >= ?", since).
+		Scan(&out).Error; err != nil {
+		return out, err
+	}
+	if out.Plays > 0 {
+		out.SkipRate = float64(out.SkippedPlays) / float64(out.Plays)
+	}
+	return out, nil
+}
+
+// GetListeningHeatmap returns one entry per local day for the last `days` days, oldest first.
+func (s *Service) GetListeningHeatmap(days int) ([]HeatmapDay, error) {
+	if days <= 0 || days > 366 {
+		days = 365
+	}
+	today := time.Now()
+	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, -(days - 1))
+
+	var events []models.PlayEvent
+	if err := s.db.Select("started_at, listened_seconds").
+		Where("started_at >= ?", start).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]HeatmapDay, days)
+	index := make(map[string]int, days)
+	for i := range out {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		out[i].Date = date
+		index[date] = i
+	}
+	for _, e := range events {
+		if i, ok := index[e.StartedAt.Local().Format("2006-01-02")]; ok {
+			out[i].Plays++
+			out[i].ListenedSeconds += e.ListenedSeconds
+		}
+	}
+	return out, nil
+}
+
+// GetRecentlyPlayed returns distinct songs ordered by when they were last played.
+func (s *Service) GetRecentlyPlayed(limit int) ([]RecentPlay, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	// 每首歌只取最近的一次播放
+	var events []models.PlayEvent
+	if err := s.db.
+		Where("id = (SELECT p2.id FROM play_events p2 WHERE p2.song_id = play_events.song_id ORDER BY p2.started_at DESC, p2.id DESC LIMIT 1)").
+		// 与 GetTopSongs 相同，先排除已删除的歌曲再取前 limit 首
+		Where("song_id IN (SELECT id FROM songs)").
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	songs, err := s.songsByID(len(events), func(i int) string { return events[i].SongID })
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]RecentPlay, 0, len(events))
+	for _, e := range events {
+		song, ok := songs[e.SongID]
+		if !ok {
+			continue
+		}
+		out = append(out, RecentPlay{Song: song, FavoriteID: e.FavoriteID, PlayedAt: e.StartedAt})
+	}
+	return out, nil
+}
+
+// songsByID loads the songs with the n ids produced by id(i), keyed by ID.
+func (s *Service) songsByID(n int, id func(i int) string) (map[string]models.Song, error) {
+	out := make(map[string]models.Song, n)
+	if n == 0 {
+		return out, nil
+	}
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		ids = append(ids, id(i))
+	}
+	var songs []models.Song
+	if err := s.db.Where("id IN ?", ids).Find(&songs).Error; err != nil {
+		return nil, err
+	}
+	for _, song := range songs {
+		out[song.ID] = song
+	}
+	return out, nil
+}
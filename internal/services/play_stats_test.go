@@ -0,0 +1,150 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"half-beat-player/internal/models"
+)
+
+func seedPlays(t *testing.T, s *Service) {
+	t.Helper()
+	if err := s.UpsertSongs([]models.Song{
+		{ID: "a", Name: "A", Singer: "X"},
+		{ID: "b", Name: "B", Singer: "Y"},
+		{ID: "c", Name: "C", Singer: "X"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	events := []models.PlayEvent{
+		{SongID: "a", ListenedSeconds: 100, Completed: true, StartedAt: now.Add(-3 * time.Hour)},
+		{SongID: "a", ListenedSeconds: 10, Skipped: true, StartedAt: now.Add(-2 * time.Hour)},
+		{SongID: "b", ListenedSeconds: 50, StartedAt: now.AddDate(0, 0, -3)},
+		{SongID: "c", ListenedSeconds: 30, Completed: true, StartedAt: now.AddDate(0, -2, 0)},
+		// 已删除歌曲的播放记录最多、最近
+		{SongID: "gone", ListenedSeconds: 60, StartedAt: now.Add(-time.Hour)},
+		{SongID: "gone", ListenedSeconds: 60, StartedAt: now.Add(-time.Hour)},
+		{SongID: "gone", ListenedSeconds: 60, StartedAt: now.Add(-time.Hour)},
+	}
+	for _, e := range events {
+		if _, err := s.RecordPlayEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func pluck[T any](rows []T, id func(T) string) []string {
+	out := make([]string, len(rows))
+	for i, r := range rows {
+		out[i] = id(r)
+	}
+	return out
+}
+
+func TestGetTopSongs(t *testing.T) {
+	s := newTestService(t)
+	seedPlays(t, s)
+
+	top, err := s.GetTopSongs("week", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := pluck(top, func(r SongStat) string { return r.Song.ID })
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Fatalf("top songs = %v, want [a b]", ids)
+	}
+	if top[0].Plays != 2 || top[0].ListenedSeconds != 110 {
+		t.Fatalf("top song a = %+v", top[0])
+	}
+
+	all, err := s.GetTopSongs("all", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("all-time top songs = %d, want 3", len(all))
+	}
+	if _, err := s.GetTopSongs("decade", 5); err == nil {
+		t.Fatal("unknown period accepted")
+	}
+}
+
+func TestGetRecentlyPlayedSkipsDeletedBeforeLimit(t *testing.T) {
+	s := newTestService(t)
+	seedPlays(t, s)
+
+	recent, err := s.GetRecentlyPlayed(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := pluck(recent, func(r RecentPlay) string { return r.Song.ID })
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Fatalf("recently played = %v, want [a b]", ids)
+	}
+	// 每首歌只出现一次，时间为最近一次播放
+	if want := time.Now().Add(-2 * time.Hour); recent[0].PlayedAt.Sub(want).Abs() > time.Minute {
+		t.Fatalf("a played at %v, want about %v", recent[0].PlayedAt, want)
+	}
+}
+
+func TestGetTopArtistsAndSummary(t *testing.T) {
+	s := newTestService(t)
+	seedPlays(t, s)
+
+	artists, err := s.GetTopArtists("all", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artists) != 2 || artists[0].Singer != "X" || artists[0].Plays != 3 {
+		t.Fatalf("top artists = %+v", artists)
+	}
+
+	sum, err := s.GetListeningSummary("week")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Plays != 6 || sum.CompletedPlays != 1 || sum.SkippedPlays != 1 || sum.ListenedSeconds != 340 {
+		t.Fatalf("summary = %+v", sum)
+	}
+	if sum.SkipRate != 1.0/6 {
+		t.Fatalf("skip rate = %v", sum.SkipRate)
+	}
+}
+
+func TestGetListeningHeatmap(t *testing.T) {
+	s := newTestService(t)
+	seedPlays(t, s)
+
+	days, err := s.GetListeningHeatmap(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 7 || days[6].Date != time.Now().Format("2006-01-02") {
+		t.Fatalf("heatmap days = %+v", days)
+	}
+	total := 0
+	for _, d := range days {
+		total += d.Plays
+	}
+	if total != 6 {
+		t.Fatalf("heatmap plays = %d, want 6", total)
+	}
+}
+
+func TestRecordPlayEventValidation(t *testing.T) {
+	s := newTestService(t)
+	if _, err := s.RecordPlayEvent(models.PlayEvent{}); err == nil {
+		t.Fatal("event without song accepted")
+	}
+	if _, err := s.RecordPlayEvent(models.PlayEvent{SongID: "a", Source: "radio"}); err == nil {
+		t.Fatal("unknown source accepted")
+	}
+	e, err := s.RecordPlayEvent(models.PlayEvent{SongID: "a", ListenedSeconds: -5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Source != playSourceStream || e.ListenedSeconds != 0 || e.StartedAt.IsZero() {
+		t.Fatalf("defaults not applied: %+v", e)
+	}
+}
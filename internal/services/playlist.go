@@ -1,8 +1,10 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
-	"time"
+	"fmt"
+	"sort"
 
 	"half-beat-player/internal/models"
 
@@ -10,29 +12,89 @@ import (
 )
 
 // SavePlaylist saves the current playback queue and index.
+// queueJSON is a JSON array of song IDs; it replaces the queue order, keeping
+// the origin of songs that were already queued. A shuffled queue stays shuffled:
+// kept songs retain their original position and new ones go to the end of the
+// original order. Saving an unchanged queue emits no change event.
 func (s *Service) SavePlaylist(queueJSON string, currentIndex int) error {
-	playlist := models.Playlist{
-		ID:           1,
-		Queue:        queueJSON,
-		CurrentIndex: currentIndex,
-		UpdatedAt:    time.Now(),
+	var ids []string
+	if queueJSON != "" {
+		if err := json.Unmarshal([]byte(queueJSON), &ids); err != nil {
+			return fmt.Errorf("parse queue: %w", err)
+		}
 	}
-	return s.db.Save(&playlist).Error
+	_, err := s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		if currentIndex == w.playlist.CurrentIndex && sameQueueSongs(w.entries, ids) {
+			return errQueueUnchanged
+		}
+		existing := map[string][]models.QueueEntry{}
+		for _, e := range w.entries {
+			existing[e.SongID] = append(existing[e.SongID], e)
+		}
+		entries := make([]models.QueueEntry, 0, len(ids))
+		var added []int
+		for _, id := range ids {
+			if reuse := existing[id]; len(reuse) > 0 {
+				entries = append(entries, reuse[0])
+				existing[id] = reuse[1:]
+				continue
+			}
+			added = append(added, len(entries))
+			entries = append(entries, newQueueEntries([]string{id}, "", "user")...)
+		}
+		if w.playlist.Shuffled {
+			// 保留原始顺序：已有歌曲按原位置排列，新歌接在末尾，再压缩为连续编号
+			order := make([]int, len(entries))
+			for i := range order {
+				order[i] = i
+			}
+			for n, i := range added {
+				entries[i].OriginalPosition = len(w.entries) + n
+			}
+			sort.SliceStable(order, func(a, b int) bool {
+				return entries[order[a]].OriginalPosition < entries[order[b]].OriginalPosition
+			})
+			for pos, i := range order {
+				entries[i].OriginalPosition = pos
+			}
+		} else {
+			for i := range entries {
+				entries[i].OriginalPosition = i
+			}
+		}
+		w.entries = entries
+		w.playlist.CurrentIndex = currentIndex
+		return nil
+	})
+	if errors.Is(err, errQueueUnchanged) {
+		return nil
+	}
+	return err
+}
+
+// sameQueueSongs reports whether the queue holds exactly ids in order.
+func sameQueueSongs(entries []models.QueueEntry, ids []string) bool {
+	if len(entries) != len(ids) {
+		return false
+	}
+	for i, e := range entries {
+		if e.SongID != ids[i] {
+			return false
+		}
+	}
+	return true
 }
 
 // GetPlaylist retrieves the saved playlist state.
 func (s *Service) GetPlaylist() (models.Playlist, error) {
-	var playlist models.Playlist
-	if err := s.db.First(&playlist, 1).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// Return empty playlist if not found
-			return models.Playlist{
-				ID:           1,
-				Queue:        "[]",
-				CurrentIndex: 0,
-			}, nil
-		}
-		return playlist, err
+	w, err := loadQueue(s.db, currentQueueID)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	playlist := w.playlist
+	playlist.Queue = queueSongIDsJSON(w.entries)
+	if playlist.RepeatMode == "" {
+		playlist.RepeatMode = repeatAll
 	}
 	return playlist, nil
 }
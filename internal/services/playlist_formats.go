@@ -0,0 +1,531 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"half-beat-player/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ===== Playlist import/export (M3U8, XSPF, JSPF) =====
+
+const (
+	playlistFormatM3U8 = "m3u8"
+	playlistFormatXSPF = "xspf"
+	playlistFormatJSPF = "jspf"
+
+	xspfNamespace = "http://xspf.org/ns/0/"
+)
+
+// playlistTrack is the format-neutral form of one playlist entry.
+type playlistTrack struct {
+	Title    string
+	Creator  string
+	Duration float64 // 秒，0 表示未知
+	Image    string
+	Location string
+}
+
+// PlaylistImportResult reports how an imported playlist was matched to the library.
+type PlaylistImportResult struct {
+	Favorite models.Favorite `json:"favorite"`
+	Matched  int             `json:"matched"` // 匹配到已有歌曲
+	Created  int             `json:"created"` // 根据 B 站链接新建的歌曲
+	Skipped  []string        `json:"skipped"` // 无法识别的条目
+}
+
+// biliVideoURL returns the bilibili page URL of a song.
+func biliVideoURL(song models.Song) string {
+	if song.BVID == "" {
+		return ""
+	}
+	u := "https://www.bilibili.com/video/" + song.BVID
+	if song.PageNumber > 1 {
+		u += "?p=" + strconv.Itoa(song.PageNumber)
+	}
+	return u
+}
+
+// songDurations returns the last reported duration (seconds) of each song from play events.
+func songDurations(db *gorm.DB, ids []string) (map[string]float64, error) {
+	out := make(map[string]float64, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		SongID   string
+		Duration float64
+	}
+	if err := db.Model(&models.PlayEvent{}).
+		Select("song_id, MAX(duration_seconds) AS duration").
+		Where("song_id IN ?", ids).
+		Group("song_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		out[r.SongID] = r.Duration
+	}
+	return out, nil
+}
+
+// favoriteTracks loads a favorite's songs as playlist tracks. With preferLocal,
+// downloaded songs point at the local file instead of bilibili.
+func (s *Service) favoriteTracks(favID string, preferLocal bool) (string, []playlistTrack, error) {
+	var fav models.Favorite
+	if err := s.db.Preload("SongIDs", orderedSongRefs).First(&fav, "id = ?", favID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, fmt.Errorf("歌单不存在: %s", favID)
+		}
+		return "", nil, err
+	}
+	if fav.SmartRules != "" {
+		if err := s.fillSmartFavorite(&fav); err != nil {
+			return "", nil, err
+		}
+	}
+	ids := make([]string, 0, len(fav.SongIDs))
+	for _, r := range fav.SongIDs {
+		ids = append(ids, r.SongID)
+	}
+	songs, err := s.songsByID(len(ids), func(i int) string { return ids[i] })
+	if err != nil {
+		return "", nil, err
+	}
+	durations, err := songDurations(s.db, ids)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tracks := make([]playlistTrack, 0, len(ids))
+	for _, id := range ids {
+		song, ok := songs[id]
+		if !ok {
+			continue
+		}
+		t := playlistTrack{
+			Title:    song.Name,
+			Creator:  song.Singer,
+			Duration: durations[id],
+			Image:    song.Cover,
+			Location: biliVideoURL(song),
+		}
+		if preferLocal {
+			path := filepath.Join(s.dataDir, downloadsDir, s.getLocalAudioFilename(song))
+			if _, err := os.Stat(path); err == nil {
+				t.Location = path
+			}
+		}
+		tracks = append(tracks, t)
+	}
+	return fav.Title, tracks, nil
+}
+
+// fileURI converts a local path to a file:// URI; URLs are returned unchanged.
+func fileURI(location string) string {
+	if location == "" || strings.Contains(location, "://") {
+		return location
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(location)}).String()
+}
+
+// ExportFavoritePlaylist renders a favorite as an M3U8, XSPF or JSPF document.
+func (s *Service) ExportFavoritePlaylist(favID, format string, preferLocal bool) (string, error) {
+	title, tracks, err := s.favoriteTracks(favID, preferLocal)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(format) {
+	case playlistFormatM3U8:
+		return encodeM3U8(title, tracks), nil
+	case playlistFormatXSPF:
+		return encodeXSPF(title, tracks)
+	case playlistFormatJSPF:
+		return encodeJSPF(title, tracks)
+	default:
+		return "", fmt.Errorf("不支持的歌单格式: %s", format)
+	}
+}
+
+func encodeM3U8(title string, tracks []playlistTrack) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	if title != "" {
+		fmt.Fprintf(&b, "#PLAYLIST:%s\n", title)
+	}
+	for _, t := range tracks {
+		// M3U8 每个条目必须有 URI，空行会让后续标题错位
+		if t.Location == "" {
+			continue
+		}
+		duration := -1
+		if t.Duration > 0 {
+			duration = int(math.Round(t.Duration))
+		}
+		name := t.Title
+		if t.Creator != "" {
+			name = t.Creator + " - " + t.Title
+		}
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n", duration, name)
+		if t.Image != "" {
+			fmt.Fprintf(&b, "#EXTIMG:%s\n", t.Image)
+		}
+		b.WriteString(t.Location + "\n")
+	}
+	return b.String()
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Version string      `xml:"version,attr"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string     `xml:"location,omitempty"`
+	Title    string     `xml:"title,omitempty"`
+	Creator  string     `xml:"creator,omitempty"`
+	Duration xspfMillis `xml:"duration,omitempty"`
+	Image    string     `xml:"image,omitempty"`
+}
+
+// xspfMillis is an XSPF duration in milliseconds. Some tools write fractions, and
+// encoding/xml would write large floats in exponent form, so both ways are custom.
+type xspfMillis float64
+
+func (m xspfMillis) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(m), 'f', -1, 64)), nil
+}
+
+func (m *xspfMillis) UnmarshalText(text []byte) error {
+	f, err := strconv.ParseFloat(strings.TrimSpace(string(text)), 64)
+	if err != nil {
+		return err
+	}
+	*m = xspfMillis(f)
+	return nil
+}
+
+func encodeXSPF(title string, tracks []playlistTrack) (string, error) {
+	doc := xspfPlaylist{Version: "1", Xmlns: xspfNamespace, Title: title}
+	for _, t := range tracks {
+		doc.Tracks = append(doc.Tracks, xspfTrack{
+			Location: fileURI(t.Location),
+			Title:    t.Title,
+			Creator:  t.Creator,
+			Duration: xspfMillis(math.Round(t.Duration * 1000)),
+			Image:    t.Image,
+		})
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data) + "\n", nil
+}
+
+type jspfDocument struct {
+	Playlist jspfPlaylist `json:"playlist"`
+}
+
+type jspfPlaylist struct {
+	Title string      `json:"title,omitempty"`
+	Track []jspfTrack `json:"track"`
+}
+
+type jspfTrack struct {
+	Location jspfLocations `json:"location,omitempty"`
+	Title    string        `json:"title,omitempty"`
+	Creator  string        `json:"creator,omitempty"`
+	Duration float64       `json:"duration,omitempty"` // 毫秒，部分工具会写成小数
+	Image    string        `json:"image,omitempty"`
+}
+
+// jspfLocations is a JSPF location list; a bare string is accepted on import.
+type jspfLocations []string
+
+func (l *jspfLocations) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*l = jspfLocations{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*l = many
+	return nil
+}
+
+func encodeJSPF(title string, tracks []playlistTrack) (string, error) {
+	doc := jspfDocument{Playlist: jspfPlaylist{Title: title, Track: []jspfTrack{}}}
+	for _, t := range tracks {
+		jt := jspfTrack{
+			Title:    t.Title,
+			Creator:  t.Creator,
+			Duration: math.Round(t.Duration * 1000),
+			Image:    t.Image,
+		}
+		if t.Location != "" {
+			jt.Location = jspfLocations{fileURI(t.Location)}
+		}
+		doc.Playlist.Track = append(doc.Playlist.Track, jt)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// detectPlaylistFormat guesses the format of a playlist document.
+func detectPlaylistFormat(content string) string {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(content, "\ufeff"))
+	switch {
+	case strings.HasPrefix(trimmed, "<"):
+		return playlistFormatXSPF
+	case strings.HasPrefix(trimmed, "{"):
+		return playlistFormatJSPF
+	default:
+		return playlistFormatM3U8
+	}
+}
+
+func decodeM3U8(content string) (string, []playlistTrack) {
+	var (
+		title   string
+		tracks  []playlistTrack
+		pending playlistTrack
+	)
+	sc := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(content, "\ufeff")))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || line == "#EXTM3U":
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			title = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			durationPart, name, _ := strings.Cut(info, ",")
+			if d, err := strconv.ParseFloat(strings.TrimSpace(durationPart), 64); err == nil && d > 0 {
+				pending.Duration = d
+			}
+			if creator, t, ok := strings.Cut(name, " - "); ok {
+				pending.Creator, pending.Title = strings.TrimSpace(creator), strings.TrimSpace(t)
+			} else {
+				pending.Title = strings.TrimSpace(name)
+			}
+		case strings.HasPrefix(line, "#EXTIMG:"):
+			pending.Image = strings.TrimSpace(strings.TrimPrefix(line, "#EXTIMG:"))
+		case strings.HasPrefix(line, "#"):
+		default:
+			pending.Location = line
+			tracks = append(tracks, pending)
+			pending = playlistTrack{}
+		}
+	}
+	return title, tracks
+}
+
+func decodeXSPF(content string) (string, []playlistTrack, error) {
+	var doc xspfPlaylist
+	if err := xml.NewDecoder(bytes.NewReader([]byte(content))).Decode(&doc); err != nil {
+		return "", nil, fmt.Errorf("解析 XSPF 失败: %w", err)
+	}
+	tracks := make([]playlistTrack, 0, len(doc.Tracks))
+	for _, t := range doc.Tracks {
+		tracks = append(tracks, playlistTrack{
+			Title:    strings.TrimSpace(t.Title),
+			Creator:  strings.TrimSpace(t.Creator),
+			Duration: float64(t.Duration) / 1000,
+			Image:    strings.TrimSpace(t.Image),
+			Location: strings.TrimSpace(t.Location),
+		})
+	}
+	return strings.TrimSpace(doc.Title), tracks, nil
+}
+
+func decodeJSPF(content string) (string, []playlistTrack, error) {
+	var doc jspfDocument
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return "", nil, fmt.Errorf("解析 JSPF 失败: %w", err)
+	}
+	tracks := make([]playlistTrack, 0, len(doc.Playlist.Track))
+	for _, t := range doc.Playlist.Track {
+		pt := playlistTrack{
+			Title:    t.Title,
+			Creator:  t.Creator,
+			Duration: t.Duration / 1000,
+			Image:    t.Image,
+		}
+		if len(t.Location) > 0 {
+			pt.Location = t.Location[0]
+		}
+		tracks = append(tracks, pt)
+	}
+	return doc.Playlist.Title, tracks, nil
+}
+
+var localPageRegexp = regexp.MustCompile(`-P(\d+)$`)
+
+// trackBiliRef extracts BVID and page from a bilibili URL or a downloaded file name.
+func trackBiliRef(location string) (bvid string, page int) {
+	bvid = extractBVID(location)
+	if bvid == "" {
+		return "", 0
+	}
+	page = 1
+	if u, err := url.Parse(location); err == nil && u.Scheme != "file" && u.Host != "" {
+		if p, err := strconv.Atoi(u.Query().Get("p")); err == nil && p > 0 {
+			page = p
+		}
+		return bvid, page
+	}
+	base := strings.TrimSuffix(filepath.Base(filepath.FromSlash(location)), filepath.Ext(location))
+	if m := localPageRegexp.FindStringSubmatch(base); m != nil {
+		page, _ = strconv.Atoi(m[1])
+	}
+	return bvid, page
+}
+
+// findSongByBVIDPage returns the oldest library song for a BVID and page.
+func findSongByBVIDPage(tx *gorm.DB, bvid string, page int) (models.Song, bool, error) {
+	var song models.Song
+	q := tx.Where("bvid = ?", bvid)
+	if page <= 1 {
+		q = q.Where("page_number <= 1")
+	} else {
+		q = q.Where("page_number = ?", page)
+	}
+	if err := q.Order("created_at").First(&song).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return song, false, nil
+		}
+		return song, false, err
+	}
+	return song, true, nil
+}
+
+// matchTrackSong finds the library song for a track by BVID+page or by local file name.
+func matchTrackSong(tx *gorm.DB, t playlistTrack) (models.Song, bool, error) {
+	var song models.Song
+	if bvid, page := trackBiliRef(t.Location); bvid != "" {
+		return findSongByBVIDPage(tx, bvid, page)
+	}
+	// 本地文件以歌曲 ID 命名
+	if strings.HasSuffix(t.Location, ".m4s") {
+		id := strings.TrimSuffix(filepath.Base(filepath.FromSlash(t.Location)), ".m4s")
+		err := tx.First(&song, "id = ?", id).Error
+		if err == nil {
+			return song, true, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return song, false, err
+		}
+	}
+	return song, false, nil
+}
+
+// ImportPlaylist creates a favorite from an M3U8, XSPF or JSPF document. An empty
+// format is detected from the content and an empty title uses the playlist's own.
+// Entries are matched to existing songs; unknown bilibili entries become new songs.
+// Everything, including the new favorite, is written in one transaction.
+func (s *Service) ImportPlaylist(content, format, title string) (PlaylistImportResult, error) {
+	var result PlaylistImportResult
+	if format == "" {
+		format = detectPlaylistFormat(content)
+	}
+	var (
+		docTitle string
+		tracks   []playlistTrack
+		err      error
+	)
+	switch strings.ToLower(format) {
+	case playlistFormatM3U8, "m3u":
+		docTitle, tracks = decodeM3U8(content)
+	case playlistFormatXSPF:
+		docTitle, tracks, err = decodeXSPF(content)
+	case playlistFormatJSPF:
+		docTitle, tracks, err = decodeJSPF(content)
+	default:
+		return result, fmt.Errorf("不支持的歌单格式: %s", format)
+	}
+	if err != nil {
+		return result, err
+	}
+	if title = strings.TrimSpace(title); title == "" {
+		title = docTitle
+	}
+	if title == "" {
+		title = "导入的歌单"
+	}
+
+	result.Skipped = []string{}
+	fav := models.Favorite{ID: "FavList-" + uuid.NewString(), Title: title}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		seen := make(map[string]bool)
+		for _, t := range tracks {
+			song, ok, err := matchTrackSong(tx, t)
+			if err != nil {
+				return err
+			}
+			if ok {
+				result.Matched++
+			} else if bvid, page := trackBiliRef(t.Location); bvid != "" {
+				song = models.Song{
+					ID:         uuid.NewString(),
+					BVID:       bvid,
+					Name:       t.Title,
+					Singer:     t.Creator,
+					Cover:      t.Image,
+					PageNumber: page,
+				}
+				if song.Name == "" {
+					song.Name = bvid
+				}
+				if err := tx.Create(&song).Error; err != nil {
+					return err
+				}
+				result.Created++
+			} else {
+				label := t.Title
+				if label == "" {
+					label = t.Location
+				}
+				result.Skipped = append(result.Skipped, label)
+				continue
+			}
+			if seen[song.ID] {
+				continue
+			}
+			seen[song.ID] = true
+			fav.SongIDs = append(fav.SongIDs, models.SongRef{SongID: song.ID})
+		}
+		if err := s.saveFavorite(tx, fav); err != nil {
+			return err
+		}
+		return tx.Preload("SongIDs", orderedSongRefs).First(&result.Favorite, "id = ?", fav.ID).Error
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
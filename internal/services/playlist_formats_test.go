@@ -0,0 +1,138 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var sampleTracks = []playlistTrack{
+	{Title: "晴天", Creator: "周杰伦", Duration: 269, Image: "https://i0.hdslb.com/a.jpg", Location: "https://www.bilibili.com/video/BV1xx411c7mD?p=2"},
+	{Title: "No Creator", Duration: 61.5, Location: "https://www.bilibili.com/video/BV1yy411c7mE"},
+}
+
+func TestPlaylistRoundTrip(t *testing.T) {
+	encoders := map[string]func(string, []playlistTrack) (string, error){
+		playlistFormatM3U8: func(title string, tracks []playlistTrack) (string, error) { return encodeM3U8(title, tracks), nil },
+		playlistFormatXSPF: encodeXSPF,
+		playlistFormatJSPF: encodeJSPF,
+	}
+	decoders := map[string]func(string) (string, []playlistTrack, error){
+		playlistFormatM3U8: func(content string) (string, []playlistTrack, error) {
+			title, tracks := decodeM3U8(content)
+			return title, tracks, nil
+		},
+		playlistFormatXSPF: decodeXSPF,
+		playlistFormatJSPF: decodeJSPF,
+	}
+	for format, encode := range encoders {
+		t.Run(format, func(t *testing.T) {
+			content, err := encode("我的歌单", sampleTracks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := detectPlaylistFormat(content); got != format {
+				t.Fatalf("detectPlaylistFormat = %s, want %s", got, format)
+			}
+			title, tracks, err := decoders[format](content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if title != "我的歌单" {
+				t.Fatalf("title = %q", title)
+			}
+			want := append([]playlistTrack(nil), sampleTracks...)
+			if format == playlistFormatM3U8 {
+				// M3U8 只保存整数秒
+				want[1].Duration = 62
+			}
+			if !reflect.DeepEqual(tracks, want) {
+				t.Fatalf("tracks = %+v\nwant %+v", tracks, want)
+			}
+		})
+	}
+}
+
+func TestDecodeM3U8WithoutExtInfo(t *testing.T) {
+	title, tracks := decodeM3U8("\ufeff# comment\n\n/music/a.m4s\nhttps://www.bilibili.com/video/BV1xx411c7mD\n")
+	if title != "" || len(tracks) != 2 {
+		t.Fatalf("title = %q, tracks = %+v", title, tracks)
+	}
+	if tracks[0].Location != "/music/a.m4s" || tracks[0].Title != "" {
+		t.Fatalf("track 0 = %+v", tracks[0])
+	}
+}
+
+func TestDecodeJSPFBareLocation(t *testing.T) {
+	_, tracks, err := decodeJSPF(`{"playlist":{"track":[{"location":"file:///a.m4s","duration":1500.5}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 1 || tracks[0].Location != "file:///a.m4s" || tracks[0].Duration != 1.5005 {
+		t.Fatalf("tracks = %+v", tracks)
+	}
+}
+
+func TestTrackBiliRef(t *testing.T) {
+	cases := []struct {
+		location string
+		bvid     string
+		page     int
+	}{
+		{"https://www.bilibili.com/video/BV1xx411c7mD", "BV1xx411c7mD", 1},
+		{"https://www.bilibili.com/video/BV1xx411c7mD?p=3", "BV1xx411c7mD", 3},
+		{"https://www.bilibili.com/video/BV1xx411c7mD?p=0", "BV1xx411c7mD", 1},
+		{"/downloads/晴天-BV1xx411c7mD-P4.m4a", "BV1xx411c7mD", 4},
+		{"file:///downloads/BV1xx411c7mD.m4a", "BV1xx411c7mD", 1},
+		{"/music/a.m4s", "", 0},
+	}
+	for _, tc := range cases {
+		bvid, page := trackBiliRef(tc.location)
+		if bvid != tc.bvid || page != tc.page {
+			t.Errorf("trackBiliRef(%q) = %s, %d, want %s, %d", tc.location, bvid, page, tc.bvid, tc.page)
+		}
+	}
+}
+
+func TestEncodeM3U8SkipsTracksWithoutLocation(t *testing.T) {
+	tracks := []playlistTrack{
+		{Title: "本地缺失", Creator: "X"},
+		sampleTracks[0],
+		{Title: "Also Missing", Image: "https://i0.hdslb.com/b.jpg"},
+		sampleTracks[1],
+	}
+	content := encodeM3U8("", tracks)
+	if strings.Contains(content, "\n\n") || strings.Contains(content, "Missing") {
+		t.Fatalf("encoded = %q", content)
+	}
+	_, got := decodeM3U8(content)
+	want := []playlistTrack{sampleTracks[0], sampleTracks[1]}
+	want[1].Duration = 62
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tracks = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestXSPFDurations(t *testing.T) {
+	_, tracks, err := decodeXSPF(`<?xml version="1.0"?><playlist version="1" xmlns="http://xspf.org/ns/0/"><trackList>
+		<track><location>https://a</location><duration>1500.5</duration></track>
+		<track><location>https://b</location><duration> 2000 </duration></track>
+		<track><location>https://c</location></track>
+	</trackList></playlist>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []float64{tracks[0].Duration, tracks[1].Duration, tracks[2].Duration}
+	if !reflect.DeepEqual(got, []float64{1.5005, 2, 0}) {
+		t.Fatalf("durations = %v", got)
+	}
+
+	// 长时长不能写成科学计数法
+	content, err := encodeXSPF("", []playlistTrack{{Location: "https://a", Duration: 3600.25}, {Location: "https://b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, "<duration>3600250</duration>") || strings.Count(content, "<duration>") != 1 {
+		t.Fatalf("encoded = %s", content)
+	}
+}
@@ -0,0 +1,612 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"gorm.io/gorm"
+)
+
+// ===== Playback queue =====
+
+const (
+	currentQueueID uint = 1 // playlists 表中 ID=1 为当前播放队列
+
+	repeatOff = "off"
+	repeatAll = "all"
+	repeatOne = "one"
+
+	// queueChangedEvent is emitted with the new QueueState after every change.
+	queueChangedEvent = "queue:changed"
+)
+
+// errQueueUnchanged rolls back a queue mutation that changed nothing.
+var errQueueUnchanged = errors.New("queue unchanged")
+
+// QueueState is the full state of a playback queue.
+type QueueState struct {
+	Entries      []models.QueueEntry `json:"entries"`
+	CurrentIndex int                 `json:"currentIndex"`
+	RepeatMode   string              `json:"repeatMode"`
+	Shuffled     bool                `json:"shuffled"`
+	ShuffleSeed  int64               `json:"shuffleSeed"`
+}
+
+// queueWork is a queue loaded inside a transaction for mutation.
+type queueWork struct {
+	playlist models.Playlist
+	entries  []models.QueueEntry // 按 Position 排序
+}
+
+func (w *queueWork) current() *models.QueueEntry {
+	if w.playlist.CurrentIndex < 0 || w.playlist.CurrentIndex >= len(w.entries) {
+		return nil
+	}
+	return &w.entries[w.playlist.CurrentIndex]
+}
+
+func (w *queueWork) state() QueueState {
+	mode := w.playlist.RepeatMode
+	if mode == "" {
+		mode = repeatAll
+	}
+	entries := w.entries
+	if entries == nil {
+		entries = []models.QueueEntry{}
+	}
+	return QueueState{
+		Entries:      entries,
+		CurrentIndex: w.playlist.CurrentIndex,
+		RepeatMode:   mode,
+		Shuffled:     w.playlist.Shuffled,
+		ShuffleSeed:  w.playlist.ShuffleSeed,
+	}
+}
+
+func loadQueue(tx *gorm.DB, playlistID uint) (*queueWork, error) {
+	w := &queueWork{}
+	if err := tx.First(&w.playlist, playlistID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		w.playlist = models.Playlist{ID: playlistID, RepeatMode: repeatAll}
+	}
+	if err := tx.Where("playlist_id = ?", playlistID).Order("position").Find(&w.entries).Error; err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// saveQueue writes positions back and removes entries no longer in the queue.
+func saveQueue(tx *gorm.DB, w *queueWork) error {
+	keep := make([]uint, 0, len(w.entries))
+	for i := range w.entries {
+		w.entries[i].PlaylistID = w.playlist.ID
+		w.entries[i].Position = i
+		if w.entries[i].ID != 0 {
+			keep = append(keep, w.entries[i].ID)
+		}
+	}
+	del := tx.Where("playlist_id = ?", w.playlist.ID)
+	if len(keep) > 0 {
+		del = del.Where("id NOT IN ?", keep)
+	}
+	if err := del.Delete(&models.QueueEntry{}).Error; err != nil {
+		return err
+	}
+	for i := range w.entries {
+		if err := tx.Save(&w.entries[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(w.entries) == 0 {
+		w.playlist.CurrentIndex = 0
+	} else if w.playlist.CurrentIndex >= len(w.entries) {
+		w.playlist.CurrentIndex = len(w.entries) - 1
+	} else if w.playlist.CurrentIndex < 0 {
+		w.playlist.CurrentIndex = 0
+	}
+	w.playlist.Queue = ""
+	w.playlist.UpdatedAt = time.Now()
+	return tx.Save(&w.playlist).Error
+}
+
+// mutateQueue runs fn on the current queue in a transaction, persists it and emits a change event.
+func (s *Service) mutateQueue(fn func(tx *gorm.DB, w *queueWork) error) (QueueState, error) {
+	var st QueueState
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		w, err := loadQueue(tx, currentQueueID)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx, w); err != nil {
+			return err
+		}
+		if err := saveQueue(tx, w); err != nil {
+			return err
+		}
+		st = w.state()
+		return nil
+	})
+	if err != nil {
+		return st, err
+	}
+	if s.appCtx != nil {
+		runtime.EventsEmit(s.appCtx, queueChangedEvent, st)
+	}
+	return st, nil
+}
+
+// emitQueueChanged reloads the queue and emits a change event, for writes to
+// queue_entries made outside mutateQueue.
+func (s *Service) emitQueueChanged() {
+	if s.appCtx == nil {
+		return
+	}
+	st, err := s.GetQueue()
+	if err != nil {
+		fmt.Printf("[Queue] 重新加载队列失败: %v\n", err)
+		return
+	}
+	runtime.EventsEmit(s.appCtx, queueChangedEvent, st)
+}
+
+// GetQueue returns the current playback queue.
+func (s *Service) GetQueue() (QueueState, error) {
+	w, err := loadQueue(s.db, currentQueueID)
+	if err != nil {
+		return QueueState{}, err
+	}
+	return w.state(), nil
+}
+
+func newQueueEntries(songIDs []string, favoriteID, addedBy string) []models.QueueEntry {
+	if addedBy == "" {
+		addedBy = "user"
+	}
+	now := time.Now()
+	out := make([]models.QueueEntry, 0, len(songIDs))
+	for _, id := range songIDs {
+		if id == "" {
+			continue
+		}
+		out = append(out, models.QueueEntry{SongID: id, FavoriteID: favoriteID, AddedBy: addedBy, AddedAt: now})
+	}
+	return out
+}
+
+// nextOriginalPosition returns the original-order slot after every existing entry.
+func (w *queueWork) nextOriginalPosition() int {
+	next := 0
+	for _, e := range w.entries {
+		if e.OriginalPosition >= next {
+			next = e.OriginalPosition + 1
+		}
+	}
+	return next
+}
+
+// QueueAppend adds songs to the end of the queue.
+func (s *Service) QueueAppend(songIDs []string, favoriteID, addedBy string) (QueueState, error) {
+	return s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		added := newQueueEntries(songIDs, favoriteID, addedBy)
+		base := w.nextOriginalPosition()
+		for i := range added {
+			added[i].OriginalPosition = base + i
+		}
+		w.entries = append(w.entries, added...)
+		return nil
+	})
+}
+
+// QueueInsertNext inserts songs right after the current entry.
+func (s *Service) QueueInsertNext(songIDs []string, favoriteID, addedBy string) (QueueState, error) {
+	return s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		added := newQueueEntries(songIDs, favoriteID, addedBy)
+		at := 0
+		if len(w.entries) > 0 {
+			at = w.playlist.CurrentIndex + 1
+		}
+
+		// 原始顺序同样插在当前歌曲之后，取消随机后仍是“下一首”
+		origin := w.nextOriginalPosition()
+		if cur := w.current(); cur != nil {
+			origin = cur.OriginalPosition + 1
+			for i := range w.entries {
+				if w.entries[i].OriginalPosition >= origin {
+					w.entries[i].OriginalPosition += len(added)
+				}
+			}
+		}
+		for i := range added {
+			added[i].OriginalPosition = origin + i
+		}
+
+		entries := make([]models.QueueEntry, 0, len(w.entries)+len(added))
+		entries = append(entries, w.entries[:at]...)
+		entries = append(entries, added...)
+		entries = append(entries, w.entries[at:]...)
+		w.entries = entries
+		return nil
+	})
+}
+
+// QueueMove moves the entry at index from to index to, keeping the current song current.
+func (s *Service) QueueMove(from, to int) (QueueState, error) {
+	return s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		n := len(w.entries)
+		if from < 0 || from >= n || to < 0 || to >= n {
+			return fmt.Errorf("队列位置越界: %d -> %d", from, to)
+		}
+		cur := w.current()
+		var curID uint
+		if cur != nil {
+			curID = cur.ID
+		}
+		moved := w.entries[from]
+		w.entries = append(w.entries[:from], w.entries[from+1:]...)
+		w.entries = append(w.entries[:to], append([]models.QueueEntry{moved}, w.entries[to:]...)...)
+		if !w.playlist.Shuffled {
+			for i := range w.entries {
+				w.entries[i].OriginalPosition = i
+			}
+		}
+		w.playlist.CurrentIndex = indexOfEntry(w.entries, curID)
+		return nil
+	})
+}
+
+// QueueRemove removes the entry at index.
+func (s *Service) QueueRemove(index int) (QueueState, error) {
+	return s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		if index < 0 || index >= len(w.entries) {
+			return fmt.Errorf("队列位置越界: %d", index)
+		}
+		w.entries = append(w.entries[:index], w.entries[index+1:]...)
+		if index < w.playlist.CurrentIndex {
+			w.playlist.CurrentIndex--
+		}
+		return nil
+	})
+}
+
+// QueueClear removes every entry and resets shuffle.
+func (s *Service) QueueClear() (QueueState, error) {
+	return s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		w.entries = nil
+		w.playlist.CurrentIndex = 0
+		w.playlist.Shuffled = false
+		w.playlist.ShuffleSeed = 0
+		return nil
+	})
+}
+
+// QueueShuffle shuffles the queue with the given seed (0 picks a random one).
+// The same seed over the same entries always yields the same order; the current
+// song moves to the front so playback is not interrupted.
+func (s *Service) QueueShuffle(seed int64) (QueueState, error) {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		var curID uint
+		if cur := w.current(); cur != nil {
+			curID = cur.ID
+		}
+		sort.SliceStable(w.entries, func(i, j int) bool {
+			return w.entries[i].OriginalPosition < w.entries[j].OriginalPosition
+		})
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(w.entries), func(i, j int) {
+			w.entries[i], w.entries[j] = w.entries[j], w.entries[i]
+		})
+		if i := indexOfEntry(w.entries, curID); i > 0 {
+			cur := w.entries[i]
+			copy(w.entries[1:i+1], w.entries[:i])
+			w.entries[0] = cur
+		}
+		w.playlist.CurrentIndex = 0
+		w.playlist.Shuffled = true
+		w.playlist.ShuffleSeed = seed
+		return nil
+	})
+}
+
+// QueueUnshuffle restores the original order.
+func (s *Service) QueueUnshuffle() (QueueState, error) {
+	return s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		var curID uint
+		if cur := w.current(); cur != nil {
+			curID = cur.ID
+		}
+		sort.SliceStable(w.entries, func(i, j int) bool {
+			return w.entries[i].OriginalPosition < w.entries[j].OriginalPosition
+		})
+		w.playlist.CurrentIndex = indexOfEntry(w.entries, curID)
+		w.playlist.Shuffled = false
+		return nil
+	})
+}
+
+// QueueSetRepeatMode sets the repeat mode: off, all or one.
+func (s *Service) QueueSetRepeatMode(mode string) (QueueState, error) {
+	switch mode {
+	case repeatOff, repeatAll, repeatOne:
+	default:
+		return QueueState{}, fmt.Errorf("未知的循环模式: %s", mode)
+	}
+	return s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		w.playlist.RepeatMode = mode
+		return nil
+	})
+}
+
+// QueueSetCurrent jumps to index and records it in the play history.
+func (s *Service) QueueSetCurrent(index int) (QueueState, error) {
+	return s.mutateQueue(func(tx *gorm.DB, w *queueWork) error {
+		if index < 0 || index >= len(w.entries) {
+			return fmt.Errorf("队列位置越界: %d", index)
+		}
+		w.playlist.CurrentIndex = index
+		return recordQueueHistory(tx, w)
+	})
+}
+
+// QueueNext advances according to the repeat mode. At the end of the queue with
+// repeat off, the state is returned unchanged.
+func (s *Service) QueueNext() (QueueState, error) {
+	return s.mutateQueue(func(tx *gorm.DB, w *queueWork) error {
+		n := len(w.entries)
+		if n == 0 {
+			return nil
+		}
+		switch w.playlist.RepeatMode {
+		case repeatOne:
+		case repeatOff:
+			if w.playlist.CurrentIndex+1 >= n {
+				return nil
+			}
+			w.playlist.CurrentIndex++
+		default:
+			w.playlist.CurrentIndex = (w.playlist.CurrentIndex + 1) % n
+		}
+		return recordQueueHistory(tx, w)
+	})
+}
+
+// QueuePrevious goes back one entry (wrapping unless repeat is off).
+func (s *Service) QueuePrevious() (QueueState, error) {
+	return s.mutateQueue(func(tx *gorm.DB, w *queueWork) error {
+		n := len(w.entries)
+		if n == 0 {
+			return nil
+		}
+		if w.playlist.CurrentIndex > 0 {
+			w.playlist.CurrentIndex--
+		} else if w.playlist.RepeatMode != repeatOff {
+			w.playlist.CurrentIndex = n - 1
+		}
+		return recordQueueHistory(tx, w)
+	})
+}
+
+// GetQueueHistory returns recently played queue items, newest first.
+func (s *Service) GetQueueHistory(limit int) ([]models.QueueHistoryEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var out []models.QueueHistoryEntry
+	if err := s.db.Where("playlist_id = ?", currentQueueID).Order("played_at DESC, id DESC").Limit(limit).Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func recordQueueHistory(tx *gorm.DB, w *queueWork) error {
+	cur := w.current()
+	if cur == nil {
+		return nil
+	}
+	return tx.Create(&models.QueueHistoryEntry{
+		PlaylistID: w.playlist.ID,
+		SongID:     cur.SongID,
+		FavoriteID: cur.FavoriteID,
+		PlayedAt:   time.Now(),
+	}).Error
+}
+
+func indexOfEntry(entries []models.QueueEntry, id uint) int {
+	if id == 0 {
+		return 0
+	}
+	for i, e := range entries {
+		if e.ID == id {
+			return i
+		}
+	}
+	return 0
+}
+
+// queueSongIDsJSON renders the queue as the legacy JSON array of song IDs.
+func queueSongIDsJSON(entries []models.QueueEntry) string {
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.SongID)
+	}
+	data, _ := json.Marshal(ids)
+	return string(data)
+}
+
+// migrateLegacyQueue moves a queue saved as a JSON string by older versions into queue_entries.
+func (s *Service) migrateLegacyQueue() error {
+	var playlist models.Playlist
+	if err := s.db.First(&playlist, currentQueueID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if playlist.Queue == "" {
+		return nil
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(playlist.Queue), &ids); err != nil {
+		return fmt.Errorf("parse legacy queue: %w", err)
+	}
+	_, err := s.mutateQueue(func(_ *gorm.DB, w *queueWork) error {
+		if len(w.entries) > 0 {
+			return nil
+		}
+		w.entries = newQueueEntries(ids, "", "restore")
+		for i := range w.entries {
+			w.entries[i].OriginalPosition = i
+		}
+		return nil
+	})
+	return err
+}
+
+// QueueSetProgress remembers the playback progress of the entry at index.
+// Called periodically by the player, so no change event is emitted.
+func (s *Service) QueueSetProgress(index int, seconds float64) error {
+	if seconds < 0 {
+		seconds = 0
+	}
+	return s.db.Model(&models.QueueEntry{}).
+		Where("playlist_id = ? AND position = ?", currentQueueID, index).
+		Update("progress_seconds", seconds).Error
+}
+
+// ===== Queue snapshots =====
+
+// QueueSnapshot summarises a saved queue.
+type QueueSnapshot struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	SongCount    int64     `json:"songCount"`
+	CurrentIndex int       `json:"currentIndex"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// copyQueueEntries clones entries for another playlist row.
+func copyQueueEntries(entries []models.QueueEntry) []models.QueueEntry {
+	out := make([]models.QueueEntry, len(entries))
+	for i, e := range entries {
+		e.ID = 0
+		out[i] = e
+	}
+	return out
+}
+
+// SaveQueueSnapshot saves the current queue under name, replacing a snapshot with the same name.
+func (s *Service) SaveQueueSnapshot(name string) (QueueSnapshot, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return QueueSnapshot{}, fmt.Errorf("快照名称不能为空")
+	}
+	var out QueueSnapshot
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		current, err := loadQueue(tx, currentQueueID)
+		if err != nil {
+			return err
+		}
+		// 确保当前队列占用 ID=1，新快照不会抢占该 ID
+		if err := tx.Save(&current.playlist).Error; err != nil {
+			return err
+		}
+
+		var snapshot models.Playlist
+		if err := tx.Where("name = ? AND id <> ?", name, currentQueueID).First(&snapshot).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			snapshot = models.Playlist{Name: name}
+		}
+		id := snapshot.ID
+		snapshot = current.playlist
+		snapshot.ID = id
+		snapshot.Name = name
+		snapshot.Queue = ""
+		snapshot.UpdatedAt = time.Now()
+		if err := tx.Save(&snapshot).Error; err != nil {
+			return err
+		}
+
+		w := &queueWork{playlist: snapshot, entries: copyQueueEntries(current.entries)}
+		if err := saveQueue(tx, w); err != nil {
+			return err
+		}
+		out = QueueSnapshot{
+			ID:           w.playlist.ID,
+			Name:         name,
+			SongCount:    int64(len(w.entries)),
+			CurrentIndex: w.playlist.CurrentIndex,
+			UpdatedAt:    w.playlist.UpdatedAt,
+		}
+		return nil
+	})
+	return out, err
+}
+
+// ListQueueSnapshots returns saved queues, most recently updated first.
+func (s *Service) ListQueueSnapshots() ([]QueueSnapshot, error) {
+	var out []QueueSnapshot
+	if err := s.db.Table("playlists").
+		Select("playlists.id, playlists.name, playlists.current_index, playlists.updated_at, "+
+			"(SELECT COUNT(*) FROM queue_entries WHERE queue_entries.playlist_id = playlists.id) AS song_count").
+		Where("playlists.id <> ?", currentQueueID).
+		Order("playlists.updated_at DESC").
+		Scan(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RestoreQueueSnapshot replaces the current queue with a saved snapshot, including
+// its position, repeat/shuffle state and per-song progress.
+func (s *Service) RestoreQueueSnapshot(id uint) (QueueState, error) {
+	if id == currentQueueID {
+		return QueueState{}, fmt.Errorf("无效的快照 ID: %d", id)
+	}
+	return s.mutateQueue(func(tx *gorm.DB, w *queueWork) error {
+		var snapshot models.Playlist
+		if err := tx.First(&snapshot, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("快照不存在: %d", id)
+			}
+			return err
+		}
+		saved, err := loadQueue(tx, id)
+		if err != nil {
+			return err
+		}
+		w.entries = copyQueueEntries(saved.entries)
+		w.playlist.CurrentIndex = snapshot.CurrentIndex
+		w.playlist.RepeatMode = snapshot.RepeatMode
+		w.playlist.Shuffled = snapshot.Shuffled
+		w.playlist.ShuffleSeed = snapshot.ShuffleSeed
+		return nil
+	})
+}
+
+// DeleteQueueSnapshot removes a saved snapshot and its entries.
+func (s *Service) DeleteQueueSnapshot(id uint) error {
+	if id == currentQueueID {
+		return fmt.Errorf("无效的快照 ID: %d", id)
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("playlist_id = ?", id).Delete(&models.QueueEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Playlist{}, id).Error
+	})
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ===== Resume position for long songs =====
+
+const (
+	// resumeMinDurationKey 设置项：时长不低于该值（秒）的歌曲才记录续播位置
+	resumeMinDurationKey     = "resumeMinDurationSeconds"
+	defaultResumeMinDuration = 600.0
+
+	// 距离结尾不足该秒数视为已播完
+	resumeEndMargin = 10.0
+)
+
+// getConfigFloat reads a numeric value from the config map.
+func getConfigFloat(m map[string]any, key string, defaultValue float64) float64 {
+	switch v := m[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return defaultValue
+}
+
+// GetResumeMinDuration returns the minimum song duration (seconds) for which resume applies.
+func (s *Service) GetResumeMinDuration() (float64, error) {
+	setting, err := s.GetPlayerSetting()
+	if err != nil {
+		return defaultResumeMinDuration, err
+	}
+	return getConfigFloat(setting.Config, resumeMinDurationKey, defaultResumeMinDuration), nil
+}
+
+// SetResumeMinDuration sets the minimum song duration (seconds) for which resume applies.
+func (s *Service) SetResumeMinDuration(seconds float64) error {
+	if seconds < 0 {
+		return fmt.Errorf("最短时长不能为负数")
+	}
+	return s.SavePlayerSetting(models.PlayerSetting{Config: map[string]any{resumeMinDurationKey: seconds}})
+}
+
+// SaveResumePosition records the playback position of a song. The player calls it
+// periodically; songs shorter than the minimum duration are ignored, and a position
+// at the very end clears the record.
+func (s *Service) SaveResumePosition(songID string, position, duration float64) error {
+	if songID == "" {
+		return fmt.Errorf("songID 不能为空")
+	}
+	minDuration, err := s.GetResumeMinDuration()
+	if err != nil {
+		return err
+	}
+	if duration <= 0 || duration < minDuration {
+		return nil
+	}
+	if position <= 0 || position >= duration-resumeEndMargin {
+		return s.ClearResumePosition(songID)
+	}
+	rec := models.ResumePosition{
+		SongID:          songID,
+		PositionSeconds: position,
+		DurationSeconds: duration,
+		UpdatedAt:       time.Now(),
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "song_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position_seconds", "duration_seconds", "updated_at"}),
+	}).Create(&rec).Error
+}
+
+// GetResumePosition returns the saved position of a song in seconds, or 0 when
+// playback should start from the beginning.
+func (s *Service) GetResumePosition(songID string) (float64, error) {
+	var rec models.ResumePosition
+	if err := s.db.First(&rec, "song_id = ?", songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	minDuration, err := s.GetResumeMinDuration()
+	if err != nil {
+		return 0, err
+	}
+	if rec.DurationSeconds < minDuration {
+		return 0, nil
+	}
+	return rec.PositionSeconds, nil
+}
+
+// ClearResumePosition forgets the saved position of a song.
+func (s *Service) ClearResumePosition(songID string) error {
+	return s.db.Delete(&models.ResumePosition{}, "song_id = ?", songID).Error
+}
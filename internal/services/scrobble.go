@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"half-beat-player/internal/models"
+	"half-beat-player/internal/scrobbler"
+)
+
+// ===== Scrobbling =====
+
+const (
+	scrobbleProtocolListenBrainz = "listenbrainz"
+	scrobbleProtocolLastFM       = "lastfm"
+
+	// Last.fm 规则：至少听 30 秒，且听完一半或满 4 分钟才记为一次收听
+	scrobbleMinSeconds  = 30
+	scrobbleFullSeconds = 240
+)
+
+// ScrobbleConfig is stored under the "scrobble" settings key. Secret fields are encrypted at rest.
+type ScrobbleConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Protocol   string `json:"protocol"`   // "listenbrainz" 或 "lastfm"
+	ServerURL  string `json:"serverUrl"`  // 为空时使用官方地址，可填自建 Maloja 等兼容服务
+	Token      string `json:"token"`      // ListenBrainz user token
+	APIKey     string `json:"apiKey"`     // Last.fm API key
+	APISecret  string `json:"apiSecret"`  // Last.fm shared secret
+	SessionKey string `json:"sessionKey"` // Last.fm session key（由 ConnectLastFM 获取）
+}
+
+// GetScrobbleConfig returns the scrobble configuration with secrets decrypted.
+func (s *Service) GetScrobbleConfig() (ScrobbleConfig, error) {
+	setting, err := s.GetPlayerSetting()
+	if err != nil {
+		return ScrobbleConfig{}, err
+	}
+	var cfg ScrobbleConfig
+	if raw, ok := setting.Config["scrobble"]; ok && raw != nil {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return cfg, err
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("parse scrobble config: %w", err)
+		}
+	}
+	for _, field := range []*string{&cfg.Token, &cfg.APISecret, &cfg.SessionKey} {
+		plain, err := s.secrets.Open(*field)
+		if err != nil {
+			return cfg, fmt.Errorf("decrypt scrobble config: %w", err)
+		}
+		*field = plain
+	}
+	return cfg, nil
+}
+
+// SaveScrobbleConfig stores the configuration and reconfigures the scrobbler.
+func (s *Service) SaveScrobbleConfig(cfg ScrobbleConfig) error {
+	switch cfg.Protocol {
+	case scrobbleProtocolListenBrainz, scrobbleProtocolLastFM:
+	case "":
+		cfg.Protocol = scrobbleProtocolListenBrainz
+	default:
+		return fmt.Errorf("未知的 scrobble 协议: %s", cfg.Protocol)
+	}
+
+	stored := cfg
+	for _, field := range []*string{&stored.Token, &stored.APISecret, &stored.SessionKey} {
+		sealed, err := s.secrets.Seal(*field)
+		if err != nil {
+			return err
+		}
+		*field = sealed
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	var asMap map[string]any
+	if err := json.Unmarshal(data, &asMap); err != nil {
+		return err
+	}
+	if err := s.savePlayerSetting(models.PlayerSetting{Config: map[string]any{"scrobble": asMap}}); err != nil {
+		return err
+	}
+
+	s.scrobbler.SetClient(s.newScrobbleClient(cfg))
+	return nil
+}
+
+// ConnectLastFM obtains a Last.fm session key with the account password and saves it.
+// The password itself is not stored.
+func (s *Service) ConnectLastFM(username, password string) error {
+	cfg, err := s.GetScrobbleConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.APIKey == "" || cfg.APISecret == "" {
+		return fmt.Errorf("请先填写 Last.fm API key 与 secret")
+	}
+	client := &scrobbler.LastFM{BaseURL: cfg.ServerURL, APIKey: cfg.APIKey, APISecret: cfg.APISecret, HTTPClient: s.httpClient}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	key, err := client.MobileSession(ctx, username, password)
+	if err != nil {
+		return err
+	}
+
+	cfg.Protocol = scrobbleProtocolLastFM
+	cfg.SessionKey = key
+	return s.SaveScrobbleConfig(cfg)
+}
+
+// NotifyNowPlaying tells the scrobble server which song just started.
+func (s *Service) NotifyNowPlaying(songID string) error {
+	if !s.scrobbler.Enabled() {
+		return nil
+	}
+	var song models.Song
+	if err := s.db.First(&song, "id = ?", songID).Error; err != nil {
+		return fmt.Errorf("查询歌曲失败: %w", err)
+	}
+	s.scrobbler.NowPlaying(scrobbleTrack(song, 0, time.Now()))
+	return nil
+}
+
+// GetScrobbleQueueSize returns how many listens are waiting to be submitted.
+func (s *Service) GetScrobbleQueueSize() (int64, error) {
+	return s.scrobbler.Pending()
+}
+
+// FlushScrobbleQueue submits all queued listens now, ignoring retry backoff.
+func (s *Service) FlushScrobbleQueue() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return s.scrobbler.Flush(ctx, true)
+}
+
+// applyScrobbleConfig configures the scrobbler from stored settings.
+func (s *Service) applyScrobbleConfig() {
+	cfg, err := s.GetScrobbleConfig()
+	if err != nil {
+		log.Printf("load scrobble config: %v", err)
+		return
+	}
+	s.scrobbler.SetClient(s.newScrobbleClient(cfg))
+}
+
+func (s *Service) newScrobbleClient(cfg ScrobbleConfig) scrobbler.Client {
+	if !cfg.Enabled {
+		return nil
+	}
+	switch cfg.Protocol {
+	case scrobbleProtocolLastFM:
+		if cfg.APIKey == "" || cfg.SessionKey == "" {
+			return nil
+		}
+		return &scrobbler.LastFM{
+			BaseURL:    cfg.ServerURL,
+			APIKey:     cfg.APIKey,
+			APISecret:  cfg.APISecret,
+			SessionKey: cfg.SessionKey,
+			HTTPClient: s.httpClient,
+		}
+	default:
+		if cfg.Token == "" {
+			return nil
+		}
+		return &scrobbler.ListenBrainz{BaseURL: cfg.ServerURL, Token: cfg.Token, HTTPClient: s.httpClient}
+	}
+}
+
+// scrobblePlayEvent queues a finished play if it counts as a listen.
+func (s *Service) scrobblePlayEvent(event models.PlayEvent) {
+	if !s.scrobbler.Enabled() || event.ListenedSeconds < scrobbleMinSeconds {
+		return
+	}
+	halfway := event.DurationSeconds > 0 && event.ListenedSeconds >= event.DurationSeconds/2
+	if !event.Completed && !halfway && event.ListenedSeconds < scrobbleFullSeconds {
+		return
+	}
+	var song models.Song
+	if err := s.db.First(&song, "id = ?", event.SongID).Error; err != nil {
+		return
+	}
+	if err := s.scrobbler.Enqueue(scrobbleTrack(song, int(event.DurationSeconds), event.StartedAt)); err != nil {
+		log.Printf("queue scrobble: %v", err)
+	}
+}
+
+func scrobbleTrack(song models.Song, durationSec int, listenedAt time.Time) scrobbler.Track {
+	t := scrobbler.Track{
+		Artist:      song.Singer,
+		Title:       song.Name,
+		DurationSec: durationSec,
+		ListenedAt:  listenedAt,
+	}
+	if song.TotalPages > 1 {
+		t.Title = song.PageTitle
+		t.Album = song.VideoTitle
+		if t.Title == "" {
+			t.Title = song.Name
+		}
+	}
+	if song.BVID != "" {
+		page := song.PageNumber
+		if page <= 0 {
+			page = 1
+		}
+		t.OriginURL = fmt.Sprintf("https://www.bilibili.com/video/%s?p=%d", song.BVID, page)
+	}
+	return t
+}
@@ -2,15 +2,18 @@ package services
 
 import (
 	"context"
-	"fmt"
-	"net/url"
+	"log"
 	"path/filepath"
-	"net"
 	"net/http"
 	"net/http/cookiejar"
 	"os"
+	"sync"
 	"time"
 
+	"half-beat-player/internal/proxy"
+	"half-beat-player/internal/scrobbler"
+	"half-beat-player/internal/secrets"
+
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"gorm.io/gorm"
 )
@@ -18,31 +21,37 @@ import (
 // Service exposes backend operations to the Wails frontend.
 type Service struct {
 	db         *gorm.DB
-	cookieJar  http.CookieJar
+	cookieJar  *swappableJar
 	httpClient *http.Client
 	dataDir    string // 数据目录用于存储 cookie
 	appCtx     context.Context
+	secrets    *secrets.Box // 登录凭据加密
+	scrobbler  *scrobbler.Scrobbler
+
+	accountMu sync.Mutex // 串行化账号保存/切换，保证 cookie 与数据库状态一致
+	backupMu  sync.Mutex // 串行化数据库备份与恢复
+
+	transport     *tunableTransport // 随网络设置实时替换
+	downloadSlots *slotLimiter      // 限制同时下载数
+	settingsMu    sync.Mutex
+	settingsSubs  []settingsSubscriber
+
+	audioProxy *proxy.AudioProxy // 本地音频/图片代理，端口由设置决定
+	proxyMu    sync.Mutex
+	proxyErr   string // 最近一次启动失败的原因
 }
 
 func NewService(db *gorm.DB, dataDir string) *Service {
-    jar, _ := cookiejar.New(nil)
-
-    // 创建具有合理超时的 HTTP Transport
-    transport := &http.Transport{
-        DialContext: (&net.Dialer{
-            Timeout:   10 * time.Second, // 连接超时
-            KeepAlive: 30 * time.Second,
-        }).DialContext,
-        TLSHandshakeTimeout: 10 * time.Second,
-        IdleConnTimeout:     90 * time.Second,
-        MaxIdleConns:        100,
-        MaxIdleConnsPerHost: 10,
-    }
+    inner, _ := cookiejar.New(nil)
+    jar := &swappableJar{jar: inner}
+
+    // 创建具有合理超时的 HTTP Transport，超时与代理由设置实时调整
+    transport := &tunableTransport{}
+    transport.set(newTransport(10*time.Second, ""), 30*time.Second)
 
     client := &http.Client{
         Jar:       jar,
         Transport: transport,
-        Timeout:   30 * time.Second, // 默认请求超时
     }
 
     // 确保数据目录存在（跨平台用户级路径）
@@ -55,11 +64,33 @@ func NewService(db *gorm.DB, dataDir string) *Service {
         cookieJar:  jar,
         httpClient: client,
         dataDir:    dataDir,
+        secrets:    secrets.NewBox(secrets.DefaultKeyring(), secrets.DefaultPassphrase(), filepath.Join(dataDir, credentialSaltFile), filepath.Join(dataDir, credentialKeyFile)),
+        scrobbler:  scrobbler.New(db),
+
+        transport:     transport,
+        downloadSlots: newSlotLimiter(2),
+        audioProxy:    proxy.NewAudioProxy(defaultProxyPort, client, dataDir),
+    }
+
+    // 加密旧版本以明文保存的登录凭据
+    if err := service.encryptLoginSessions(); err != nil {
+        log.Printf("encrypt stored login sessions: %v", err)
     }
 
     // 在启动时尝试恢复之前的登录状态
     _ = service.restoreLogin()
 
+    service.applyScrobbleConfig()
+
+    // 网络、缓存、下载等子系统按设置配置，并在设置变化时实时更新
+    service.registerSettingsSubscribers()
+    service.reloadSettings()
+
+    // 旧版本以 JSON 字符串保存的播放队列迁移到 queue_entries
+    if err := service.migrateLegacyQueue(); err != nil {
+        log.Printf("migrate legacy queue: %v", err)
+    }
+
     return service
 }
 
@@ -72,12 +103,17 @@ func (s *Service) GetImageProxyURL(imageURL string) string {
 	if imageURL == "" {
 		return ""
 	}
-	// Use the same proxy port as audio (9999)
-	return fmt.Sprintf("http://127.0.0.1:9999/image?u=%s", url.QueryEscape(imageURL))
+	return s.audioProxy.GetImageProxyURL(imageURL)
 }
 
 func (s *Service) SetAppContext(ctx context.Context) {
 	s.appCtx = ctx
+	// 定期检查并刷新 B 站登录 cookie
+	s.startSessionRefresher(ctx)
+	s.startBackupScheduler(ctx)
+	s.startCachePruner(ctx)
+	// 后台提交 scrobble 队列
+	s.scrobbler.Start(ctx)
 }
 
 // 窗口控制方法
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"half-beat-player/internal/db"
+
+	"gorm.io/gorm"
+)
+
+// newTestService opens a migrated database in a temp directory. Credentials go to a
+// file keyring there so tests never touch the OS keyring.
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HALF_BEAT_KEYRING_FILE", filepath.Join(dir, "keyring.json"))
+	gdb, err := db.Open(filepath.Join(dir, "library.db"), func(gdb *gorm.DB) error { return db.Migrate(gdb, "") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewService(gdb, dir)
+}
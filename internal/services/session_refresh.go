@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ===== Cookie refresh =====
+// 参考 B 站 Web 端的 cookie 刷新流程：
+//   1. cookie/info 判断是否需要刷新
+//   2. 用公钥加密 "refresh_{timestamp}" 得到 correspondPath，访问 /correspond/1/{path} 取 refresh_csrf
+//   3. cookie/refresh 换取新 cookie 与新的 refresh_token
+//   4. confirm/refresh 使旧 refresh_token 失效
+
+const (
+	sessionCheckInterval = 6 * time.Hour
+	sessionCheckDelay    = 30 * time.Second // 启动后延迟检查，避免与首屏请求争抢
+)
+
+const biliCorrespondPublicKey = `-----BEGIN PUBLIC KEY-----
+MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQDLgd2OAkcGVtoE3ThUREbio0Eg
+Uc/prcajMKXvkCKFCWhJYJcLkcM2DKKcSeFpD/j6Boy538YXnR6VhcuUJOhH2x71
+nzPjfdTcqMz7djHum0qSZA0AyCBDABUqCrfNgCiJ00Ra7GmRj+YCK1NJEuewlb40
+JNrRuoEUXpabUzGB8QIDAQAB
+-----END PUBLIC KEY-----`
+
+var refreshCsrfRegexp = regexp.MustCompile(`<div id="1-name">([^<]+)</div>`)
+
+// startSessionRefresher periodically checks whether the saved sessions need refreshing.
+func (s *Service) startSessionRefresher(ctx context.Context) {
+	go func() {
+		timer := time.NewTimer(sessionCheckDelay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			if refreshed, err := s.RefreshAllLoginSessions(); err != nil {
+				log.Printf("session refresh check failed: %v", err)
+			} else if refreshed > 0 {
+				log.Printf("%d login session(s) refreshed", refreshed)
+			}
+			timer.Reset(sessionCheckInterval)
+		}
+	}()
+}
+
+// RefreshLoginSession checks cookie/info and runs the official refresh flow when Bilibili
+// asks for it. Returns true when new cookies were obtained and persisted.
+func (s *Service) RefreshLoginSession() (bool, error) {
+	targets, err := s.snapshotRefreshTargets(true)
+	if err != nil || len(targets) == 0 {
+		return false, err
+	}
+	if targets[0].err != nil {
+		return false, targets[0].err
+	}
+	return s.refreshSession(targets[0].session, targets[0].cookies)
+}
+
+// RefreshAllLoginSessions runs the refresh flow for every saved account, so that
+// switching to an account later does not hit expired cookies. Each account is
+// checked even when another fails; the count of refreshed sessions and the first
+// error are returned.
+func (s *Service) RefreshAllLoginSessions() (int, error) {
+	targets, err := s.snapshotRefreshTargets(false)
+	if err != nil {
+		return 0, err
+	}
+	refreshed := 0
+	var firstErr error
+	for _, t := range targets {
+		ok, err := false, t.err
+		if err == nil {
+			ok, err = s.refreshSession(t.session, t.cookies)
+		}
+		if err != nil {
+			log.Printf("session refresh for uid %d failed: %v", t.session.UID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if ok {
+			refreshed++
+		}
+	}
+	return refreshed, firstErr
+}
+
+// refreshTarget is a saved account captured under accountMu for a refresh that
+// runs without the lock.
+type refreshTarget struct {
+	session models.LoginSession // 已解密
+	cookies []sessionCookie
+	err     error // 解密失败
+}
+
+// snapshotRefreshTargets loads the saved accounts, or only the active one, with
+// their current cookies. The active account's cookies come from the shared jar.
+func (s *Service) snapshotRefreshTargets(activeOnly bool) ([]refreshTarget, error) {
+	s.accountMu.Lock()
+	defer s.accountMu.Unlock()
+
+	q := s.db.Order("active desc")
+	if activeOnly {
+		q = q.Where("active = ?", true)
+	}
+	var sessions []models.LoginSession
+	if err := q.Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("load login sessions from db: %w", err)
+	}
+	targets := make([]refreshTarget, len(sessions))
+	for i, session := range sessions {
+		t := refreshTarget{session: session}
+		if t.err = s.openSession(&t.session); t.err == nil {
+			t.cookies = sessionCookiesOf(t.session)
+			if session.Active {
+				t.cookies = collectSessionCookies(s.cookieJar, nil, t.cookies)
+			}
+		}
+		targets[i] = t
+	}
+	return targets, nil
+}
+
+// refreshSession runs the refresh flow for one account with its own cookie jar.
+// The network round-trips run without accountMu; only persisting takes the lock.
+func (s *Service) refreshSession(session models.LoginSession, previous []sessionCookie) (bool, error) {
+	jar := newSessionJar(previous)
+	if jarCookieValue(jar, "SESSDATA") == "" {
+		return false, nil
+	}
+	csrf := jarCookieValue(jar, "bili_jct")
+	if csrf == "" || session.RefreshToken == "" {
+		// 旧版本只保存了 SESSDATA，无法走刷新流程
+		return false, fmt.Errorf("账号 %s 缺少刷新凭据，请重新登录", session.Username)
+	}
+	client := *s.httpClient
+	client.Jar = jar
+
+	refreshed, newToken, issued, err := s.runRefreshFlow(&client, csrf, session.RefreshToken)
+	if err != nil || !refreshed {
+		return false, err
+	}
+
+	// 新 cookie 先持久化，避免确认失败时丢失新会话
+	saved, err := s.storeRefreshedSession(session, jar, collectSessionCookies(jar, issued, previous), newToken)
+	if err != nil || !saved {
+		return false, err
+	}
+
+	if err := s.confirmRefresh(&client, jarCookieValue(jar, "bili_jct"), session.RefreshToken); err != nil {
+		// 旧 refresh_token 未失效不影响新会话使用
+		log.Printf("confirm session refresh failed: %v", err)
+	}
+	return true, nil
+}
+
+// storeRefreshedSession writes refreshed credentials back to the account's row and
+// swaps the shared jar to them when it is still the current account. Nothing is
+// written when the account was removed or logged in again during the refresh.
+func (s *Service) storeRefreshedSession(session models.LoginSession, jar http.CookieJar, cookies []sessionCookie, newToken string) (bool, error) {
+	s.accountMu.Lock()
+	defer s.accountMu.Unlock()
+
+	var current models.LoginSession
+	if err := s.db.First(&current, session.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("session refresh for uid %d discarded: account removed", session.UID)
+			return false, nil
+		}
+		return false, fmt.Errorf("load login session from db: %w", err)
+	}
+	if err := s.openSession(&current); err != nil {
+		return false, err
+	}
+	if current.RefreshToken != session.RefreshToken {
+		log.Printf("session refresh for uid %d discarded: account logged in again", session.UID)
+		return false, nil
+	}
+
+	cookiesJSON, err := json.Marshal(cookies)
+	if err != nil {
+		return false, fmt.Errorf("encode cookie payload: %w", err)
+	}
+	updated := models.LoginSession{
+		Sessdata:     jarCookieValue(jar, "SESSDATA"),
+		Cookies:      string(cookiesJSON),
+		RefreshToken: newToken,
+	}
+	if err := s.sealSession(&updated); err != nil {
+		return false, err
+	}
+	if err := s.db.Model(&models.LoginSession{}).Where("id = ?", session.ID).Updates(map[string]any{
+		"sessdata":      updated.Sessdata,
+		"cookies":       updated.Cookies,
+		"refresh_token": updated.RefreshToken,
+	}).Error; err != nil {
+		return false, fmt.Errorf("save login session to db: %w", err)
+	}
+	if current.Active {
+		s.cookieJar.swap(jar)
+	}
+	return true, nil
+}
+
+// runRefreshFlow asks cookie/info whether a refresh is due and, if so, exchanges the
+// refresh token for new cookies, which client's jar receives.
+func (s *Service) runRefreshFlow(client *http.Client, csrf, refreshToken string) (bool, string, []*http.Cookie, error) {
+	needRefresh, timestamp, err := s.checkCookieInfo(client, csrf)
+	if err != nil || !needRefresh {
+		return false, "", nil, err
+	}
+	refreshCsrf, err := s.fetchRefreshCsrf(client, timestamp)
+	if err != nil {
+		return false, "", nil, err
+	}
+	newToken, issued, err := s.refreshCookie(client, csrf, refreshCsrf, refreshToken)
+	if err != nil {
+		return false, "", nil, err
+	}
+	return true, newToken, issued, nil
+}
+
+func (s *Service) checkCookieInfo(client *http.Client, csrf string) (bool, int64, error) {
+	endpoint := "https://passport.bilibili.com/x/passport-login/web/cookie/info?csrf=" + url.QueryEscape(csrf)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://www.bilibili.com/")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, 0, fmt.Errorf("cookie info request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			Refresh   bool  `json:"refresh"`
+			Timestamp int64 `json:"timestamp"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return false, 0, fmt.Errorf("cookie info decode error: %w", err)
+	}
+	if res.Code == -101 {
+		return false, 0, fmt.Errorf("登录状态已失效")
+	}
+	if res.Code != 0 {
+		return false, 0, fmt.Errorf("cookie info API error: code=%d, msg=%s", res.Code, res.Message)
+	}
+	return res.Data.Refresh, res.Data.Timestamp, nil
+}
+
+// correspondPath encrypts "refresh_{timestamp}" with Bilibili's RSA-OAEP public key.
+func correspondPath(timestamp int64) (string, error) {
+	block, _ := pem.Decode([]byte(biliCorrespondPublicKey))
+	if block == nil {
+		return "", errors.New("invalid correspond public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("parse correspond public key: %w", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return "", errors.New("correspond public key is not RSA")
+	}
+	encrypted, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, rsaPub, []byte(fmt.Sprintf("refresh_%d", timestamp)), nil)
+	if err != nil {
+		return "", fmt.Errorf("encrypt correspond path: %w", err)
+	}
+	return hex.EncodeToString(encrypted), nil
+}
+
+func (s *Service) fetchRefreshCsrf(client *http.Client, timestamp int64) (string, error) {
+	path, err := correspondPath(timestamp)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("GET", "https://www.bilibili.com/correspond/1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://www.bilibili.com/")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("correspond request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	match := refreshCsrfRegexp.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("correspond: refresh_csrf not found (status=%d)", resp.StatusCode)
+	}
+	return strings.TrimSpace(string(match[1])), nil
+}
+
+func (s *Service) refreshCookie(client *http.Client, csrf, refreshCsrf, refreshToken string) (string, []*http.Cookie, error) {
+	form := url.Values{}
+	form.Set("csrf", csrf)
+	form.Set("refresh_csrf", refreshCsrf)
+	form.Set("source", "main_web")
+	form.Set("refresh_token", refreshToken)
+
+	req, err := http.NewRequest("POST", "https://passport.bilibili.com/x/passport-login/web/cookie/refresh", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://www.bilibili.com/")
+	req.Header.Set("Origin", "https://www.bilibili.com")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("cookie refresh request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			RefreshToken string `json:"refresh_token"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", nil, fmt.Errorf("cookie refresh decode error: %w", err)
+	}
+	if res.Code != 0 {
+		return "", nil, fmt.Errorf("cookie refresh API error: code=%d, msg=%s", res.Code, res.Message)
+	}
+	return res.Data.RefreshToken, resp.Cookies(), nil
+}
+
+func (s *Service) confirmRefresh(client *http.Client, csrf, oldRefreshToken string) error {
+	form := url.Values{}
+	form.Set("csrf", csrf)
+	form.Set("refresh_token", oldRefreshToken)
+
+	req, err := http.NewRequest("POST", "https://passport.bilibili.com/x/passport-login/web/confirm/refresh", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Referer", "https://www.bilibili.com/")
+	req.Header.Set("Origin", "https://www.bilibili.com")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("confirm refresh request error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("confirm refresh decode error: %w", err)
+	}
+	if res.Code != 0 {
+		return fmt.Errorf("confirm refresh API error: code=%d, msg=%s", res.Code, res.Message)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"half-beat-player/internal/models"
+)
+
+// fakePassport answers the Bilibili refresh flow, issuing SESSDATA "new-<old SESSDATA>".
+// onInfo runs while the cookie/info request is in flight.
+type fakePassport struct {
+	onInfo func()
+}
+
+func (f *fakePassport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/cookie/info"):
+		if f.onInfo != nil {
+			f.onInfo()
+		}
+		rec.WriteString(`{"code":0,"data":{"refresh":true,"timestamp":1}}`)
+	case strings.HasPrefix(r.URL.Path, "/correspond/"):
+		rec.WriteString(`<div id="1-name">refresh-csrf</div>`)
+	case strings.HasSuffix(r.URL.Path, "/cookie/refresh"):
+		old, _ := r.Cookie("SESSDATA")
+		http.SetCookie(rec, &http.Cookie{Name: "SESSDATA", Value: "new-" + old.Value, Path: "/", Domain: ".bilibili.com", MaxAge: 3600})
+		http.SetCookie(rec, &http.Cookie{Name: "bili_jct", Value: "new-jct", Path: "/", Domain: ".bilibili.com", MaxAge: 3600})
+		rec.WriteString(`{"code":0,"data":{"refresh_token":"new-token"}}`)
+	default:
+		rec.WriteString(`{"code":0}`)
+	}
+	return rec.Result(), nil
+}
+
+func saveTestSession(t *testing.T, s *Service, uid int64, sessdata string, active bool) models.LoginSession {
+	t.Helper()
+	cookies := []sessionCookie{{Name: "SESSDATA", Value: sessdata}, {Name: "bili_jct", Value: "jct"}}
+	data, err := json.Marshal(cookies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := models.LoginSession{UID: uid, Sessdata: sessdata, Cookies: string(data), RefreshToken: "token", Active: active}
+	if err := s.sealSession(&session); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.Create(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	if active {
+		s.cookieJar.swap(newSessionJar(cookies))
+	}
+	return session
+}
+
+func loadTestSession(t *testing.T, s *Service, id uint) models.LoginSession {
+	t.Helper()
+	var session models.LoginSession
+	if err := s.db.First(&session, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.openSession(&session); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestRefreshAllLoginSessions(t *testing.T) {
+	s := newTestService(t)
+	locked := false
+	s.httpClient.Transport = &fakePassport{onInfo: func() {
+		// 网络请求期间不应持有 accountMu
+		if !s.accountMu.TryLock() {
+			locked = true
+			return
+		}
+		s.accountMu.Unlock()
+	}}
+	active := saveTestSession(t, s, 1, "active", true)
+	stored := saveTestSession(t, s, 2, "stored", false)
+
+	n, err := s.RefreshAllLoginSessions()
+	if err != nil || n != 2 {
+		t.Fatalf("refreshed %d, %v", n, err)
+	}
+	if locked {
+		t.Fatal("accountMu held during the refresh requests")
+	}
+	for id, want := range map[uint]string{active.ID: "new-active", stored.ID: "new-stored"} {
+		got := loadTestSession(t, s, id)
+		if got.Sessdata != want || got.RefreshToken != "new-token" || !strings.Contains(got.Cookies, "new-jct") {
+			t.Errorf("session %d = %+v", id, got)
+		}
+	}
+	if got := s.biliCookieValue("SESSDATA"); got != "new-active" {
+		t.Fatalf("shared jar SESSDATA = %q, want the active account's new cookie", got)
+	}
+}
+
+func TestRefreshDiscardedAfterRelogin(t *testing.T) {
+	s := newTestService(t)
+	var session models.LoginSession
+	s.httpClient.Transport = &fakePassport{onInfo: func() {
+		// 刷新过程中重新登录了同一账号
+		relogin := models.LoginSession{RefreshToken: "relogin-token"}
+		s.sealSession(&relogin)
+		s.db.Model(&models.LoginSession{}).Where("id = ?", session.ID).Update("refresh_token", relogin.RefreshToken)
+	}}
+	session = saveTestSession(t, s, 1, "active", true)
+
+	ok, err := s.RefreshLoginSession()
+	if err != nil || ok {
+		t.Fatalf("RefreshLoginSession = %v, %v, want the result discarded", ok, err)
+	}
+	if got := loadTestSession(t, s, session.ID); got.Sessdata != "active" || got.RefreshToken != "relogin-token" {
+		t.Fatalf("session overwritten: %+v", got)
+	}
+	if got := s.biliCookieValue("SESSDATA"); got != "active" {
+		t.Fatalf("shared jar SESSDATA = %q", got)
+	}
+}
@@ -1,24 +1,45 @@
 package services
 
 import (
-    "encoding/json"
     "errors"
     "fmt"
     "time"
 
     "half-beat-player/internal/models"
 
-    "github.com/google/uuid"
     "gorm.io/gorm"
 )
 
-// SavePlayerSetting overwrites the single settings row.
+// SavePlayerSetting merges the given keys into the single settings row. Known keys
+// are validated against the settings registry; a *SettingsValidationError lists
+// every invalid key and nothing is saved.
+// Internal keys (see SettingDef.Internal) are ignored, so an autosave built from
+// a stale config cannot overwrite what their dedicated savers stored.
 func (s *Service) SavePlayerSetting(setting models.PlayerSetting) error {
+	setting.Config = withoutInternalSettings(setting.Config)
+	return s.savePlayerSetting(setting)
+}
+
+// savePlayerSetting saves every given key, internal ones included; only the
+// dedicated savers of internal keys call it directly.
+func (s *Service) savePlayerSetting(setting models.PlayerSetting) error {
+	incoming := make(map[string]any, len(setting.Config))
+	for k, v := range setting.Config {
+		incoming[k] = v
+	}
+	migrateSettingKeys(incoming)
+	if err := validateSettings(incoming); err != nil {
+		return err
+	}
+	setting.Config = incoming
+
 	var existing models.PlayerSetting
 	if err := s.db.First(&existing, 1).Error; err == nil {
 		if existing.Config == nil {
 			existing.Config = make(map[string]any)
 		}
+		sanitizeStoredSettings(existing.Config)
+		changed := changedSettingKeys(existing.Config, setting.Config)
 		// Merge new config into existing one
 		for k, v := range setting.Config {
 			existing.Config[k] = v
@@ -27,18 +48,27 @@ func (s *Service) SavePlayerSetting(setting models.PlayerSetting) error {
 		err := s.db.Save(&existing).Error
 		if err != nil {
 			fmt.Printf("SavePlayerSetting error: %v\n", err)
+			return err
 		}
-		return err
+		s.notifySettingsChanged(changed, existing.Config)
+		return nil
 	}
 
 	// If not found, create new
+	config := defaultSettingsConfig()
+	for k, v := range setting.Config {
+		config[k] = v
+	}
+	setting.Config = config
 	setting.ID = 1
 	setting.UpdatedAt = time.Now()
 	err := s.db.Save(&setting).Error
 	if err != nil {
 		fmt.Printf("SavePlayerSetting error: %v\n", err)
+		return err
 	}
-	return err
+	s.notifySettingsChanged(changedSettingKeys(defaultSettingsConfig(), setting.Config), setting.Config)
+	return nil
 }
 
 // GetPlayerSetting returns the stored setting (or defaults).
@@ -46,18 +76,7 @@ func (s *Service) GetPlayerSetting() (models.PlayerSetting, error) {
 	var setting models.PlayerSetting
 	if err := s.db.First(&setting, 1).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			themesJSON, _ := formatThemesJSON([]models.Theme{})
-			setting = models.PlayerSetting{
-				ID: 1,
-				Config: map[string]any{
-					"playMode":             "order",
-					"defaultVolume":        0.5,
-					"themes":               themesJSON,
-					"currentThemeId":       "light",
-					"volumeCompensationDb": 0,
-					"songVolumeOffsets":    map[string]any{},
-				},
-			}
+			setting = models.PlayerSetting{ID: 1, Config: defaultSettingsConfig()}
 			if err := s.db.Create(&setting).Error; err != nil {
 				return setting, err
 			}
@@ -69,6 +88,18 @@ func (s *Service) GetPlayerSetting() (models.PlayerSetting, error) {
 	if setting.Config == nil {
 		setting.Config = make(map[string]any)
 	}
+	// 迁移旧键名并修正无效的存储值
+	if sanitizeStoredSettings(setting.Config) {
+		if err := s.db.Save(&setting).Error; err != nil {
+			fmt.Printf("GetPlayerSetting: save migrated settings error: %v\n", err)
+		}
+	}
+	// 未保存过的键返回默认值
+	for k, v := range defaultSettingsConfig() {
+		if _, ok := setting.Config[k]; !ok {
+			setting.Config[k] = v
+		}
+	}
 	return setting, nil
 }
 
@@ -79,153 +110,3 @@ func getConfigString(m map[string]any, key string, defaultValue string) string {
 	}
 	return defaultValue
 }
-
-// formatThemesJSON converts theme slice to JSON string
-func formatThemesJSON(themes []models.Theme) (string, error) {
-    data, err := json.Marshal(themes)
-    return string(data), err
-}
-
-// parseThemesJSON converts JSON string back to theme slice
-func parseThemesJSON(themesJSON string) ([]models.Theme, error) {
-    var themes []models.Theme
-    if themesJSON == "" || themesJSON == "null" {
-        return themes, nil
-    }
-    if err := json.Unmarshal([]byte(themesJSON), &themes); err != nil {
-        // Return empty slice instead of nil on error
-        return themes, err
-    }
-    return themes, nil
-}
-
-// GetThemes returns all available themes
-func (s *Service) GetThemes() ([]models.Theme, error) {
-	setting, err := s.GetPlayerSetting()
-	if err != nil {
-		fmt.Printf("GetThemes: GetPlayerSetting error: %v\n", err)
-		return []models.Theme{}, err
-	}
-	themesJSON := getConfigString(setting.Config, "themes", "")
-	themes, err := parseThemesJSON(themesJSON)
-	if err != nil {
-		fmt.Printf("GetThemes: parseThemesJSON error: %v\n", err)
-		return []models.Theme{}, err
-	}
-	return themes, nil
-}
-
-// CreateTheme adds a new custom theme
-func (s *Service) CreateTheme(theme models.Theme) (models.Theme, error) {
-	setting, err := s.GetPlayerSetting()
-	if err != nil {
-		return theme, err
-	}
-
-	themesJSON := getConfigString(setting.Config, "themes", "")
-	themes, err := parseThemesJSON(themesJSON)
-	if err != nil {
-		return theme, err
-	}
-
-	// Generate unique ID for new theme
-	theme.ID = "theme-" + uuid.NewString()
-	theme.IsDefault = false
-	theme.IsReadOnly = false
-	themes = append(themes, theme)
-
-	newThemesJSON, err := formatThemesJSON(themes)
-	if err != nil {
-		return theme, err
-	}
-
-	setting.Config["themes"] = newThemesJSON
-	err = s.SavePlayerSetting(setting)
-	return theme, err
-}
-
-// UpdateTheme modifies an existing custom theme
-func (s *Service) UpdateTheme(theme models.Theme) error {
-	setting, err := s.GetPlayerSetting()
-	if err != nil {
-		return err
-	}
-
-	themesJSON := getConfigString(setting.Config, "themes", "")
-	themes, err := parseThemesJSON(themesJSON)
-	if err != nil {
-		return err
-	}
-
-	found := false
-	for i, t := range themes {
-		if t.ID == theme.ID {
-			// 保留 IsDefault 属性
-			theme.IsDefault = t.IsDefault
-			themes[i] = theme
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		return fmt.Errorf("theme not found: %s", theme.ID)
-	}
-
-	newThemesJSON, err := formatThemesJSON(themes)
-	if err != nil {
-		return err
-	}
-
-	setting.Config["themes"] = newThemesJSON
-	return s.SavePlayerSetting(setting)
-}
-
-// DeleteTheme removes a custom theme
-func (s *Service) DeleteTheme(themeID string) error {
-	setting, err := s.GetPlayerSetting()
-	if err != nil {
-		return err
-	}
-
-	themesJSON := getConfigString(setting.Config, "themes", "")
-	themes, err := parseThemesJSON(themesJSON)
-	if err != nil {
-		return err
-	}
-
-	newThemes := []models.Theme{}
-	for _, t := range themes {
-		if t.ID == themeID {
-			if t.IsDefault {
-				return fmt.Errorf("cannot delete default theme")
-			}
-			continue
-		}
-		newThemes = append(newThemes, t)
-	}
-
-	// If deleted theme was current, switch to light theme
-	if getConfigString(setting.Config, "currentThemeId", "") == themeID {
-		setting.Config["currentThemeId"] = "light"
-	}
-
-	newThemesJSON, err := formatThemesJSON(newThemes)
-	if err != nil {
-		return err
-	}
-
-	setting.Config["themes"] = newThemesJSON
-	return s.SavePlayerSetting(setting)
-}
-
-// SetCurrentTheme changes the active theme
-func (s *Service) SetCurrentTheme(themeID string) error {
-	setting, err := s.GetPlayerSetting()
-	if err != nil {
-		return err
-	}
-
-	setting.Config["currentThemeId"] = themeID
-	return s.SavePlayerSetting(setting)
-}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ===== Live reconfiguration of backend subsystems =====
+
+const (
+	httpConnectTimeoutKey = "httpConnectTimeoutSeconds"
+	httpRequestTimeoutKey = "httpRequestTimeoutSeconds"
+	httpProxyKey          = "httpProxy"
+	audioCacheMaxMBKey    = "audioCacheMaxMB"
+	downloadConcurrentKey = "downloadConcurrency"
+
+	cachePruneInterval = time.Hour
+)
+
+// checkProxyURL accepts "" (use the system proxy) or an http/https/socks5 URL.
+func checkProxyURL(v any) error {
+	str, _ := v.(string)
+	if str == "" {
+		return nil
+	}
+	u, err := url.Parse(str)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("代理地址无效")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return nil
+	}
+	return fmt.Errorf("代理只支持 http、https、socks5")
+}
+
+// tunableTransport lets the shared HTTP client's transport and request timeout be
+// replaced while requests are in flight.
+type tunableTransport struct {
+	cur atomic.Pointer[tunedTransport]
+}
+
+type tunedTransport struct {
+	rt      *http.Transport
+	timeout time.Duration // 整个请求（含读取响应体）的超时，0 表示不限
+}
+
+func newTransport(connectTimeout time.Duration, proxy string) *http.Transport {
+	proxyFunc := http.ProxyFromEnvironment
+	if u, err := url.Parse(proxy); proxy != "" && err == nil {
+		proxyFunc = http.ProxyURL(u)
+	}
+	return &http.Transport{
+		Proxy: proxyFunc,
+		DialContext: (&net.Dialer{
+			Timeout:   connectTimeout, // 连接超时
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: connectTimeout,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+	}
+}
+
+func (t *tunableTransport) set(rt *http.Transport, timeout time.Duration) {
+	if old := t.cur.Swap(&tunedTransport{rt: rt, timeout: timeout}); old != nil {
+		old.rt.CloseIdleConnections()
+	}
+}
+
+func (t *tunableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cur := t.cur.Load()
+	if cur.timeout <= 0 {
+		return cur.rt.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), cur.timeout)
+	resp, err := cur.rt.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the request timeout once the body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// applyHTTPSettings rebuilds the shared transport from the timeout and proxy settings.
+func (s *Service) applyHTTPSettings(cfg map[string]any) {
+	connect := time.Duration(getConfigFloat(cfg, httpConnectTimeoutKey, 10)) * time.Second
+	timeout := time.Duration(getConfigFloat(cfg, httpRequestTimeoutKey, 30)) * time.Second
+	proxy := getConfigString(cfg, httpProxyKey, "")
+	s.transport.set(newTransport(connect, proxy), timeout)
+	fmt.Printf("[Settings] HTTP connect=%s request=%s proxy=%q\n", connect, timeout, proxy)
+}
+
+// slotLimiter bounds concurrent work; the limit can change while slots are held.
+type slotLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+func newSlotLimiter(limit int) *slotLimiter {
+	l := &slotLimiter{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *slotLimiter) acquire() {
+	l.mu.Lock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+	l.mu.Unlock()
+}
+
+func (l *slotLimiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+	l.cond.Signal()
+}
+
+func (l *slotLimiter) setLimit(limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// applyDownloadSettings resizes the download limiter.
+func (s *Service) applyDownloadSettings(cfg map[string]any) {
+	s.downloadSlots.setLimit(int(getConfigFloat(cfg, downloadConcurrentKey, 2)))
+}
+
+// pruneAudioCache deletes the least recently written cache files until the cache
+// fits in maxMB. 0 means unlimited.
+func (s *Service) pruneAudioCache(maxMB float64) error {
+	if maxMB <= 0 {
+		return nil
+	}
+	dir := filepath.Join(s.dataDir, cacheDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	type cached struct {
+		path string
+		size int64
+		mod  time.Time
+	}
+	var files []cached
+	var total int64
+	for _, e := range entries {
+		// 正在写入的 .part 文件不计入
+		if e.IsDir() || strings.HasSuffix(e.Name(), ".part") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cached{filepath.Join(dir, e.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	limit := int64(maxMB * 1024 * 1024)
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.Before(files[j].mod) })
+	for _, f := range files {
+		if total <= limit {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			return err
+		}
+		total -= f.size
+	}
+	return nil
+}
+
+func (s *Service) applyCacheSettings(cfg map[string]any) {
+	if err := s.pruneAudioCache(getConfigFloat(cfg, audioCacheMaxMBKey, 0)); err != nil {
+		fmt.Printf("[Settings] prune audio cache: %v\n", err)
+	}
+}
+
+// startCachePruner keeps the passive audio cache under its size limit.
+func (s *Service) startCachePruner(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(cachePruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			setting, err := s.GetPlayerSetting()
+			if err != nil {
+				continue
+			}
+			s.applyCacheSettings(setting.Config)
+		}
+	}()
+}
+
+// registerSettingsSubscribers wires the subsystems that follow settings live.
+func (s *Service) registerSettingsSubscribers() {
+	s.subscribeSettings(s.applyHTTPSettings, httpConnectTimeoutKey, httpRequestTimeoutKey, httpProxyKey)
+	s.subscribeSettings(s.applyDownloadSettings, downloadConcurrentKey)
+	s.subscribeSettings(s.applyCacheSettings, audioCacheMaxMBKey)
+	s.subscribeSettings(s.applyProxySettings, proxyPortKey)
+}
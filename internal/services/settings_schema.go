@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ===== Settings registry =====
+
+const (
+	settingTypeString  = "string"
+	settingTypeNumber  = "number"
+	settingTypeInteger = "integer"
+	settingTypeBoolean = "boolean"
+	settingTypeEnum    = "enum"
+	settingTypeColor   = "color"
+	settingTypeObject  = "object"
+)
+
+// SettingDef declares one key of PlayerSetting.Config.
+type SettingDef struct {
+	Key         string   `json:"key"`
+	Type        string   `json:"type"` // string | number | integer | boolean | enum | color | object
+	Default     any      `json:"default"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Options     []string `json:"options,omitempty"` // enum 可选值
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Internal    bool     `json:"internal"` // 由专门接口管理，设置界面不直接展示
+
+	check func(v any) error // 类型检查之后的额外校验
+}
+
+// SettingFieldError is a validation failure of one key.
+type SettingFieldError struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// SettingsValidationError lists every invalid key of a save.
+type SettingsValidationError struct {
+	Fields []SettingFieldError `json:"fields"`
+}
+
+func (e *SettingsValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Key + ": " + f.Message
+	}
+	return "设置无效: " + strings.Join(parts, "; ")
+}
+
+func numberSetting(key, category, desc string, def, lo, hi float64) SettingDef {
+	return SettingDef{Key: key, Type: settingTypeNumber, Default: def, Min: &lo, Max: &hi, Category: category, Description: desc}
+}
+
+func integerSetting(key, category, desc string, def, lo, hi float64) SettingDef {
+	d := numberSetting(key, category, desc, def, lo, hi)
+	d.Type = settingTypeInteger
+	return d
+}
+
+func colorSetting(key, desc, def string) SettingDef {
+	return SettingDef{Key: key, Type: settingTypeColor, Default: def, Category: "appearance", Description: desc}
+}
+
+// settingDefs is the registry; keys not listed here are stored unchecked.
+var settingDefs = []SettingDef{
+	{Key: "playMode", Type: settingTypeEnum, Default: "loop", Options: []string{"loop", "random", "single"}, Category: "playback", Description: "播放模式：列表循环、随机、单曲循环"},
+	numberSetting("defaultVolume", "playback", "默认音量", 0.5, 0, 1),
+	numberSetting("volumeCompensationDb", "playback", "全局音量补偿（dB）", 0, -20, 20),
+	{Key: "songVolumeOffsets", Type: settingTypeObject, Default: map[string]any{}, Category: "playback", Description: "按歌曲 ID 的音量偏移（dB）"},
+	numberSetting(resumeMinDurationKey, "playback", "时长不低于该值（秒）的歌曲记录续播位置", defaultResumeMinDuration, 0, 24*3600),
+
+	{Key: "currentThemeId", Type: settingTypeString, Default: "light", Category: "appearance", Description: "当前主题 ID"},
+	colorSetting("themeColor", "主题色", "#66ccff"),
+	colorSetting("backgroundColor", "背景颜色", "#f8fafc"),
+	numberSetting("backgroundOpacity", "appearance", "背景不透明度", 1, 0, 1),
+	{Key: "backgroundImage", Type: settingTypeString, Default: "", Category: "appearance", Description: "背景图片地址"},
+	colorSetting("panelColor", "面板颜色", "#ffffff"),
+	numberSetting("panelOpacity", "appearance", "面板不透明度", 0.92, 0.2, 1),
+	numberSetting("panelBlur", "appearance", "面板模糊（px）", 0, 0, 30),
+	numberSetting("panelRadius", "appearance", "面板圆角（px）", 8, 0, 32),
+	colorSetting("controlColor", "控件颜色", "#ffffff"),
+	numberSetting("controlOpacity", "appearance", "控件不透明度", 1, 0, 1),
+	colorSetting("textColorPrimary", "主要文字颜色", "#1a1b1e"),
+	colorSetting("textColorSecondary", "次要文字颜色", "#c1c2c5"),
+	colorSetting("favoriteCardColor", "歌单卡片颜色", "#ffffff"),
+	numberSetting("componentRadius", "appearance", "组件圆角（px）", 8, 0, 32),
+	numberSetting("modalRadius", "appearance", "弹窗圆角（px）", 12, 0, 32),
+	numberSetting("notificationRadius", "appearance", "通知圆角（px）", 8, 0, 32),
+	numberSetting("coverRadius", "appearance", "封面圆角（px）", 8, 0, 50),
+	{Key: "windowControlsPos", Type: settingTypeEnum, Default: "right", Options: []string{"left", "right", "hidden"}, Category: "appearance", Description: "窗口按钮位置"},
+
+	integerSetting(httpConnectTimeoutKey, "network", "连接超时（秒）", 10, 1, 120),
+	integerSetting(httpRequestTimeoutKey, "network", "请求超时（秒）", 30, 5, 600),
+	{Key: httpProxyKey, Type: settingTypeString, Default: "", Category: "network", Description: "代理服务器，如 http://127.0.0.1:7890，为空使用系统代理", check: checkProxyURL},
+	integerSetting(proxyPortKey, "network", "本地音频代理端口，0 为自动选择；被占用时自动改用空闲端口", defaultProxyPort, 0, 65535),
+	integerSetting(audioCacheMaxMBKey, "storage", "音频缓存上限（MB），0 为不限", 0, 0, 1024*1024),
+	integerSetting(downloadConcurrentKey, "storage", "同时下载数", 2, 1, 8),
+
+	{Key: "scrobble", Type: settingTypeObject, Category: "integrations", Description: "Scrobble 配置，由 SaveScrobbleConfig 管理", Internal: true},
+	{Key: dbBackupConfigKey, Type: settingTypeObject, Category: "data", Description: "数据库自动备份配置，由 SaveDBBackupConfig 管理", Internal: true},
+}
+
+var settingDefsByKey = func() map[string]SettingDef {
+	m := make(map[string]SettingDef, len(settingDefs))
+	for _, d := range settingDefs {
+		m[d.Key] = d
+	}
+	return m
+}()
+
+// renamedSettingKeys maps old key names to their current names.
+var renamedSettingKeys = map[string]string{
+	"backgroundImageUrl": "backgroundImage", // 前端状态名曾被直接写入
+}
+
+// legacySettingValues maps retired values of a key to their replacement.
+var legacySettingValues = map[string]map[string]string{
+	"playMode": {"order": "loop"}, // 旧版默认值，前端只识别 loop/random/single
+}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+// withoutInternalSettings returns config without the keys managed by dedicated savers.
+func withoutInternalSettings(config map[string]any) map[string]any {
+	out := make(map[string]any, len(config))
+	for k, v := range config {
+		if def, ok := settingDefsByKey[k]; ok && def.Internal {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// GetSettingsSchema returns the settings registry for the settings UI.
+func (s *Service) GetSettingsSchema() []SettingDef {
+	out := make([]SettingDef, len(settingDefs))
+	copy(out, settingDefs)
+	return out
+}
+
+// defaultSettingsConfig builds the config of a new settings row.
+func defaultSettingsConfig() map[string]any {
+	cfg := make(map[string]any, len(settingDefs))
+	for _, d := range settingDefs {
+		switch v := d.Default.(type) {
+		case nil:
+		case map[string]any:
+			cfg[d.Key] = maps.Clone(v)
+		default:
+			cfg[d.Key] = v
+		}
+	}
+	return cfg
+}
+
+// migrateSettingKeys renames old keys and replaces retired values in place. It
+// reports whether anything changed.
+func migrateSettingKeys(cfg map[string]any) bool {
+	changed := false
+	for old, key := range renamedSettingKeys {
+		v, ok := cfg[old]
+		if !ok {
+			continue
+		}
+		if _, exists := cfg[key]; !exists {
+			cfg[key] = v
+		}
+		delete(cfg, old)
+		changed = true
+	}
+	for key, values := range legacySettingValues {
+		if v, ok := cfg[key].(string); ok {
+			if repl, ok := values[v]; ok {
+				cfg[key] = repl
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// normalizeSetting checks v against the definition and returns it in its stored form
+// (integers and numbers as float64, like values decoded from JSON).
+func normalizeSetting(def SettingDef, v any) (any, error) {
+	switch def.Type {
+	case settingTypeNumber, settingTypeInteger:
+		var f float64
+		switch n := v.(type) {
+		case float64:
+			f = n
+		case float32:
+			f = float64(n)
+		case int:
+			f = float64(n)
+		case int64:
+			f = float64(n)
+		default:
+			return nil, fmt.Errorf("必须是数字")
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("必须是有限数字")
+		}
+		if def.Type == settingTypeInteger && f != math.Trunc(f) {
+			return nil, fmt.Errorf("必须是整数")
+		}
+		if def.Min != nil && f < *def.Min {
+			return nil, fmt.Errorf("不能小于 %g", *def.Min)
+		}
+		if def.Max != nil && f > *def.Max {
+			return nil, fmt.Errorf("不能大于 %g", *def.Max)
+		}
+		return f, nil
+	case settingTypeBoolean:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("必须是布尔值")
+		}
+		return b, nil
+	case settingTypeString:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("必须是字符串")
+		}
+		return str, nil
+	case settingTypeEnum:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("必须是字符串")
+		}
+		for _, o := range def.Options {
+			if o == str {
+				return str, nil
+			}
+		}
+		return nil, fmt.Errorf("必须是 %s 之一", strings.Join(def.Options, "/"))
+	case settingTypeColor:
+		str, ok := v.(string)
+		if !ok || !hexColorPattern.MatchString(str) {
+			return nil, fmt.Errorf("必须是十六进制颜色 (#RRGGBB)")
+		}
+		return str, nil
+	case settingTypeObject:
+		if _, ok := v.(map[string]any); !ok {
+			return nil, fmt.Errorf("必须是对象")
+		}
+		return v, nil
+	}
+	return v, nil
+}
+
+// validateSettings normalizes known keys of cfg in place. Null values and unknown
+// keys are left as they are.
+func validateSettings(cfg map[string]any) error {
+	var fields []SettingFieldError
+	for key, v := range cfg {
+		def, ok := settingDefsByKey[key]
+		if !ok || v == nil {
+			continue
+		}
+		nv, err := normalizeSetting(def, v)
+		if err == nil && def.check != nil {
+			err = def.check(nv)
+		}
+		if err != nil {
+			fields = append(fields, SettingFieldError{Key: key, Message: err.Error()})
+			continue
+		}
+		cfg[key] = nv
+	}
+	if len(fields) > 0 {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+		return &SettingsValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// sanitizeStoredSettings migrates a loaded config and resets invalid stored values
+// to their defaults. It reports whether the config changed.
+func sanitizeStoredSettings(cfg map[string]any) bool {
+	changed := migrateSettingKeys(cfg)
+	err := validateSettings(cfg)
+	var verr *SettingsValidationError
+	if err != nil && errors.As(err, &verr) {
+		for _, f := range verr.Fields {
+			fmt.Printf("[Settings] reset invalid %s: %s\n", f.Key, f.Message)
+			if def := settingDefsByKey[f.Key]; def.Default != nil {
+				cfg[f.Key] = def.Default
+			} else {
+				delete(cfg, f.Key)
+			}
+		}
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMigrateSettingKeys(t *testing.T) {
+	cfg := map[string]any{"backgroundImageUrl": "a.png", "playMode": "order"}
+	if !migrateSettingKeys(cfg) {
+		t.Fatal("migrateSettingKeys reported no change")
+	}
+	if cfg["backgroundImage"] != "a.png" || cfg["playMode"] != "loop" {
+		t.Fatalf("cfg = %v", cfg)
+	}
+	if _, ok := cfg["backgroundImageUrl"]; ok {
+		t.Fatal("old key kept")
+	}
+
+	// 新键已存在时以新键为准
+	cfg = map[string]any{"backgroundImageUrl": "old.png", "backgroundImage": "new.png"}
+	migrateSettingKeys(cfg)
+	if cfg["backgroundImage"] != "new.png" || len(cfg) != 1 {
+		t.Fatalf("cfg = %v", cfg)
+	}
+
+	if migrateSettingKeys(map[string]any{"playMode": "random"}) {
+		t.Fatal("current values reported as changed")
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	cfg := map[string]any{
+		"defaultVolume":         1,
+		httpConnectTimeoutKey:   int64(20),
+		"playMode":              "single",
+		"themeColor":            "#abc",
+		"backgroundImage":       nil,
+		"someFrontendOnlyState": []any{1, 2},
+	}
+	if err := validateSettings(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg["defaultVolume"] != 1.0 || cfg[httpConnectTimeoutKey] != 20.0 {
+		t.Fatalf("numbers not normalized to float64: %v", cfg)
+	}
+
+	cfg = map[string]any{
+		"defaultVolume":       1.5,
+		httpConnectTimeoutKey: 2.5,
+		"playMode":            "order",
+		"themeColor":          "blue",
+		"panelOpacity":        "0.5",
+		"songVolumeOffsets":   []any{},
+		httpProxyKey:          "ftp://127.0.0.1:21",
+	}
+	err := validateSettings(cfg)
+	var verr *SettingsValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want SettingsValidationError", err)
+	}
+	keys := pluck(verr.Fields, func(f SettingFieldError) string { return f.Key })
+	want := []string{"defaultVolume", httpConnectTimeoutKey, httpProxyKey, "panelOpacity", "playMode", "songVolumeOffsets", "themeColor"}
+	sort.Strings(want)
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Fatalf("fields = %v, want %v", keys, want)
+	}
+}
+
+func TestSanitizeStoredSettingsResetsInvalidValues(t *testing.T) {
+	cfg := map[string]any{"defaultVolume": 7.0, "playMode": "order", "panelBlur": 5.0}
+	if !sanitizeStoredSettings(cfg) {
+		t.Fatal("sanitizeStoredSettings reported no change")
+	}
+	if cfg["defaultVolume"] != 0.5 || cfg["playMode"] != "loop" || cfg["panelBlur"] != 5.0 {
+		t.Fatalf("cfg = %v", cfg)
+	}
+}
+
+func TestWithoutInternalSettings(t *testing.T) {
+	cfg := map[string]any{"scrobble": map[string]any{}, dbBackupConfigKey: map[string]any{}, "defaultVolume": 0.3}
+	out := withoutInternalSettings(cfg)
+	if len(out) != 1 || out["defaultVolume"] != 0.3 {
+		t.Fatalf("out = %v", out)
+	}
+	if len(cfg) != 3 {
+		t.Fatal("input map modified")
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ===== Settings change notifications =====
+
+// settingsChangedEvent is emitted with a SettingsChange after every save that changes a key.
+const settingsChangedEvent = "settings:changed"
+
+// SettingsChange is the payload of the settings:changed event.
+type SettingsChange struct {
+	Keys   []string       `json:"keys"`
+	Config map[string]any `json:"config"`
+}
+
+// settingsSubscriber reacts to changes of some keys; an empty key set means any key.
+type settingsSubscriber struct {
+	keys map[string]bool
+	fn   func(cfg map[string]any)
+}
+
+// subscribeSettings registers fn to run with the full config after a save changes
+// one of keys (any key when none are given). Subscribers run in registration order
+// on the saving goroutine and should return quickly.
+func (s *Service) subscribeSettings(fn func(cfg map[string]any), keys ...string) {
+	sub := settingsSubscriber{keys: make(map[string]bool, len(keys)), fn: fn}
+	for _, k := range keys {
+		sub.keys[k] = true
+	}
+	s.settingsMu.Lock()
+	s.settingsSubs = append(s.settingsSubs, sub)
+	s.settingsMu.Unlock()
+}
+
+// changedSettingKeys returns the keys of next whose values differ from prev.
+func changedSettingKeys(prev, next map[string]any) []string {
+	var keys []string
+	for k, v := range next {
+		if old, ok := prev[k]; !ok || !reflect.DeepEqual(old, v) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// notifySettingsChanged runs the subscribers interested in keys and emits the
+// settings:changed event so other windows refresh.
+func (s *Service) notifySettingsChanged(keys []string, cfg map[string]any) {
+	if len(keys) == 0 {
+		return
+	}
+	full := make(map[string]any, len(cfg))
+	for k, v := range defaultSettingsConfig() {
+		full[k] = v
+	}
+	for k, v := range cfg {
+		full[k] = v
+	}
+
+	s.settingsMu.Lock()
+	subs := append([]settingsSubscriber(nil), s.settingsSubs...)
+	s.settingsMu.Unlock()
+	for _, sub := range subs {
+		if len(sub.keys) > 0 && !anySettingKey(sub.keys, keys) {
+			continue
+		}
+		sub.fn(full)
+	}
+
+	if s.appCtx != nil {
+		runtime.EventsEmit(s.appCtx, settingsChangedEvent, SettingsChange{Keys: keys, Config: full})
+	}
+}
+
+func anySettingKey(set map[string]bool, keys []string) bool {
+	for _, k := range keys {
+		if set[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadSettings runs every subscriber with the stored settings, used at startup and
+// after the database is replaced by a restore.
+func (s *Service) reloadSettings() {
+	setting, err := s.GetPlayerSetting()
+	if err != nil {
+		return
+	}
+	keys := make([]string, 0, len(setting.Config))
+	for k := range setting.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	s.settingsMu.Lock()
+	subs := append([]settingsSubscriber(nil), s.settingsSubs...)
+	s.settingsMu.Unlock()
+	for _, sub := range subs {
+		sub.fn(setting.Config)
+	}
+	if s.appCtx != nil {
+		runtime.EventsEmit(s.appCtx, settingsChangedEvent, SettingsChange{Keys: keys, Config: setting.Config})
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ===== Smart playlists =====
+
+// Smart rule fields.
+const (
+	smartSingerContains  = "singer_contains"   // Value: 歌手名包含
+	smartAddedWithinDays = "added_within_days" // Number: 最近 N 天内添加
+	smartPlayedMoreThan  = "played_more_than"  // Number: 播放次数大于 N
+	smartNeverPlayed     = "never_played"
+	smartHasLyrics       = "has_lyrics"
+	smartDownloaded      = "downloaded"
+	smartDurationBetween = "duration_between" // Number..Max 秒，Max 为 0 表示不设上限
+	smartInFavorite      = "in_favorite"      // Value: 普通歌单 ID
+)
+
+// SmartRule is one condition of a smart playlist.
+type SmartRule struct {
+	Field  string  `json:"field"`
+	Value  string  `json:"value,omitempty"`
+	Number float64 `json:"number,omitempty"`
+	Max    float64 `json:"max,omitempty"`
+	Negate bool    `json:"negate,omitempty"`
+}
+
+// SmartRuleDef describes a rule field for the rule editor.
+type SmartRuleDef struct {
+	Field       string `json:"field"`
+	Input       string `json:"input"` // none | text | number | range | favorite
+	Description string `json:"description"`
+}
+
+var smartRuleDefs = []SmartRuleDef{
+	{Field: smartSingerContains, Input: "text", Description: "歌手名包含"},
+	{Field: smartAddedWithinDays, Input: "number", Description: "最近 N 天内添加"},
+	{Field: smartPlayedMoreThan, Input: "number", Description: "播放次数大于 N"},
+	{Field: smartNeverPlayed, Input: "none", Description: "从未播放"},
+	{Field: smartHasLyrics, Input: "none", Description: "有歌词"},
+	{Field: smartDownloaded, Input: "none", Description: "已下载"},
+	{Field: smartDurationBetween, Input: "range", Description: "时长在范围内（秒，上限为 0 表示不限）。时长取自播放记录，从未播放过的歌曲无论是否取反都不会匹配"},
+	{Field: smartInFavorite, Input: "favorite", Description: "在指定歌单中"},
+}
+
+// GetSmartRuleDefs returns the rule fields supported by smart playlists.
+func (s *Service) GetSmartRuleDefs() []SmartRuleDef {
+	out := make([]SmartRuleDef, len(smartRuleDefs))
+	copy(out, smartRuleDefs)
+	return out
+}
+
+// SmartRuleSet defines a smart playlist.
+type SmartRuleSet struct {
+	Match string      `json:"match"` // all | any
+	Rules []SmartRule `json:"rules"`
+	Limit int         `json:"limit,omitempty"` // 0 表示不限
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+// downloadedSongKeys returns the base names of files in the downloads directory.
+func (s *Service) downloadedSongKeys() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dataDir, downloadsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".m4s" {
+			continue
+		}
+		keys = append(keys, strings.TrimSuffix(e.Name(), ".m4s"))
+	}
+	return keys, nil
+}
+
+// smartRuleSQL translates a rule into a WHERE fragment on the songs table.
+func (s *Service) smartRuleSQL(rule SmartRule) (string, []any, error) {
+	var (
+		cond string
+		args []any
+	)
+	switch rule.Field {
+	case smartSingerContains:
+		if strings.TrimSpace(rule.Value) == "" {
+			return "", nil, fmt.Errorf("规则 %s 缺少歌手名", rule.Field)
+		}
+		cond = `songs.singer LIKE ? ESCAPE '\'`
+		args = []any{"%" + escapeLike(strings.TrimSpace(rule.Value)) + "%"}
+	case smartAddedWithinDays:
+		if rule.Number <= 0 {
+			return "", nil, fmt.Errorf("规则 %s 的天数必须大于 0", rule.Field)
+		}
+		cond = "songs.created_at >= ?"
+		args = []any{time.Now().Add(-time.Duration(rule.Number * float64(24*time.Hour)))}
+	case smartPlayedMoreThan:
+		cond = "(SELECT COUNT(*) FROM play_events WHERE play_events.song_id = songs.id) > ?"
+		args = []any{int64(rule.Number)}
+	case smartNeverPlayed:
+		cond = "NOT EXISTS (SELECT 1 FROM play_events WHERE play_events.song_id = songs.id)"
+	case smartHasLyrics:
+		cond = "(COALESCE(songs.lyric, '') <> '' OR EXISTS (SELECT 1 FROM lyric_mappings WHERE lyric_mappings.id = songs.id AND COALESCE(lyric_mappings.lyric, '') <> ''))"
+	case smartDownloaded:
+		keys, err := s.downloadedSongKeys()
+		if err != nil {
+			return "", nil, err
+		}
+		if len(keys) == 0 {
+			cond = "1 = 0"
+			break
+		}
+		cond = "(songs.id IN ? OR (songs.bvid IN ? AND songs.total_pages <= 1))"
+		args = []any{keys, keys}
+	case smartDurationBetween:
+		// 歌曲表没有时长，使用播放记录中上报的时长；从未播放过的歌曲不匹配
+		duration := "(SELECT MAX(play_events.duration_seconds) FROM play_events WHERE play_events.song_id = songs.id)"
+		if rule.Max > 0 {
+			if rule.Max < rule.Number {
+				return "", nil, fmt.Errorf("规则 %s 的时长范围无效", rule.Field)
+			}
+			cond = duration + " BETWEEN ? AND ?"
+			args = []any{rule.Number, rule.Max}
+		} else {
+			cond = duration + " >= ?"
+			args = []any{rule.Number}
+		}
+	case smartInFavorite:
+		if rule.Value == "" {
+			return "", nil, fmt.Errorf("规则 %s 缺少歌单 ID", rule.Field)
+		}
+		cond = "songs.id IN (SELECT song_refs.song_id FROM song_refs WHERE song_refs.favorite_id = ?)"
+		args = []any{rule.Value}
+	default:
+		return "", nil, fmt.Errorf("未知的智能歌单规则: %s", rule.Field)
+	}
+	if rule.Negate {
+		cond = "NOT (" + cond + ")"
+	}
+	return cond, args, nil
+}
+
+// smartPlaylistQuery builds the song query for a rule set.
+func (s *Service) smartPlaylistQuery(set SmartRuleSet) (*gorm.DB, error) {
+	joiner := " AND "
+	switch set.Match {
+	case "all", "":
+	case "any":
+		joiner = " OR "
+	default:
+		return nil, fmt.Errorf("未知的匹配方式: %s", set.Match)
+	}
+	if len(set.Rules) == 0 {
+		return nil, fmt.Errorf("智能歌单至少需要一条规则")
+	}
+	conds := make([]string, 0, len(set.Rules))
+	var args []any
+	for _, rule := range set.Rules {
+		cond, a, err := s.smartRuleSQL(rule)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+		args = append(args, a...)
+	}
+	q := s.db.Model(&models.Song{}).
+		Where("("+strings.Join(conds, joiner)+")", args...).
+		Order("songs.created_at DESC")
+	if set.Limit > 0 {
+		q = q.Limit(set.Limit)
+	}
+	return q, nil
+}
+
+// evaluateSmartRules returns the IDs of songs matching a rule set.
+func (s *Service) evaluateSmartRules(set SmartRuleSet) ([]string, error) {
+	q, err := s.smartPlaylistQuery(set)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	if err := q.Pluck("songs.id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// fillSmartFavorite computes the songs of a smart favorite in place.
+func (s *Service) fillSmartFavorite(fav *models.Favorite) error {
+	fav.ReadOnly = true
+	fav.SongIDs = []models.SongRef{}
+	var set SmartRuleSet
+	if err := json.Unmarshal([]byte(fav.SmartRules), &set); err != nil {
+		return fmt.Errorf("parse smart rules: %w", err)
+	}
+	ids, err := s.evaluateSmartRules(set)
+	if err != nil {
+		return err
+	}
+	for i, id := range ids {
+		fav.SongIDs = append(fav.SongIDs, models.SongRef{FavoriteID: fav.ID, SongID: id, Position: i})
+	}
+	return nil
+}
+
+// PreviewSmartPlaylist returns the song IDs a rule set currently matches.
+func (s *Service) PreviewSmartPlaylist(set SmartRuleSet) ([]string, error) {
+	return s.evaluateSmartRules(set)
+}
+
+// SaveSmartPlaylist creates or updates a smart playlist. An empty id creates a new one.
+func (s *Service) SaveSmartPlaylist(id, title string, set SmartRuleSet) (models.Favorite, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return models.Favorite{}, fmt.Errorf("歌单名称不能为空")
+	}
+	if _, err := s.smartPlaylistQuery(set); err != nil {
+		return models.Favorite{}, err
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		return models.Favorite{}, err
+	}
+
+	fav := models.Favorite{ID: id}
+	if id == "" {
+		fav.ID = "SmartList-" + uuid.NewString()
+	} else if err := s.db.First(&fav, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Favorite{}, fmt.Errorf("歌单不存在: %s", id)
+		}
+		return models.Favorite{}, err
+	} else if fav.SmartRules == "" {
+		return models.Favorite{}, fmt.Errorf("普通歌单不能转换为智能歌单")
+	}
+	fav.Title = title
+	fav.SmartRules = string(data)
+	if err := s.db.Save(&fav).Error; err != nil {
+		return models.Favorite{}, err
+	}
+	if err := s.fillSmartFavorite(&fav); err != nil {
+		return fav, err
+	}
+	return fav, nil
+}
+
+// isSmartFavorite reports whether the stored favorite is rule based.
+func (s *Service) isSmartFavorite(tx *gorm.DB, id string) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Favorite{}).
+		Where("id = ? AND COALESCE(smart_rules, '') <> ''", id).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// fillSmartFavorites computes the songs of every smart favorite in the list.
+// A broken rule set only empties that playlist.
+func (s *Service) fillSmartFavorites(favs []models.Favorite) {
+	for i := range favs {
+		if favs[i].SmartRules == "" {
+			continue
+		}
+		if err := s.fillSmartFavorite(&favs[i]); err != nil {
+			log.Printf("evaluate smart playlist %s: %v", favs[i].ID, err)
+		}
+	}
+}
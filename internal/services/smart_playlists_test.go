@@ -0,0 +1,65 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"half-beat-player/internal/models"
+)
+
+func TestSmartRuleDefsCoverEveryField(t *testing.T) {
+	s := newTestService(t)
+	for _, def := range s.GetSmartRuleDefs() {
+		rule := SmartRule{Field: def.Field, Value: "x", Number: 1}
+		if _, _, err := s.smartRuleSQL(rule); err != nil {
+			t.Errorf("%s: %v", def.Field, err)
+		}
+	}
+	for _, def := range s.GetFavoriteSortDefs() {
+		w := &favoriteWork{fav: models.Favorite{ID: "fav", SortBy: def.Key}}
+		if err := w.sortRefs(s.db); err != nil {
+			t.Errorf("sort %q: %v", def.Key, err)
+		}
+	}
+}
+
+// 时长来自播放记录，从未播放的歌曲时长未知
+func TestDurationUsesPlayEvents(t *testing.T) {
+	s := newTestService(t)
+	if err := s.UpsertSongs([]models.Song{
+		{ID: "short", Name: "Short"},
+		{ID: "long", Name: "Long"},
+		{ID: "unplayed", Name: "Unplayed"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for id, d := range map[string]float64{"short": 60, "long": 600} {
+		if _, err := s.RecordPlayEvent(models.PlayEvent{SongID: id, DurationSeconds: d, ListenedSeconds: 10, StartedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for negate, want := range map[bool]string{false: "long", true: "short"} {
+		ids, err := s.PreviewSmartPlaylist(SmartRuleSet{Match: "all", Rules: []SmartRule{{Field: smartDurationBetween, Number: 300, Negate: negate}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(ids, ",") != want {
+			t.Errorf("negate=%v: %v, want [%s]", negate, ids, want)
+		}
+	}
+
+	if err := s.SaveFavorite(models.Favorite{ID: "fav", Title: "F", SongIDs: []models.SongRef{{SongID: "long"}, {SongID: "unplayed"}, {SongID: "short"}}}); err != nil {
+		t.Fatal(err)
+	}
+	for sortBy, want := range map[string]string{"duration": "unplayed,short,long", "-duration": "long,short,unplayed"} {
+		refs, err := s.SetFavoriteSort("fav", sortBy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(pluck(refs, func(r models.SongRef) string { return r.SongID }), ","); got != want {
+			t.Errorf("%s: %s, want %s", sortBy, got, want)
+		}
+	}
+}
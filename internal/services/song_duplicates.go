@@ -0,0 +1,399 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"half-beat-player/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ===== Duplicate songs =====
+
+// 时长相差不超过该秒数视为同一首
+const duplicateDurationTolerance = 2.0
+
+// DuplicateGroup is a set of song instances that look like the same song.
+type DuplicateGroup struct {
+	Reasons  []string      `json:"reasons"` // bvid_page | metadata
+	Songs    []models.Song `json:"songs"`
+	Survivor string        `json:"survivor"` // 建议保留的歌曲 ID
+}
+
+// MergeSongsResult reports what MergeSongs changed.
+type MergeSongsResult struct {
+	Survivor    string   `json:"survivor"`
+	Deleted     []string `json:"deleted"`
+	RefsMoved   int      `json:"refsMoved"`
+	RefsRemoved int      `json:"refsRemoved"` // 合并后在同一歌单中重复的引用
+}
+
+// disjointSet groups song IDs; each root keeps the reasons that joined it.
+type disjointSet struct {
+	parent  map[string]string
+	reasons map[string]map[string]bool
+}
+
+func (d *disjointSet) find(id string) string {
+	for d.parent[id] != id {
+		d.parent[id] = d.parent[d.parent[id]]
+		id = d.parent[id]
+	}
+	return id
+}
+
+func (d *disjointSet) union(a, b, reason string) {
+	ra, rb := d.find(a), d.find(b)
+	if ra != rb {
+		d.parent[rb] = ra
+		for r := range d.reasons[rb] {
+			d.reasons[ra][r] = true
+		}
+		delete(d.reasons, rb)
+	}
+	d.reasons[ra][reason] = true
+}
+
+// FindDuplicateSongs groups songs with the same BVID+page, or the same normalized
+// name and singer with known durations within two seconds. Songs without a known
+// duration only match by BVID+page, so different recordings sharing a title are
+// not merged by mistake.
+func (s *Service) FindDuplicateSongs() ([]DuplicateGroup, error) {
+	var songs []models.Song
+	if err := s.db.Order("created_at").Find(&songs).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(songs))
+	set := &disjointSet{parent: map[string]string{}, reasons: map[string]map[string]bool{}}
+	for i, song := range songs {
+		ids[i] = song.ID
+		set.parent[song.ID] = song.ID
+		set.reasons[song.ID] = map[string]bool{}
+	}
+	durations, err := songDurations(s.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byPage := map[string]string{}
+	byMeta := map[string][]models.Song{}
+	for _, song := range songs {
+		if song.BVID != "" {
+			key := fmt.Sprintf("%s#%d", song.BVID, max(song.PageNumber, 1))
+			if first, ok := byPage[key]; ok {
+				set.union(first, song.ID, "bvid_page")
+			} else {
+				byPage[key] = song.ID
+			}
+		}
+		if name := normalizeForMatch(song.Name); name != "" {
+			key := name + "\x00" + normalizeForMatch(song.Singer)
+			byMeta[key] = append(byMeta[key], song)
+		}
+	}
+	for _, group := range byMeta {
+		sort.SliceStable(group, func(i, j int) bool { return durations[group[i].ID] < durations[group[j].ID] })
+		for i := 1; i < len(group); i++ {
+			prev, cur := durations[group[i-1].ID], durations[group[i].ID]
+			if prev == 0 || cur == 0 || math.Abs(cur-prev) > duplicateDurationTolerance {
+				continue
+			}
+			set.union(group[i-1].ID, group[i].ID, "metadata")
+		}
+	}
+
+	members := map[string][]models.Song{}
+	for _, song := range songs {
+		root := set.find(song.ID)
+		members[root] = append(members[root], song)
+	}
+	out := []DuplicateGroup{}
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		reasons := make([]string, 0, len(set.reasons[root]))
+		for r := range set.reasons[root] {
+			reasons = append(reasons, r)
+		}
+		sort.Strings(reasons)
+		survivor, err := s.pickSurvivor(group)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, DuplicateGroup{Reasons: reasons, Songs: group, Survivor: survivor})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Songs[0].CreatedAt.Before(out[j].Songs[0].CreatedAt) })
+	return out, nil
+}
+
+// pickSurvivor prefers the instance in most favorites, then the downloaded one,
+// then the one with lyrics, then the most played, then the oldest.
+func (s *Service) pickSurvivor(group []models.Song) (string, error) {
+	type score struct {
+		refs, plays       int64
+		downloaded, lyric bool
+	}
+	scores := make(map[string]score, len(group))
+	for _, song := range group {
+		var sc score
+		if err := s.db.Model(&models.SongRef{}).Where("song_id = ?", song.ID).Count(&sc.refs).Error; err != nil {
+			return "", err
+		}
+		if err := s.db.Model(&models.PlayEvent{}).Where("song_id = ?", song.ID).Count(&sc.plays).Error; err != nil {
+			return "", err
+		}
+		var lyrics int64
+		if err := s.db.Model(&models.LyricMapping{}).Where("id = ? AND lyric <> ''", song.ID).Count(&lyrics).Error; err != nil {
+			return "", err
+		}
+		sc.lyric = lyrics > 0 || song.Lyric != ""
+		sc.downloaded = s.songDownloadPath(song) != ""
+		scores[song.ID] = sc
+	}
+	best := group[0]
+	for _, song := range group[1:] {
+		a, b := scores[song.ID], scores[best.ID]
+		switch {
+		case a.refs != b.refs:
+			if a.refs > b.refs {
+				best = song
+			}
+		case a.downloaded != b.downloaded:
+			if a.downloaded {
+				best = song
+			}
+		case a.lyric != b.lyric:
+			if a.lyric {
+				best = song
+			}
+		case a.plays != b.plays:
+			if a.plays > b.plays {
+				best = song
+			}
+		case song.CreatedAt.Before(best.CreatedAt):
+			best = song
+		}
+	}
+	return best.ID, nil
+}
+
+// songDownloadPath returns the downloaded file of a song, or "" when there is none.
+func (s *Service) songDownloadPath(song models.Song) string {
+	name := s.getLocalAudioFilename(song)
+	if name == "" {
+		return ""
+	}
+	path := filepath.Join(s.dataDir, downloadsDir, name)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// MergeSongs folds duplicates into one song. An empty survivor is chosen as in
+// FindDuplicateSongs. Favorite refs, queue entries, history and play events move to
+// the survivor; lyrics, skip times and other empty fields are filled from the
+// duplicates; the duplicates are then deleted.
+func (s *Service) MergeSongs(survivor string, duplicates []string) (MergeSongsResult, error) {
+	result := MergeSongsResult{Deleted: []string{}}
+	all := append([]string{}, duplicates...)
+	if survivor != "" {
+		all = append(all, survivor)
+	}
+	var songs []models.Song
+	if err := s.db.Where("id IN ?", all).Order("created_at").Find(&songs).Error; err != nil {
+		return result, err
+	}
+	byID := make(map[string]models.Song, len(songs))
+	for _, song := range songs {
+		byID[song.ID] = song
+	}
+	for _, id := range all {
+		if _, ok := byID[id]; !ok {
+			return result, fmt.Errorf("歌曲不存在: %s", id)
+		}
+	}
+	if survivor == "" {
+		id, err := s.pickSurvivor(songs)
+		if err != nil {
+			return result, err
+		}
+		survivor = id
+	}
+	result.Survivor = survivor
+	keep := byID[survivor]
+	var dups []models.Song
+	for _, song := range songs {
+		if song.ID != survivor {
+			dups = append(dups, song)
+		}
+	}
+	if len(dups) == 0 {
+		return result, fmt.Errorf("没有需要合并的歌曲")
+	}
+	dupIDs := make([]string, len(dups))
+	for i, d := range dups {
+		dupIDs[i] = d.ID
+	}
+
+	queueChanged := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		mergeSongFields(&keep, dups)
+		if err := tx.Save(&keep).Error; err != nil {
+			return err
+		}
+
+		res := tx.Model(&models.SongRef{}).Where("song_id IN ?", dupIDs).Update("song_id", survivor)
+		if res.Error != nil {
+			return res.Error
+		}
+		result.RefsMoved = int(res.RowsAffected)
+		// 同一歌单中保留位置最靠前的一条
+		res = tx.Exec(`DELETE FROM song_refs WHERE song_id = ? AND EXISTS (
+			SELECT 1 FROM song_refs o WHERE o.favorite_id = song_refs.favorite_id AND o.song_id = song_refs.song_id
+			AND (o.position < song_refs.position OR (o.position = song_refs.position AND o.id < song_refs.id)))`, survivor)
+		if res.Error != nil {
+			return res.Error
+		}
+		result.RefsRemoved = int(res.RowsAffected)
+
+		res = tx.Model(&models.QueueEntry{}).Where("song_id IN ?", dupIDs).Update("song_id", survivor)
+		if res.Error != nil {
+			return res.Error
+		}
+		queueChanged = res.RowsAffected > 0
+		for _, model := range []any{&models.QueueHistoryEntry{}, &models.PlayEvent{}, &models.PlayHistory{}} {
+			if err := tx.Model(model).Where("song_id IN ?", dupIDs).Update("song_id", survivor).Error; err != nil {
+				return err
+			}
+		}
+		if err := mergeResumePositions(tx, survivor, dupIDs); err != nil {
+			return err
+		}
+		if err := mergeLyricMappings(tx, survivor, dupIDs); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&models.Song{}, "id IN ?", dupIDs).Error; err != nil {
+			return err
+		}
+		// 清理不再被使用的流源
+		return tx.Exec(`DELETE FROM stream_sources WHERE id IN ? AND id NOT IN (SELECT source_id FROM songs WHERE source_id IS NOT NULL)`,
+			songSourceIDs(dups)).Error
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Deleted = dupIDs
+	s.mergeDownloads(keep, dups)
+	if queueChanged {
+		s.emitQueueChanged()
+	}
+	return result, nil
+}
+
+// mergeSongFields fills the survivor's empty fields from the duplicates, oldest first.
+func mergeSongFields(keep *models.Song, dups []models.Song) {
+	for _, d := range dups {
+		if keep.Lyric == "" && d.Lyric != "" {
+			keep.Lyric, keep.LyricOffset = d.Lyric, d.LyricOffset
+		}
+		if keep.SkipStartTime == 0 && keep.SkipEndTime == 0 && (d.SkipStartTime != 0 || d.SkipEndTime != 0) {
+			keep.SkipStartTime, keep.SkipEndTime = d.SkipStartTime, d.SkipEndTime
+		}
+		if keep.Cover == "" {
+			keep.Cover = d.Cover
+		}
+		if keep.CoverLocal == "" {
+			keep.CoverLocal = d.CoverLocal
+		}
+		if keep.Singer == "" {
+			keep.Singer, keep.SingerID = d.Singer, d.SingerID
+		}
+		if keep.SourceID == "" && d.SourceID != "" {
+			keep.SourceID, keep.StreamURL, keep.StreamURLExpiresAt = d.SourceID, d.StreamURL, d.StreamURLExpiresAt
+		}
+		if d.CreatedAt.Before(keep.CreatedAt) {
+			keep.CreatedAt = d.CreatedAt
+		}
+	}
+}
+
+// mergeResumePositions keeps the most recent resume position under the survivor.
+func mergeResumePositions(tx *gorm.DB, survivor string, dupIDs []string) error {
+	var latest models.ResumePosition
+	err := tx.Where("song_id IN ?", append([]string{survivor}, dupIDs...)).Order("updated_at DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Delete(&models.ResumePosition{}, "song_id IN ?", append([]string{survivor}, dupIDs...)).Error; err != nil {
+		return err
+	}
+	latest.SongID = survivor
+	return tx.Create(&latest).Error
+}
+
+// mergeLyricMappings keeps the survivor's mapping, or else the newest duplicate's.
+func mergeLyricMappings(tx *gorm.DB, survivor string, dupIDs []string) error {
+	var count int64
+	if err := tx.Model(&models.LyricMapping{}).Where("id = ?", survivor).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		var latest models.LyricMapping
+		err := tx.Where("id IN ?", dupIDs).Order("updated_at DESC").First(&latest).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			latest.ID = survivor
+			if err := tx.Create(&latest).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Delete(&models.LyricMapping{}, "id IN ?", dupIDs).Error
+}
+
+func songSourceIDs(songs []models.Song) []string {
+	ids := []string{}
+	for _, song := range songs {
+		if song.SourceID != "" {
+			ids = append(ids, song.SourceID)
+		}
+	}
+	return ids
+}
+
+// mergeDownloads moves a duplicate's download to the survivor when it has none and
+// removes the other duplicate files.
+func (s *Service) mergeDownloads(keep models.Song, dups []models.Song) {
+	target := s.songDownloadPath(keep)
+	for _, d := range dups {
+		path := s.songDownloadPath(d)
+		if path == "" {
+			continue
+		}
+		if target == "" {
+			name := s.getLocalAudioFilename(keep)
+			if name == "" {
+				continue
+			}
+			target = filepath.Join(s.dataDir, downloadsDir, name)
+			if err := os.Rename(path, target); err != nil {
+				target = ""
+			}
+			continue
+		}
+		_ = os.Remove(path)
+	}
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"half-beat-player/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ===== Themes =====
+
+const (
+	themeFileFormat        = "half-beat-theme"
+	themeFileSchemaVersion = 1
+	defaultThemeID         = "light"
+)
+
+// ThemeFile is the content of a .hbtheme file.
+type ThemeFile struct {
+	Format        string          `json:"format"`
+	SchemaVersion int             `json:"schemaVersion"`
+	AppVersion    string          `json:"appVersion"`
+	Name          string          `json:"name"`
+	Data          json.RawMessage `json:"data"` // 主题配置对象
+	ExportedAt    time.Time       `json:"exportedAt"`
+}
+
+// ThemeValidationError lists every invalid field of a theme.
+type ThemeValidationError struct {
+	Fields []SettingFieldError `json:"fields"`
+}
+
+func (e *ThemeValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Key + ": " + f.Message
+	}
+	return "主题无效: " + strings.Join(parts, "; ")
+}
+
+// themeFieldDefs mirrors the theme editor's field rules; unknown fields are kept.
+var themeFieldDefs = func() map[string]SettingDef {
+	defs := []SettingDef{
+		{Key: "colorScheme", Type: settingTypeEnum, Options: []string{"light", "dark"}},
+		colorSetting("themeColor", "主题色", ""),
+		colorSetting("backgroundColor", "背景颜色", ""),
+		numberSetting("backgroundOpacity", "", "", 0, 0, 1),
+		{Key: "backgroundImage", Type: settingTypeString},
+		numberSetting("backgroundBlur", "", "", 0, 0, 50),
+		colorSetting("panelColor", "面板颜色", ""),
+		numberSetting("panelOpacity", "", "", 0, 0.2, 1),
+		numberSetting("panelBlur", "", "", 0, 0, 30),
+		numberSetting("panelRadius", "", "", 0, 0, 32),
+		colorSetting("controlColor", "控件颜色", ""),
+		numberSetting("controlOpacity", "", "", 0, 0, 1),
+		numberSetting("controlBlur", "", "", 0, 0, 20),
+		colorSetting("textColorPrimary", "主要文字颜色", ""),
+		colorSetting("textColorSecondary", "次要文字颜色", ""),
+		colorSetting("favoriteCardColor", "歌单卡片颜色", ""),
+		numberSetting("cardOpacity", "", "", 0, 0, 1),
+		numberSetting("componentRadius", "", "", 0, 0, 32),
+		numberSetting("modalRadius", "", "", 0, 0, 32),
+		numberSetting("notificationRadius", "", "", 0, 0, 32),
+		numberSetting("coverRadius", "", "", 0, 0, 50),
+		colorSetting("modalColor", "弹窗颜色", ""),
+		numberSetting("modalOpacity", "", "", 0, 0, 1),
+		numberSetting("modalBlur", "", "", 0, 0, 30),
+		{Key: "windowControlsPos", Type: settingTypeEnum, Options: []string{"left", "right", "hidden"}},
+	}
+	m := make(map[string]SettingDef, len(defs))
+	for _, d := range defs {
+		m[d.Key] = d
+	}
+	return m
+}()
+
+// validateThemeData checks that data is a JSON object whose known fields are valid.
+func validateThemeData(data string) error {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(data), &obj); err != nil || obj == nil {
+		return &ThemeValidationError{Fields: []SettingFieldError{{Key: "data", Message: "必须是 JSON 对象"}}}
+	}
+	var fields []SettingFieldError
+	for key, v := range obj {
+		def, ok := themeFieldDefs[key]
+		if !ok || v == nil {
+			continue
+		}
+		if _, err := normalizeSetting(def, v); err != nil {
+			fields = append(fields, SettingFieldError{Key: key, Message: err.Error()})
+		}
+	}
+	if len(fields) > 0 {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+		return &ThemeValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func mustThemeJSON(v map[string]any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// builtinThemes ship with the app and cannot be edited or deleted.
+var builtinThemes = []models.Theme{
+	{
+		ID:   "light",
+		Name: "亮色",
+		Data: mustThemeJSON(map[string]any{
+			"colorScheme": "light", "themeColor": "#66ccff",
+			"backgroundColor": "#f8fafc", "backgroundOpacity": 1, "backgroundBlur": 0,
+			"panelColor": "#ffffff", "panelOpacity": 0.92, "panelBlur": 0, "panelRadius": 8,
+			"controlColor": "#ffffff", "controlOpacity": 1, "controlBlur": 0,
+			"textColorPrimary": "#1a1b1e", "textColorSecondary": "#c1c2c5",
+			"favoriteCardColor": "#ffffff", "cardOpacity": 1,
+			"componentRadius": 8, "modalRadius": 12, "notificationRadius": 8, "coverRadius": 8,
+			"modalColor": "#ffffff", "modalOpacity": 0.92, "modalBlur": 0,
+			"windowControlsPos": "right",
+		}),
+		IsDefault:  true,
+		IsReadOnly: true,
+	},
+	{
+		ID:   "dark",
+		Name: "暗色",
+		Data: mustThemeJSON(map[string]any{
+			"colorScheme": "dark", "themeColor": "#f4004f",
+			"backgroundColor": "#210b13ff", "backgroundOpacity": 1, "backgroundBlur": 0,
+			"panelColor": "#371f25ff", "panelOpacity": 0.92, "panelBlur": 0, "panelRadius": 8,
+			"controlColor": "#371f25ff", "controlOpacity": 1, "controlBlur": 0,
+			"textColorPrimary": "#ffffff", "textColorSecondary": "#a6a7ab",
+			"favoriteCardColor": "#371f25ff", "cardOpacity": 1,
+			"componentRadius": 8, "modalRadius": 12, "notificationRadius": 8, "coverRadius": 8,
+			"modalColor": "#371f25ff", "modalOpacity": 0.92, "modalBlur": 0,
+			"windowControlsPos": "right",
+		}),
+		IsDefault:  true,
+		IsReadOnly: true,
+	},
+}
+
+func builtinTheme(id string) (models.Theme, bool) {
+	for _, t := range builtinThemes {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return models.Theme{}, false
+}
+
+// GetBuiltinThemes returns the read-only themes shipped with the app.
+func (s *Service) GetBuiltinThemes() []models.Theme {
+	out := make([]models.Theme, len(builtinThemes))
+	copy(out, builtinThemes)
+	return out
+}
+
+// GetThemes returns the custom themes.
+func (s *Service) GetThemes() ([]models.Theme, error) {
+	themes := []models.Theme{}
+	if err := s.db.Order("created_at").Find(&themes).Error; err != nil {
+		return []models.Theme{}, err
+	}
+	return themes, nil
+}
+
+// ListThemes returns the built-in themes followed by the custom ones.
+func (s *Service) ListThemes() ([]models.Theme, error) {
+	custom, err := s.GetThemes()
+	if err != nil {
+		return nil, err
+	}
+	return append(s.GetBuiltinThemes(), custom...), nil
+}
+
+// findTheme looks a theme up among built-in and custom themes.
+func (s *Service) findTheme(id string) (models.Theme, error) {
+	if t, ok := builtinTheme(id); ok {
+		return t, nil
+	}
+	var t models.Theme
+	if err := s.db.First(&t, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return t, fmt.Errorf("theme not found: %s", id)
+		}
+		return t, err
+	}
+	return t, nil
+}
+
+// CreateTheme adds a new custom theme
+func (s *Service) CreateTheme(theme models.Theme) (models.Theme, error) {
+	if strings.TrimSpace(theme.Name) == "" {
+		return theme, fmt.Errorf("主题名称不能为空")
+	}
+	if err := validateThemeData(theme.Data); err != nil {
+		return theme, err
+	}
+	// Generate unique ID for new theme
+	theme.ID = "theme-" + uuid.NewString()
+	theme.IsDefault = false
+	theme.IsReadOnly = false
+	err := s.db.Create(&theme).Error
+	return theme, err
+}
+
+// UpdateTheme modifies an existing custom theme
+func (s *Service) UpdateTheme(theme models.Theme) error {
+	if _, ok := builtinTheme(theme.ID); ok {
+		return fmt.Errorf("cannot modify built-in theme")
+	}
+	if strings.TrimSpace(theme.Name) == "" {
+		return fmt.Errorf("主题名称不能为空")
+	}
+	existing, err := s.findTheme(theme.ID)
+	if err != nil {
+		return err
+	}
+	if err := validateThemeData(theme.Data); err != nil {
+		return err
+	}
+	existing.Name = theme.Name
+	existing.Data = theme.Data
+	return s.db.Save(&existing).Error
+}
+
+// DeleteTheme removes a custom theme
+func (s *Service) DeleteTheme(themeID string) error {
+	if _, ok := builtinTheme(themeID); ok {
+		return fmt.Errorf("cannot delete default theme")
+	}
+	if err := s.db.Delete(&models.Theme{}, "id = ?", themeID).Error; err != nil {
+		return err
+	}
+
+	// If deleted theme was current, switch to light theme
+	setting, err := s.GetPlayerSetting()
+	if err != nil {
+		return err
+	}
+	if getConfigString(setting.Config, "currentThemeId", "") == themeID {
+		return s.SetCurrentTheme(defaultThemeID)
+	}
+	return nil
+}
+
+// SetCurrentTheme changes the active theme
+func (s *Service) SetCurrentTheme(themeID string) error {
+	if _, err := s.findTheme(themeID); err != nil {
+		return err
+	}
+	return s.SavePlayerSetting(models.PlayerSetting{Config: map[string]any{"currentThemeId": themeID}})
+}
+
+// ExportTheme returns a theme as .hbtheme JSON.
+func (s *Service) ExportTheme(themeID string) (string, error) {
+	t, err := s.findTheme(themeID)
+	if err != nil {
+		return "", err
+	}
+	file := ThemeFile{
+		Format:        themeFileFormat,
+		SchemaVersion: themeFileSchemaVersion,
+		AppVersion:    AppVersion,
+		Name:          t.Name,
+		Data:          json.RawMessage(t.Data),
+		ExportedAt:    time.Now(),
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ImportTheme creates a custom theme from .hbtheme JSON. A name already in use gets
+// a numeric suffix.
+func (s *Service) ImportTheme(content string) (models.Theme, error) {
+	var file ThemeFile
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(content, "\ufeff")), &file); err != nil {
+		return models.Theme{}, fmt.Errorf("主题文件格式错误: %w", err)
+	}
+	if file.Format != themeFileFormat {
+		return models.Theme{}, fmt.Errorf("不是 half-beat 主题文件")
+	}
+	if file.SchemaVersion < 1 || file.SchemaVersion > themeFileSchemaVersion {
+		return models.Theme{}, fmt.Errorf("不支持的主题文件版本: %d", file.SchemaVersion)
+	}
+	data := bytes.TrimSpace(file.Data)
+	// 兼容把配置写成 JSON 字符串的文件
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return models.Theme{}, fmt.Errorf("主题文件格式错误: %w", err)
+		}
+		data = []byte(str)
+	}
+
+	name := strings.TrimSpace(file.Name)
+	if name == "" {
+		name = "导入的主题"
+	}
+	themes, err := s.ListThemes()
+	if err != nil {
+		return models.Theme{}, err
+	}
+	used := make(map[string]bool, len(themes))
+	for _, t := range themes {
+		used[t.Name] = true
+	}
+	for n, base := 2, name; used[name]; n++ {
+		name = fmt.Sprintf("%s (%d)", base, n)
+	}
+	return s.CreateTheme(models.Theme{Name: name, Data: string(data)})
+}
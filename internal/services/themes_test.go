@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateThemeData(t *testing.T) {
+	if err := validateThemeData(`{"colorScheme":"dark","themeColor":"#66ccff","panelOpacity":0.5,"custom":"kept"}`); err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"", "null", "[]", `"x"`} {
+		if err := validateThemeData(data); err == nil {
+			t.Errorf("validateThemeData(%q) accepted", data)
+		}
+	}
+
+	err := validateThemeData(`{"colorScheme":"sepia","themeColor":"red","panelOpacity":0.1,"backgroundBlur":"5"}`)
+	var verr *ThemeValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want ThemeValidationError", err)
+	}
+	keys := pluck(verr.Fields, func(f SettingFieldError) string { return f.Key })
+	if got := strings.Join(keys, ","); got != "backgroundBlur,colorScheme,panelOpacity,themeColor" {
+		t.Fatalf("fields = %s", got)
+	}
+}
+
+func TestImportTheme(t *testing.T) {
+	s := newTestService(t)
+	const file = `{"format":"half-beat-theme","schemaVersion":1,"name":"海盐","data":{"colorScheme":"light","themeColor":"#336699"}}`
+
+	first, err := s.ImportTheme("\ufeff" + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Name != "海盐" || first.IsReadOnly || !strings.Contains(first.Data, "#336699") {
+		t.Fatalf("imported theme = %+v", first)
+	}
+	second, err := s.ImportTheme(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Name != "海盐 (2)" || second.ID == first.ID {
+		t.Fatalf("duplicate import = %+v", second)
+	}
+
+	// 与内置主题重名、数据写成 JSON 字符串
+	third, err := s.ImportTheme(`{"format":"half-beat-theme","schemaVersion":1,"name":"暗色","data":"{\"colorScheme\":\"dark\"}"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Name != "暗色 (2)" || third.Data != `{"colorScheme":"dark"}` {
+		t.Fatalf("string data import = %+v", third)
+	}
+
+	exported, err := s.ExportTheme(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := s.ImportTheme(exported); err != nil || again.Name != "海盐 (3)" {
+		t.Fatalf("re-import of export = %+v, %v", again, err)
+	}
+}
+
+func TestImportThemeRejectsInvalidFiles(t *testing.T) {
+	s := newTestService(t)
+	for name, content := range map[string]string{
+		"not json":       `theme`,
+		"wrong format":   `{"format":"other","schemaVersion":1,"data":{}}`,
+		"future version": `{"format":"half-beat-theme","schemaVersion":99,"data":{}}`,
+		"no version":     `{"format":"half-beat-theme","data":{}}`,
+		"invalid data":   `{"format":"half-beat-theme","schemaVersion":1,"data":{"themeColor":"red"}}`,
+		"no data":        `{"format":"half-beat-theme","schemaVersion":1}`,
+	} {
+		if _, err := s.ImportTheme(content); err == nil {
+			t.Errorf("%s: imported", name)
+		}
+	}
+	themes, err := s.ListThemes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(themes) != len(builtinThemes) {
+		t.Fatalf("themes after rejected imports = %d", len(themes))
+	}
+}
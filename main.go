@@ -8,8 +8,6 @@ import (
 	"path/filepath"
 
 	"half-beat-player/internal/db"
-	"half-beat-player/internal/models"
-	"half-beat-player/internal/proxy"
 	"half-beat-player/internal/services"
 
 	"github.com/wailsapp/wails/v2"
@@ -22,8 +20,6 @@ import (
 //go:embed frontend/dist
 var assets embed.FS
 
-var audioProxy *proxy.AudioProxy
-
 func main() {
 	if err := run(); err != nil {
 		log.Fatal(err)
@@ -51,27 +47,8 @@ func run() error {
 	dbPath := filepath.Join(dataDir, "half-beat.db")
 
 	gormDB, err := db.Open(dbPath, func(gdb *gorm.DB) error {
-		// 标准迁移
-		if err := gdb.AutoMigrate(
-			&models.StreamSource{},
-			&models.Song{},
-			&models.Favorite{},
-			&models.SongRef{},
-			&models.PlayerSetting{},
-			&models.LyricMapping{},
-			&models.Playlist{},
-			&models.LoginSession{},
-			&models.PlayHistory{},
-		); err != nil {
-			return err
-		}
-		// 确保 songs 表有 bvid 列（兼容旧数据库）
-		if !gdb.Migrator().HasColumn(&models.Song{}, "bvid") {
-			if err := gdb.Migrator().AddColumn(&models.Song{}, "bvid"); err != nil {
-				return err
-			}
-		}
-		return nil
+		// 版本化迁移，升级前自动备份到 backups 目录
+		return db.Migrate(gdb, services.DBBackupPath(dataDir, "pre-migrate"))
 	})
 	if err != nil {
 		return err
@@ -82,9 +59,6 @@ func run() error {
 		return err
 	}
 
-	// Initialize audio proxy
-	audioProxy = proxy.NewAudioProxy(9999, backend.GetHTTPClient(), dataDir)
-
 	return wails.Run(&options.App{
 		Title:      "half-beat",
 		Width:      1280,
@@ -97,15 +71,15 @@ func run() error {
 		BackgroundColour: &options.RGBA{R: 30, G: 30, B: 30, A: 1},
 		OnStartup: func(ctx context.Context) {
 			backend.SetAppContext(ctx)
-			// Start audio proxy on app startup
-			if err := audioProxy.Start(); err != nil {
+			// Start audio proxy on app startup; failures are reported to the UI
+			if err := backend.StartAudioProxy(); err != nil {
 				log.Printf("Failed to start audio proxy: %v", err)
 			}
 		},
 		OnShutdown: func(ctx context.Context) {
 			log.Println("OnShutdown called")
 			// Stop audio proxy on app shutdown
-			_ = audioProxy.Stop()
+			_ = backend.StopAudioProxy()
 		},
 		Bind: []interface{}{backend},
 	})